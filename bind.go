@@ -0,0 +1,346 @@
+package kid
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/mojixcoder/kid/serializer"
+)
+
+// Binding sources, which are also the struct tags read by Context.Bind.
+const (
+	BindPath   = "path"
+	BindQuery  = "query"
+	BindHeader = "header"
+	BindCookie = "cookie"
+	BindForm   = "form"
+	BindBody   = "body"
+)
+
+// bindingSources are the binding struct tags, in the order of precedence when a field has more than one of them.
+var bindingSources = []string{BindPath, BindQuery, BindHeader, BindCookie, BindForm}
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	timeType            = reflect.TypeOf(time.Time{})
+	durationType        = reflect.TypeOf(time.Duration(0))
+)
+
+// BindingError is returned when a request value can't be bound to a struct field.
+type BindingError struct {
+	// Field is the name of the struct field, nested fields are separated by dots, e.g. Filter.Page.
+	// It's empty for body decoding errors.
+	Field string
+
+	// Source is where the value comes from, e.g. query or body.
+	Source string
+
+	// Key is the name of the value in its source, e.g. page in `query:"page"`.
+	Key string
+
+	// Err is the conversion or decoding error.
+	Err error
+}
+
+// Verifying interface compliance.
+var _ error = (*BindingError)(nil)
+
+// Error implements the error interface.
+func (e *BindingError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("binding %s: %s", e.Source, e.Err)
+	}
+
+	return fmt.Sprintf("binding %s %q to field %s: %s", e.Source, e.Key, e.Field, e.Err)
+}
+
+// Unwrap returns the conversion or decoding error.
+func (e *BindingError) Unwrap() error {
+	return e.Err
+}
+
+// Bind binds the request to the given struct, which must be a pointer.
+//
+// The body is decoded according to the request's Content-Type first. Bodies are read with the serializer registered
+// for the Content-Type, e.g. JSON or XML, and form bodies are bound to the fields with form tags. Then fields are bound with path, query, header and cookie tags,
+// e.g. `path:"id"`, `query:"page"`, `header:"X-Tenant"` or `cookie:"sid"`.
+//
+// Strings, bools, numbers, time.Duration, time.Time, encoding.TextUnmarshaler implementations,
+// pointers and slices of them are supported. time.Time uses RFC 3339 unless a layout is given with the format tag.
+// Embedded structs and struct fields without binding tags are bound recursively.
+// Fields without a value in the request are left untouched.
+//
+// Multipart forms are parsed with Context.MultipartForm, so the route's multipart limits are applied.
+//
+// Returns a *BindingError if a value can't be converted, or an *HTTPError with 415 status code if the body's
+// Content-Type is not supported, or 413 if the serializer returns a serializer.BodyTooLargeError.
+// Panics if out is not a pointer to a struct or a field's type is not supported.
+func (c *Context) Bind(out any) error {
+	v := reflect.ValueOf(out)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		panic("bind target must be a non-nil pointer to a struct")
+	}
+
+	isForm, err := c.bindBody(out)
+	if err != nil {
+		return err
+	}
+
+	return c.bindFields(v.Elem(), "", isForm)
+}
+
+// Validate validates the given struct with Kid's validator.
+func (c *Context) Validate(v any) error {
+	return c.kid.validator.Validate(v)
+}
+
+// BindAndValidate binds the request to the given struct with Context.Bind and then validates it with Kid's validator.
+//
+// The default validator returns validator.ValidationErrors, which the default error handler renders
+// with 422 status code and the list of field errors.
+func (c *Context) BindAndValidate(out any) error {
+	if err := c.Bind(out); err != nil {
+		return err
+	}
+
+	return c.Validate(out)
+}
+
+// bindBody decodes the request body according to its Content-Type and reports whether it's a form.
+func (c *Context) bindBody(out any) (bool, error) {
+	// The body is dropped after a multipart form fails to parse, the error is returned instead.
+	if c.multipartErr != nil {
+		return false, c.multipartErr
+	}
+
+	if c.request.ContentLength == 0 || c.request.Body == nil || c.request.Body == http.NoBody {
+		return false, nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(c.GetRequestHeader(contentTypeHeader))
+	if err != nil {
+		return false, NewHTTPError(http.StatusUnsupportedMediaType, "").WithCause(err)
+	}
+
+	switch mediaType {
+	case "application/x-www-form-urlencoded":
+		if err = c.request.ParseForm(); err == nil {
+			return true, nil
+		}
+	case "multipart/form-data":
+		// Multipart errors are already HTTP errors, with the status code of the violated limit.
+		if _, err = c.MultipartForm(); err != nil {
+			return false, err
+		}
+		return true, nil
+	default:
+		s, ok := c.kid.serializerFor(mediaType)
+		if !ok {
+			return false, unsupportedMediaTypeError(mediaType)
+		}
+
+		err = s.Read(c.request, out)
+	}
+
+	if err != nil {
+		var tooLargeErr *serializer.BodyTooLargeError
+		if errors.As(err, &tooLargeErr) {
+			return false, NewHTTPError(http.StatusRequestEntityTooLarge, "").WithCause(err)
+		}
+
+		return false, &BindingError{Source: BindBody, Err: err}
+	}
+
+	return false, nil
+}
+
+// bindFields binds the struct fields which have binding tags.
+func (c *Context) bindFields(v reflect.Value, prefix string, isForm bool) error {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		// Exported fields of embedded structs are promoted, even if the embedded type itself is unexported.
+		field := t.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+
+		name := prefix + field.Name
+
+		source, key, ok := bindingTag(field)
+		if !ok {
+			if isNestedStruct(field.Type) {
+				// Fields of embedded structs are named as if they were the parent's own fields.
+				nestedPrefix := name + "."
+				if field.Anonymous {
+					nestedPrefix = prefix
+				}
+
+				if err := c.bindFields(v.Field(i), nestedPrefix, isForm); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		values := c.bindingValues(source, key, isForm)
+		if len(values) == 0 {
+			continue
+		}
+
+		if err := setField(v.Field(i), values, field.Tag.Get("format")); err != nil {
+			if _, ok := err.(unsupportedTypeError); ok {
+				panic(fmt.Sprintf("unsupported type %s of field %s", field.Type, name))
+			}
+
+			return &BindingError{Field: name, Source: source, Key: key, Err: err}
+		}
+	}
+
+	return nil
+}
+
+// bindingValues returns the request values of the given source and key.
+func (c *Context) bindingValues(source, key string, isForm bool) []string {
+	switch source {
+	case BindPath:
+		if value, ok := c.params[key]; ok {
+			return []string{value}
+		}
+	case BindQuery:
+		return c.QueryParams()[key]
+	case BindHeader:
+		return c.request.Header.Values(key)
+	case BindCookie:
+		var values []string
+		for _, cookie := range c.request.Cookies() {
+			if cookie.Name == key {
+				values = append(values, cookie.Value)
+			}
+		}
+		return values
+	case BindForm:
+		if isForm {
+			return c.request.PostForm[key]
+		}
+	}
+
+	return nil
+}
+
+// bindingTag returns the first binding tag of the field.
+func bindingTag(field reflect.StructField) (string, string, bool) {
+	for _, source := range bindingSources {
+		if key, ok := field.Tag.Lookup(source); ok && key != "-" {
+			return source, key, true
+		}
+	}
+
+	return "", "", false
+}
+
+// isNestedStruct checks if the type is a struct which its fields should be bound.
+func isNestedStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t != timeType && !reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+// unsupportedTypeError is returned by setField when the field's type is not supported.
+type unsupportedTypeError struct{}
+
+// Error implements the error interface.
+func (unsupportedTypeError) Error() string {
+	return "unsupported type"
+}
+
+// setField sets the values to the field.
+//
+// Slices get all of the values, other types only get the first one.
+func setField(v reflect.Value, values []string, format string) error {
+	if v.Kind() == reflect.Slice && !v.Addr().Type().Implements(textUnmarshalerType) {
+		slice := reflect.MakeSlice(v.Type(), len(values), len(values))
+
+		for i, value := range values {
+			if err := setValue(slice.Index(i), value, format); err != nil {
+				return err
+			}
+		}
+
+		v.Set(slice)
+		return nil
+	}
+
+	return setValue(v, values[0], format)
+}
+
+// setValue converts the string value to the type of v and sets it.
+func setValue(v reflect.Value, value, format string) error {
+	if v.Kind() == reflect.Pointer {
+		ptr := reflect.New(v.Type().Elem())
+		if err := setValue(ptr.Elem(), value, format); err != nil {
+			return err
+		}
+
+		v.Set(ptr)
+		return nil
+	}
+
+	switch {
+	case v.Type() == timeType && format != "":
+		t, err := time.Parse(format, value)
+		if err != nil {
+			return err
+		}
+
+		v.Set(reflect.ValueOf(t))
+		return nil
+	case v.Addr().Type().Implements(textUnmarshalerType):
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	default:
+		return unsupportedTypeError{}
+	}
+
+	return nil
+}
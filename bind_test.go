@@ -0,0 +1,372 @@
+package kid
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mojixcoder/kid/serializer"
+	"github.com/mojixcoder/kid/validator"
+	"github.com/stretchr/testify/assert"
+)
+
+type bindPagination struct {
+	Page    int  `query:"page"`
+	PerPage *int `query:"per_page"`
+}
+
+type bindFilter struct {
+	Tags   []string  `query:"tag"`
+	Since  time.Time `query:"since" format:"2006-01-02"`
+	Until  time.Time `query:"until"`
+	Active bool      `query:"active"`
+}
+
+type bindRequest struct {
+	bindPagination
+
+	ID      uint64        `path:"id"`
+	Tenant  string        `header:"X-Tenant"`
+	Session string        `cookie:"sid"`
+	Timeout time.Duration `query:"timeout"`
+	Score   float64       `query:"score"`
+	IP      netip.Addr    `header:"X-Real-IP"`
+	Filter  bindFilter
+	Ignored string `query:"-"`
+	private string `query:"private"`
+}
+
+type bindBody struct {
+	ID    int    `path:"id" json:"id" xml:"id"`
+	Name  string `json:"name" xml:"name" form:"name"`
+	Email string `json:"email" xml:"email" form:"email"`
+	Tags  []int  `form:"tag"`
+}
+
+func newBindContext(req *http.Request) *Context {
+	k := New()
+	c := k.NewContext(req, httptest.NewRecorder())
+	c.params = Params{"id": "12"}
+	return c
+}
+
+func TestContext_Bind(t *testing.T) {
+	req := httptest.NewRequest(
+		http.MethodGet,
+		"/users/12?page=2&per_page=50&tag=a&tag=b&since=2024-01-02&until=2024-01-02T15:04:05Z&active=true&timeout=1m30s&score=9.5&private=x",
+		nil,
+	)
+	req.Header.Set("X-Tenant", "acme")
+	req.Header.Set("X-Real-IP", "192.0.2.1")
+	req.AddCookie(&http.Cookie{Name: "sid", Value: "secret"})
+
+	var out bindRequest
+	err := newBindContext(req).Bind(&out)
+	assert.NoError(t, err)
+
+	perPage := 50
+
+	assert.Equal(t, bindRequest{
+		bindPagination: bindPagination{Page: 2, PerPage: &perPage},
+		ID:             12,
+		Tenant:         "acme",
+		Session:        "secret",
+		Timeout:        90 * time.Second,
+		Score:          9.5,
+		IP:             netip.MustParseAddr("192.0.2.1"),
+		Filter: bindFilter{
+			Tags:   []string{"a", "b"},
+			Since:  time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+			Until:  time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC),
+			Active: true,
+		},
+	}, out)
+}
+
+func TestContext_Bind_KeepsMissingValues(t *testing.T) {
+	out := bindPagination{Page: 1}
+
+	err := newBindContext(httptest.NewRequest(http.MethodGet, "/", nil)).Bind(&out)
+	assert.NoError(t, err)
+	assert.Equal(t, bindPagination{Page: 1}, out)
+}
+
+func TestContext_Bind_Errors(t *testing.T) {
+	testCases := []struct {
+		name          string
+		path          string
+		expectedField string
+		expectedKey   string
+		expectedErr   string
+	}{
+		{
+			name:          "int",
+			path:          "/?page=abc",
+			expectedField: "Page",
+			expectedKey:   "page",
+			expectedErr:   `binding query "page" to field Page: strconv.ParseInt: parsing "abc": invalid syntax`,
+		},
+		{
+			name:          "nested",
+			path:          "/?since=yesterday",
+			expectedField: "Filter.Since",
+			expectedKey:   "since",
+			expectedErr:   `binding query "since" to field Filter.Since: parsing time "yesterday" as "2006-01-02": cannot parse "yesterday" as "2006"`,
+		},
+		{
+			name:          "duration",
+			path:          "/?timeout=soon",
+			expectedField: "Timeout",
+			expectedKey:   "timeout",
+			expectedErr:   `binding query "timeout" to field Timeout: time: invalid duration "soon"`,
+		},
+		{
+			name:          "bool",
+			path:          "/?active=yes",
+			expectedField: "Filter.Active",
+			expectedKey:   "active",
+			expectedErr:   `binding query "active" to field Filter.Active: strconv.ParseBool: parsing "yes": invalid syntax`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bindRequest
+			err := newBindContext(httptest.NewRequest(http.MethodGet, tc.path, nil)).Bind(&out)
+
+			var bindingErr *BindingError
+			assert.ErrorAs(t, err, &bindingErr)
+			assert.Equal(t, tc.expectedField, bindingErr.Field)
+			assert.Equal(t, BindQuery, bindingErr.Source)
+			assert.Equal(t, tc.expectedKey, bindingErr.Key)
+			assert.EqualError(t, err, tc.expectedErr)
+		})
+	}
+
+	var out bindRequest
+	err := newBindContext(httptest.NewRequest(http.MethodGet, "/?page=99999999999999999999", nil)).Bind(&out)
+	assert.ErrorIs(t, err, strconv.ErrRange)
+}
+
+func TestContext_Bind_Body(t *testing.T) {
+	testCases := []struct {
+		name        string
+		contentType string
+		body        string
+		expected    bindBody
+	}{
+		{
+			name:        "json",
+			contentType: "application/json; charset=utf-8",
+			body:        `{"id": 1, "name": "John", "email": "john@example.com"}`,
+			expected:    bindBody{ID: 12, Name: "John", Email: "john@example.com"},
+		},
+		{
+			name:        "xml",
+			contentType: "application/xml",
+			body:        `<bindBody><name>John</name><email>john@example.com</email></bindBody>`,
+			expected:    bindBody{ID: 12, Name: "John", Email: "john@example.com"},
+		},
+		{
+			name:        "form",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "name=John&email=john%40example.com&tag=1&tag=2",
+			expected:    bindBody{ID: 12, Name: "John", Email: "john@example.com", Tags: []int{1, 2}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/users/12", strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", tc.contentType)
+
+			var out bindBody
+			err := newBindContext(req).Bind(&out)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, out)
+		})
+	}
+}
+
+func TestContext_Bind_Multipart(t *testing.T) {
+	var body bytes.Buffer
+
+	writer := multipart.NewWriter(&body)
+	assert.NoError(t, writer.WriteField("name", "John"))
+	assert.NoError(t, writer.WriteField("tag", "3"))
+	assert.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/users/12", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	var out bindBody
+	err := newBindContext(req).Bind(&out)
+
+	assert.NoError(t, err)
+	assert.Equal(t, bindBody{ID: 12, Name: "John", Tags: []int{3}}, out)
+}
+
+func TestContext_Bind_BodyErrors(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name": 1}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	var out bindBody
+	err := newBindContext(req).Bind(&out)
+
+	var bindingErr *BindingError
+	assert.ErrorAs(t, err, &bindingErr)
+	assert.Equal(t, BindBody, bindingErr.Source)
+	assert.Empty(t, bindingErr.Field)
+
+	for _, contentType := range []string{"text/csv", "", "invalid/"} {
+		req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("a,b"))
+		req.Header.Set("Content-Type", contentType)
+
+		err = newBindContext(req).Bind(&out)
+
+		var httpErr *HTTPError
+		assert.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, http.StatusUnsupportedMediaType, httpErr.Status)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("tag=x"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	err = newBindContext(req).Bind(&out)
+	assert.ErrorAs(t, err, &bindingErr)
+	assert.Equal(t, BindForm, bindingErr.Source)
+	assert.Equal(t, "Tags", bindingErr.Field)
+
+	k := New()
+	k.ApplyOptions(WithJSONSerializer(serializer.NewJSONSerializerWithConfig(serializer.JSONConfig{MaxBodySize: 8})))
+
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name": "Mojix"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	err = k.NewContext(req, httptest.NewRecorder()).Bind(&out)
+
+	var httpErr *HTTPError
+	assert.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, httpErr.Status)
+
+	var tooLargeErr *serializer.BodyTooLargeError
+	assert.ErrorAs(t, err, &tooLargeErr)
+}
+
+func TestContext_Bind_Panics(t *testing.T) {
+	c := newBindContext(httptest.NewRequest(http.MethodGet, "/?value=1", nil))
+
+	for _, out := range []any{nil, bindPagination{}, (*bindPagination)(nil), new(int)} {
+		assert.PanicsWithValue(t, "bind target must be a non-nil pointer to a struct", func() {
+			_ = c.Bind(out)
+		})
+	}
+
+	var out struct {
+		Value map[string]string `query:"value"`
+	}
+
+	assert.PanicsWithValue(t, "unsupported type map[string]string of field Value", func() {
+		_ = c.Bind(&out)
+	})
+}
+
+func TestDefaultErrorHandler_BindingError(t *testing.T) {
+	k := New()
+
+	k.Get("/", WrapErrHandlerFunc(func(c *Context) error {
+		var out bindPagination
+		return c.Bind(&out)
+	}))
+
+	res := httptest.NewRecorder()
+	k.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/?page=x", nil))
+
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+	assert.Equal(t, `{"message":"binding query \"page\" to field Page: strconv.ParseInt: parsing \"x\": invalid syntax"}`+"\n", res.Body.String())
+}
+
+func TestBindingError(t *testing.T) {
+	err := &BindingError{Source: BindBody, Err: errors.New("unexpected EOF")}
+	assert.EqualError(t, err, "binding body: unexpected EOF")
+	assert.Equal(t, err.Err, errors.Unwrap(err))
+}
+
+type validatedUser struct {
+	ID    int    `path:"id" json:"-" validate:"min=1"`
+	Name  string `json:"name" validate:"required,max=8"`
+	Email string `json:"email" validate:"required,email"`
+}
+
+func TestContext_BindAndValidate(t *testing.T) {
+	testCases := []struct {
+		name         string
+		body         string
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "valid",
+			body:         `{"name": "John", "email": "john@example.com"}`,
+			expectedCode: http.StatusOK,
+			expectedBody: `{"ID":12,"name":"John","email":"john@example.com"}`,
+		},
+		{
+			name:         "invalid",
+			body:         `{"name": "Johnathan Doe", "email": "john"}`,
+			expectedCode: http.StatusUnprocessableEntity,
+			expectedBody: `{"errors":[` +
+				`{"field":"name","rule":"max","param":"8","message":"must be at most 8 characters long"},` +
+				`{"field":"email","rule":"email","message":"must be a valid email address"}` +
+				`],"message":"Unprocessable Entity"}`,
+		},
+		{
+			name:         "binding_error",
+			body:         `{"name": 1}`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"message":"binding body: json: cannot unmarshal number into Go struct field validatedUser.name of type string"}`,
+		},
+	}
+
+	k := New()
+
+	k.Post("/users/{id}", WrapErrHandlerFunc(func(c *Context) error {
+		var user validatedUser
+		if err := c.BindAndValidate(&user); err != nil {
+			return err
+		}
+
+		c.JSON(http.StatusOK, Map{"ID": user.ID, "name": user.Name, "email": user.Email})
+		return nil
+	}))
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/users/12", strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			res := httptest.NewRecorder()
+
+			k.ServeHTTP(res, req)
+
+			assert.Equal(t, tc.expectedCode, res.Code)
+			assert.JSONEq(t, tc.expectedBody, res.Body.String())
+		})
+	}
+}
+
+func TestContext_Validate(t *testing.T) {
+	c := newBindContext(httptest.NewRequest(http.MethodGet, "/", nil))
+
+	err := c.Validate(validatedUser{ID: 1, Name: "John"})
+	assert.Equal(t, validator.ValidationErrors{{Field: "email", Rule: "required", Message: "is required"}}, err)
+
+	assert.NoError(t, c.Validate(validatedUser{ID: 1, Name: "John", Email: "john@example.com"}))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"net/url"
+	"strings"
 	"sync"
 )
 
@@ -19,6 +20,14 @@ type Context struct {
 	kid       *Kid
 	lock      sync.Mutex
 	routeName string
+	err       error
+	options   *routeOptions
+
+	// multipartErr is the error of parsing the multipart form, which is returned by later calls of MultipartForm.
+	multipartErr error
+
+	// sse is the SSE writer of the request, which is closed after the handler returns.
+	sse *SSEWriter
 }
 
 // newContext returns a new empty context.
@@ -32,8 +41,18 @@ func (c *Context) reset(request *http.Request, response http.ResponseWriter) {
 	c.request = request
 	c.response = newResponse(response)
 	c.storage = make(Map)
-	c.params = make(Params)
 	c.routeName = ""
+	c.err = nil
+	c.options = nil
+	c.multipartErr = nil
+	c.sse = nil
+
+	// Path parameters storage is reused between requests.
+	if c.params == nil {
+		c.params = make(Params)
+	} else {
+		clear(c.params)
+	}
 }
 
 // setParams sets request's path parameters.
@@ -41,6 +60,11 @@ func (c *Context) setParams(params Params) {
 	c.params = params
 }
 
+// setRouteOptions sets the options of the matched route.
+func (c *Context) setRouteOptions(options *routeOptions) {
+	c.options = options
+}
+
 // setRouteName sets the route name.
 func (c *Context) setRouteName(name string) {
 	c.routeName = name
@@ -57,11 +81,15 @@ func (c *Context) Response() ResponseWriter {
 }
 
 // Param returns path parameter's value.
+//
+// Omitted optional path parameters return their default value, e.g. 1 for {page=1}.
 func (c *Context) Param(name string) string {
 	return c.params[name]
 }
 
 // Params returns all of the path parameters.
+//
+// The returned map is reused after the request is finished, use Context.Clone if it's needed in background jobs.
 func (c *Context) Params() Params {
 	return c.params
 }
@@ -119,7 +147,7 @@ func (c *Context) mustWrite(blob []byte) {
 func (c *Context) JSON(code int, obj any) {
 	c.writeContentType("application/json")
 	c.response.WriteHeader(code)
-	c.kid.jsonSerializer.Write(c.Response(), obj, "")
+	c.kid.serializers[MIMEApplicationJSON].Write(c.Response(), obj, "")
 }
 
 // JSONIndent sends JSON response with the given status code.
@@ -127,7 +155,7 @@ func (c *Context) JSON(code int, obj any) {
 func (c *Context) JSONIndent(code int, obj any, indent string) {
 	c.writeContentType("application/json")
 	c.response.WriteHeader(code)
-	c.kid.jsonSerializer.Write(c.Response(), obj, indent)
+	c.kid.serializers[MIMEApplicationJSON].Write(c.Response(), obj, indent)
 }
 
 // JSONByte sends JSON response with the given status code.
@@ -141,7 +169,7 @@ func (c *Context) JSONByte(code int, blob []byte) {
 // ReadJSON reads request's body as JSON and stores it in the given object.
 // The object must be a pointer.
 func (c *Context) ReadJSON(out any) error {
-	return c.kid.jsonSerializer.Read(c.Request(), out)
+	return c.kid.serializers[MIMEApplicationJSON].Read(c.Request(), out)
 }
 
 // XML sends XML response with the given status code.
@@ -150,7 +178,7 @@ func (c *Context) ReadJSON(out any) error {
 func (c *Context) XML(code int, obj any) {
 	c.writeContentType("application/xml")
 	c.response.WriteHeader(code)
-	c.kid.xmlSerializer.Write(c.Response(), obj, "")
+	c.kid.serializers[MIMEApplicationXML].Write(c.Response(), obj, "")
 }
 
 // XMLIndent sends XML response with the given status code.
@@ -158,7 +186,7 @@ func (c *Context) XML(code int, obj any) {
 func (c *Context) XMLIndent(code int, obj any, indent string) {
 	c.writeContentType("application/xml")
 	c.response.WriteHeader(code)
-	c.kid.xmlSerializer.Write(c.Response(), obj, indent)
+	c.kid.serializers[MIMEApplicationXML].Write(c.Response(), obj, indent)
 }
 
 // XMLByte sends XML response with the given status code.
@@ -172,7 +200,107 @@ func (c *Context) XMLByte(code int, blob []byte) {
 // ReadXML reads request's body as XML and stores it in the given object.
 // The object must be a pointer.
 func (c *Context) ReadXML(out any) error {
-	return c.kid.xmlSerializer.Read(c.Request(), out)
+	return c.kid.serializers[MIMEApplicationXML].Read(c.Request(), out)
+}
+
+// MessagePack sends MessagePack response with the given status code.
+func (c *Context) MessagePack(code int, obj any) {
+	c.writeContentType(MIMEApplicationMsgPack)
+	c.response.WriteHeader(code)
+	c.kid.serializers[MIMEApplicationMsgPack].Write(c.Response(), obj, "")
+}
+
+// ReadMessagePack reads request's body as MessagePack and stores it in the given object.
+// The object must be a pointer.
+func (c *Context) ReadMessagePack(out any) error {
+	return c.kid.serializers[MIMEApplicationMsgPack].Read(c.Request(), out)
+}
+
+// CBOR sends CBOR response with the given status code.
+func (c *Context) CBOR(code int, obj any) {
+	c.writeContentType(MIMEApplicationCBOR)
+	c.response.WriteHeader(code)
+	c.kid.serializers[MIMEApplicationCBOR].Write(c.Response(), obj, "")
+}
+
+// ReadCBOR reads request's body as CBOR and stores it in the given object.
+// The object must be a pointer.
+func (c *Context) ReadCBOR(out any) error {
+	return c.kid.serializers[MIMEApplicationCBOR].Read(c.Request(), out)
+}
+
+// YAML sends YAML response with the given status code.
+func (c *Context) YAML(code int, obj any) {
+	c.writeContentType(MIMEApplicationYAML)
+	c.response.WriteHeader(code)
+	c.kid.serializers[MIMEApplicationYAML].Write(c.Response(), obj, "")
+}
+
+// ReadYAML reads request's body as YAML and stores it in the given object.
+// The object must be a pointer.
+func (c *Context) ReadYAML(out any) error {
+	return c.kid.serializers[MIMEApplicationYAML].Read(c.Request(), out)
+}
+
+// Protobuf sends Protocol Buffers response with the given status code.
+//
+// It's rendered like Context.Render, so messages which the protobuf serializer can't write are handled by the error handler.
+func (c *Context) Protobuf(code int, msg ProtoMessage) {
+	c.Render(code, MIMEApplicationXProtobuf, msg)
+}
+
+// ReadProtobuf reads request's body as Protocol Buffers and stores it in the given message.
+func (c *Context) ReadProtobuf(msg ProtoMessage) error {
+	return c.kid.serializers[MIMEApplicationXProtobuf].Read(c.Request(), msg)
+}
+
+// Problem sends an RFC 7807 problem details response with the given status code.
+//
+// The problem is written as application/problem+xml with the XML serializer if the request prefers XML,
+// otherwise it's written as application/problem+json with the JSON serializer.
+// The problem's status and title default to the status code and its status text.
+func (c *Context) Problem(code int, problem Problem) {
+	if problem.Status == 0 {
+		problem.Status = code
+	}
+
+	if problem.Title == "" {
+		problem.Title = http.StatusText(code)
+	}
+
+	if c.prefersXML() {
+		c.writeContentType("application/problem+xml")
+		c.response.WriteHeader(code)
+		c.kid.serializers[MIMEApplicationXML].Write(c.Response(), problem, "")
+		return
+	}
+
+	c.writeContentType("application/problem+json")
+	c.response.WriteHeader(code)
+	c.kid.serializers[MIMEApplicationJSON].Write(c.Response(), problem, "")
+}
+
+// prefersXML checks if XML is preferred over JSON by the request's Accept header.
+//
+// The first media range which mentions either of them wins.
+func (c *Context) prefersXML() bool {
+	if c.request == nil {
+		return false
+	}
+
+	for _, mediaRange := range strings.Split(c.GetRequestHeader("Accept"), ",") {
+		mediaType, _, _ := strings.Cut(mediaRange, ";")
+		mediaType = strings.TrimSpace(mediaType)
+
+		switch {
+		case strings.HasSuffix(mediaType, "/xml") || strings.HasSuffix(mediaType, "+xml"):
+			return true
+		case strings.HasSuffix(mediaType, "/json") || strings.HasSuffix(mediaType, "+json"):
+			return false
+		}
+	}
+
+	return false
 }
 
 // HTML sends HTML response with the given status code.
@@ -240,6 +368,16 @@ func (c *Context) writeContentType(contentType string) {
 	}
 }
 
+// BeforeWriteHeader registers a function which is called just before the response's status code and headers are written,
+// so it can still change the headers, e.g. to set a cookie. Functions are called in the order they are registered.
+//
+// They are not called if the response is hijacked or written without Kid's response writer.
+func (c *Context) BeforeWriteHeader(f func()) {
+	panicIfNil(f, "function cannot be nil")
+
+	c.response.(*response).onBeforeWriteHeader(f)
+}
+
 // Set sets a key-value pair to current request's context.
 func (c *Context) Set(key string, val any) {
 	c.lock.Lock()
@@ -269,6 +407,10 @@ func (c *Context) Clone() *Context {
 		kid:       c.kid,
 		lock:      sync.Mutex{},
 		routeName: c.routeName,
+		err:       c.err,
+		options:   c.options,
+
+		multipartErr: c.multipartErr,
 	}
 
 	// Copy path params.
@@ -288,7 +430,37 @@ func (c *Context) Clone() *Context {
 	return &ctx
 }
 
+// Error reports an error which occurred while handling the request.
+//
+// The error is rendered by the error handler after the route handler returns, unless the response is already written.
+// Reporting another error replaces the previous one.
+func (c *Context) Error(err error) {
+	c.err = err
+}
+
+// HandleError reports the error and renders it with the error handler right away, unless the response is already written.
+//
+// It's useful for middlewares which don't call the next handler, e.g. recovery middlewares.
+func (c *Context) HandleError(err error) {
+	c.Error(err)
+	c.kid.handleError(c)
+}
+
+// Err returns the error reported while handling the request, nil if no error is reported.
+//
+// It can be used by middlewares, e.g. for logging.
+func (c *Context) Err() error {
+	return c.err
+}
+
 // Debug returns whether we are in debug mode or not.
 func (c *Context) Debug() bool {
 	return c.kid.Debug()
 }
+
+// URLFor builds the URL of the route with the given name.
+//
+// Path parameters must be given in key-value pairs, e.g. URLFor("user", "id", "1").
+func (c *Context) URLFor(name string, params ...string) (string, error) {
+	return c.kid.URL(name, params...)
+}
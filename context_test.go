@@ -13,7 +13,9 @@ import (
 	"testing"
 
 	htmlrenderer "github.com/mojixcoder/kid/html_renderer"
+	"github.com/mojixcoder/kid/serializer"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
 type errWriter struct {
@@ -155,6 +157,23 @@ func TestContext_QueryParamMultiple(t *testing.T) {
 	assert.Equal(t, []string{}, ctx.QueryParamMultiple("does_not_exist"))
 }
 
+func TestContext_BeforeWriteHeader(t *testing.T) {
+	res := httptest.NewRecorder()
+	ctx := New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), res)
+
+	ctx.BeforeWriteHeader(func() {
+		ctx.SetResponseHeader("X-Hook", "called")
+	})
+
+	ctx.String(http.StatusOK, "body")
+
+	assert.Equal(t, "called", res.Header().Get("X-Hook"))
+
+	assert.PanicsWithValue(t, "function cannot be nil", func() {
+		ctx.BeforeWriteHeader(nil)
+	})
+}
+
 func TestContext_Set(t *testing.T) {
 	ctx := newContext(New())
 	ctx.reset(nil, nil)
@@ -421,6 +440,196 @@ func TestContext_XMLByte(t *testing.T) {
 	assert.Equal(t, "<person><name>foo</name><age>1999</age></person>", res.Body.String())
 }
 
+func TestContext_MessagePack(t *testing.T) {
+	ctx := newContext(New())
+
+	res := httptest.NewRecorder()
+
+	ctx.reset(nil, res)
+
+	p := person{Name: "foo", Age: 1999}
+	ctx.MessagePack(http.StatusCreated, &p)
+
+	assert.Equal(t, http.StatusCreated, res.Code)
+	assert.Equal(t, "application/msgpack", res.Header().Get("Content-Type"))
+	assert.Equal(t, []byte("\x82\xa4name\xa3foo\xa3age\xcd\x07\xcf"), res.Body.Bytes())
+
+	res = httptest.NewRecorder()
+
+	ctx.reset(nil, res)
+
+	assert.Panics(t, func() {
+		ctx.MessagePack(http.StatusCreated, make(chan bool))
+	})
+}
+
+func TestContext_ReadMessagePack(t *testing.T) {
+	ctx := newContext(New())
+
+	req := httptest.NewRequest(http.MethodGet, "/", strings.NewReader("\x82\xa4name\xa5Mojix\xa3age\x16"))
+
+	ctx.reset(req, nil)
+
+	var p person
+	err := ctx.ReadMessagePack(&p)
+	assert.NoError(t, err)
+
+	assert.Equal(t, person{Name: "Mojix", Age: 22}, p)
+
+	req = httptest.NewRequest(http.MethodGet, "/", strings.NewReader("\x82\xa4name\xa5Mojix\xa3age"))
+
+	ctx.reset(req, nil)
+
+	var p2 person
+	err = ctx.ReadMessagePack(&p2)
+
+	assert.Error(t, err)
+}
+
+func TestContext_CBOR(t *testing.T) {
+	ctx := newContext(New())
+
+	res := httptest.NewRecorder()
+
+	ctx.reset(nil, res)
+
+	p := person{Name: "foo", Age: 1999}
+	ctx.CBOR(http.StatusCreated, &p)
+
+	assert.Equal(t, http.StatusCreated, res.Code)
+	assert.Equal(t, "application/cbor", res.Header().Get("Content-Type"))
+	assert.Equal(t, []byte("\xa2cage\x19\x07\xcfdnamecfoo"), res.Body.Bytes())
+
+	res = httptest.NewRecorder()
+
+	ctx.reset(nil, res)
+
+	assert.Panics(t, func() {
+		ctx.CBOR(http.StatusCreated, make(chan bool))
+	})
+}
+
+func TestContext_ReadCBOR(t *testing.T) {
+	ctx := newContext(New())
+
+	req := httptest.NewRequest(http.MethodGet, "/", strings.NewReader("\xa2dnameeMojixcage\x16"))
+
+	ctx.reset(req, nil)
+
+	var p person
+	err := ctx.ReadCBOR(&p)
+	assert.NoError(t, err)
+
+	assert.Equal(t, person{Name: "Mojix", Age: 22}, p)
+
+	req = httptest.NewRequest(http.MethodGet, "/", strings.NewReader("\xa2dnameeMojixcage"))
+
+	ctx.reset(req, nil)
+
+	var p2 person
+	err = ctx.ReadCBOR(&p2)
+
+	assert.Error(t, err)
+}
+
+func TestContext_YAML(t *testing.T) {
+	ctx := newContext(New())
+
+	res := httptest.NewRecorder()
+
+	ctx.reset(nil, res)
+
+	p := person{Name: "foo", Age: 1999}
+	ctx.YAML(http.StatusCreated, &p)
+
+	assert.Equal(t, http.StatusCreated, res.Code)
+	assert.Equal(t, "application/yaml", res.Header().Get("Content-Type"))
+	assert.Equal(t, "name: foo\nage: 1999\n", res.Body.String())
+
+	res = httptest.NewRecorder()
+
+	ctx.reset(nil, res)
+
+	assert.Panics(t, func() {
+		ctx.YAML(http.StatusCreated, make(chan bool))
+	})
+}
+
+func TestContext_ReadYAML(t *testing.T) {
+	ctx := newContext(New())
+
+	req := httptest.NewRequest(http.MethodGet, "/", strings.NewReader("name: Mojix\nage: 22\n"))
+
+	ctx.reset(req, nil)
+
+	var p person
+	err := ctx.ReadYAML(&p)
+	assert.NoError(t, err)
+
+	assert.Equal(t, person{Name: "Mojix", Age: 22}, p)
+
+	req = httptest.NewRequest(http.MethodGet, "/", strings.NewReader("name: [Mojix\n"))
+
+	ctx.reset(req, nil)
+
+	var p2 person
+	err = ctx.ReadYAML(&p2)
+
+	assert.Error(t, err)
+}
+
+func TestContext_Protobuf(t *testing.T) {
+	ctx := newContext(New())
+
+	res := httptest.NewRecorder()
+
+	ctx.reset(nil, res)
+
+	ctx.Protobuf(http.StatusCreated, wrapperspb.String("foo"))
+
+	assert.Equal(t, http.StatusCreated, res.Code)
+	assert.Equal(t, "application/x-protobuf", res.Header().Get("Content-Type"))
+	assert.Equal(t, []byte("\x0a\x03foo"), res.Body.Bytes())
+
+	// Values which only look like proto messages are handled by the error handler.
+	res = httptest.NewRecorder()
+	ctx.reset(httptest.NewRequest(http.MethodGet, "/", nil), res)
+
+	ctx.Protobuf(http.StatusCreated, legacyMessage{})
+
+	var notProtoErr *serializer.NotProtoMessageError
+	assert.ErrorAs(t, ctx.Err(), &notProtoErr)
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+}
+
+// legacyMessage implements ProtoMessage, but it's not a proto.Message.
+type legacyMessage struct{}
+
+func (legacyMessage) ProtoMessage() {}
+
+func TestContext_ReadProtobuf(t *testing.T) {
+	ctx := newContext(New())
+
+	req := httptest.NewRequest(http.MethodGet, "/", strings.NewReader("\x0a\x05Mojix"))
+
+	ctx.reset(req, nil)
+
+	var msg wrapperspb.StringValue
+	err := ctx.ReadProtobuf(&msg)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Mojix", msg.GetValue())
+
+	req = httptest.NewRequest(http.MethodGet, "/", strings.NewReader("\x0a\x05Mo"))
+
+	ctx.reset(req, nil)
+
+	var msg2 wrapperspb.StringValue
+	err = ctx.ReadProtobuf(&msg2)
+
+	assert.Error(t, err)
+}
+
 func TestContext_HTML(t *testing.T) {
 	k := New()
 	renderer := htmlrenderer.New("testdata/templates/", "layouts/", ".html", false)
@@ -600,3 +809,75 @@ func TestContext_Route(t *testing.T) {
 
 	assert.Equal(t, "route_name", ctx.Route())
 }
+
+func TestContext_Error(t *testing.T) {
+	k := New()
+	c := k.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+
+	assert.Nil(t, c.Err())
+
+	err := errors.New("error")
+	c.Error(err)
+	assert.Equal(t, err, c.Err())
+	assert.Equal(t, err, c.Clone().Err())
+
+	c.reset(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	assert.Nil(t, c.Err())
+}
+
+func TestContext_Problem(t *testing.T) {
+	k := New()
+
+	testCases := []struct {
+		name                string
+		accept              string
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			name:                "json",
+			expectedContentType: "application/problem+json",
+			expectedBody:        `{"detail":"Balance is 30.","status":403,"title":"Forbidden"}` + "\n",
+		},
+		{
+			name:                "json_preferred",
+			accept:              "application/json, application/xml",
+			expectedContentType: "application/problem+json",
+			expectedBody:        `{"detail":"Balance is 30.","status":403,"title":"Forbidden"}` + "\n",
+		},
+		{
+			name:                "xml",
+			accept:              "text/html, application/problem+xml;q=0.9, */*",
+			expectedContentType: "application/problem+xml",
+			expectedBody:        `<problem xmlns="urn:ietf:rfc:7807"><title>Forbidden</title><status>403</status><detail>Balance is 30.</detail></problem>`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept", tc.accept)
+			res := httptest.NewRecorder()
+
+			c := k.NewContext(req, res)
+			c.Problem(http.StatusForbidden, Problem{Detail: "Balance is 30."})
+
+			assert.Equal(t, http.StatusForbidden, res.Code)
+			assert.Equal(t, tc.expectedContentType, res.Header().Get("Content-Type"))
+			assert.Equal(t, tc.expectedBody, res.Body.String())
+		})
+	}
+}
+
+func TestContext_HandleError(t *testing.T) {
+	k := New()
+	res := httptest.NewRecorder()
+	c := k.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), res)
+
+	err := NewHTTPError(http.StatusBadRequest, "")
+	c.HandleError(err)
+
+	assert.Equal(t, err, c.Err())
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+	assert.Equal(t, "{\"message\":\"Bad Request\"}\n", res.Body.String())
+}
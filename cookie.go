@@ -0,0 +1,356 @@
+package kid
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+)
+
+// minCookieKeyLen is the minimum length of the keys of signed and encrypted cookies.
+const minCookieKeyLen = 32
+
+// ErrInvalidCookie is returned when a signed or encrypted cookie can't be verified with any of the keys.
+var ErrInvalidCookie = errors.New("invalid cookie")
+
+type (
+	// KeyRing holds the keys of signed and encrypted cookies.
+	//
+	// The first key is used for signing and encrypting new cookies, all of the keys are used for verifying and decrypting them.
+	// So keys can be rotated without invalidating the existing cookies. It's safe for concurrent use.
+	KeyRing struct {
+		mutex sync.RWMutex
+		keys  []cookieKey
+	}
+
+	// cookieKey holds the keys derived from a key ring's key.
+	cookieKey struct {
+		signing []byte
+		aead    cipher.AEAD
+	}
+
+	// CookieOption overrides the defaults which Context.SetCookie applies to a cookie.
+	CookieOption func(cookie *http.Cookie)
+)
+
+// NewKeyRing returns a new key ring with the given keys, from the newest to the oldest.
+//
+// Panics if no key is given or a key is shorter than 32 bytes.
+func NewKeyRing(keys ...[]byte) *KeyRing {
+	if len(keys) == 0 {
+		panic("key ring needs at least one key")
+	}
+
+	ring := KeyRing{keys: make([]cookieKey, len(keys))}
+	for i, key := range keys {
+		ring.keys[i] = newCookieKey(key)
+	}
+
+	return &ring
+}
+
+// Rotate makes the key the primary key of the key ring.
+//
+// Previous keys are kept for verifying and decrypting the existing cookies. Panics if the key is shorter than 32 bytes.
+func (r *KeyRing) Rotate(key []byte) {
+	primary := newCookieKey(key)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.keys = append([]cookieKey{primary}, r.keys...)
+}
+
+// Retire removes the oldest keys of the key ring and keeps the newest n keys.
+//
+// Cookies which are signed or encrypted with the removed keys become invalid. Panics if n is smaller than 1.
+func (r *KeyRing) Retire(n int) {
+	if n < 1 {
+		panic("key ring needs at least one key")
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if n < len(r.keys) {
+		// The keys are not changed in place, since they are read without the lock after all returns them.
+		r.keys = r.keys[:n:n]
+	}
+}
+
+// primary returns the key which is used for new cookies.
+func (r *KeyRing) primary() cookieKey {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.keys[0]
+}
+
+// all returns all of the keys, from the newest to the oldest.
+func (r *KeyRing) all() []cookieKey {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.keys
+}
+
+// newCookieKey derives the signing and encryption keys from the key, so the same key is not used for both.
+func newCookieKey(key []byte) cookieKey {
+	if len(key) < minCookieKeyLen {
+		panic("cookie keys must be at least 32 bytes long")
+	}
+
+	block, err := aes.NewCipher(deriveKey(key, "encryption"))
+	if err != nil {
+		panic(err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(err)
+	}
+
+	return cookieKey{signing: deriveKey(key, "signing"), aead: aead}
+}
+
+// deriveKey derives a 32 bytes key for the given purpose from the key.
+func deriveKey(key []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+// sign returns the signature of the cookie's value, the name is also signed so cookies can't be swapped.
+func (k cookieKey) sign(name string, value []byte) []byte {
+	mac := hmac.New(sha256.New, k.signing)
+	mac.Write([]byte(name))
+	mac.Write([]byte{0})
+	mac.Write(value)
+	return mac.Sum(nil)
+}
+
+// Sign signs the value with the primary key and returns the signed value, which contains the value itself.
+// The name is also signed so values signed for a name can't be used for another one.
+func (r *KeyRing) Sign(name string, value []byte) string {
+	return base64.RawURLEncoding.EncodeToString(value) + "." +
+		base64.RawURLEncoding.EncodeToString(r.primary().sign(name, value))
+}
+
+// Verify verifies the value which is signed with KeyRing.Sign using any of the keys and returns the original value.
+//
+// Returns ErrInvalidCookie if the signature is invalid.
+func (r *KeyRing) Verify(name, signed string) ([]byte, error) {
+	encodedValue, encodedSignature, ok := strings.Cut(signed, ".")
+	if !ok {
+		return nil, ErrInvalidCookie
+	}
+
+	value, err := base64.RawURLEncoding.DecodeString(encodedValue)
+	if err != nil {
+		return nil, ErrInvalidCookie
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return nil, ErrInvalidCookie
+	}
+
+	for _, key := range r.all() {
+		if hmac.Equal(signature, key.sign(name, value)) {
+			return value, nil
+		}
+	}
+
+	return nil, ErrInvalidCookie
+}
+
+// Cookie returns the value of the request's cookie.
+//
+// Returns http.ErrNoCookie if the cookie doesn't exist.
+func (c *Context) Cookie(name string) (string, error) {
+	cookie, err := c.request.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+
+	return cookie.Value, nil
+}
+
+// AllowScriptAccess is a cookie option which lets JavaScript read the cookie, by not setting its HttpOnly attribute.
+func AllowScriptAccess() CookieOption {
+	return func(cookie *http.Cookie) {
+		cookie.HttpOnly = false
+	}
+}
+
+// SetCookie adds a Set-Cookie header to the response.
+//
+// Secure defaults are applied to the cookie: Path defaults to /, SameSite defaults to Lax, HttpOnly is set
+// and Secure is set if the request is served over TLS, directly or behind a trusted proxy which sets X-Forwarded-Proto.
+// Trusted proxies are configured with WithTrustedProxies. The options are applied after the defaults,
+// e.g. AllowScriptAccess for cookies which should be readable by JavaScript.
+func (c *Context) SetCookie(cookie *http.Cookie, opts ...CookieOption) {
+	panicIfNil(cookie, "cookie cannot be nil")
+
+	secured := *cookie
+	secured.HttpOnly = true
+
+	if secured.Path == "" {
+		secured.Path = "/"
+	}
+
+	// http.SameSiteDefaultMode can still be used explicitly to omit the attribute.
+	if secured.SameSite == 0 {
+		secured.SameSite = http.SameSiteLaxMode
+	}
+
+	// Browsers reject SameSite=None cookies which are not secure.
+	if c.isTLS() || secured.SameSite == http.SameSiteNoneMode {
+		secured.Secure = true
+	}
+
+	for _, opt := range opts {
+		opt(&secured)
+	}
+
+	http.SetCookie(c.response, &secured)
+}
+
+// DeleteCookie tells the client to delete the cookie with the given name and path /.
+func (c *Context) DeleteCookie(name string) {
+	c.SetCookie(&http.Cookie{Name: name, MaxAge: -1, Expires: time.Unix(0, 0)})
+}
+
+// SignedCookie returns the value of a cookie which is set with Context.SetSignedCookie.
+//
+// Returns http.ErrNoCookie if the cookie doesn't exist, or ErrInvalidCookie if its signature is invalid.
+// Panics if the key ring is not configured with WithCookieKeyRing.
+func (c *Context) SignedCookie(name string) (string, error) {
+	ring := c.cookieKeyRing()
+
+	value, err := c.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := ring.Verify(name, value)
+	if err != nil {
+		return "", err
+	}
+
+	return string(payload), nil
+}
+
+// SetSignedCookie sets a cookie whose value is signed with HMAC-SHA256, using the primary key of the key ring.
+// The value is readable by the client but it can't be changed.
+//
+// The cookie is set with Context.SetCookie so the same defaults and options are applied.
+// Panics if the key ring is not configured with WithCookieKeyRing.
+func (c *Context) SetSignedCookie(cookie *http.Cookie, opts ...CookieOption) {
+	panicIfNil(cookie, "cookie cannot be nil")
+
+	signed := *cookie
+	signed.Value = c.cookieKeyRing().Sign(cookie.Name, []byte(cookie.Value))
+
+	c.SetCookie(&signed, opts...)
+}
+
+// EncryptedCookie returns the value of a cookie which is set with Context.SetEncryptedCookie.
+//
+// Returns http.ErrNoCookie if the cookie doesn't exist, or ErrInvalidCookie if it can't be decrypted.
+// Panics if the key ring is not configured with WithCookieKeyRing.
+func (c *Context) EncryptedCookie(name string) (string, error) {
+	ring := c.cookieKeyRing()
+
+	value, err := c.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+
+	for _, key := range ring.all() {
+		nonceSize := key.aead.NonceSize()
+		if len(sealed) < nonceSize {
+			break
+		}
+
+		// The name is authenticated as additional data so cookies can't be swapped.
+		plaintext, err := key.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], []byte(name))
+		if err == nil {
+			return string(plaintext), nil
+		}
+	}
+
+	return "", ErrInvalidCookie
+}
+
+// SetEncryptedCookie sets a cookie whose value is encrypted with AES-GCM, using the primary key of the key ring.
+// The value is neither readable nor changeable by the client.
+//
+// The cookie is set with Context.SetCookie so the same defaults and options are applied.
+// Panics if the key ring is not configured with WithCookieKeyRing.
+func (c *Context) SetEncryptedCookie(cookie *http.Cookie, opts ...CookieOption) {
+	panicIfNil(cookie, "cookie cannot be nil")
+
+	key := c.cookieKeyRing().primary()
+
+	nonce := make([]byte, key.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		panic(err)
+	}
+
+	encrypted := *cookie
+	encrypted.Value = base64.RawURLEncoding.EncodeToString(key.aead.Seal(nonce, nonce, []byte(cookie.Value), []byte(cookie.Name)))
+
+	c.SetCookie(&encrypted, opts...)
+}
+
+// cookieKeyRing returns Kid's key ring, panics if it's not configured.
+func (c *Context) cookieKeyRing() *KeyRing {
+	if c.kid.cookieKeyRing == nil {
+		panic("cookie key ring is not configured, use WithCookieKeyRing")
+	}
+	return c.kid.cookieKeyRing
+}
+
+// isTLS checks if the request is served over TLS, directly or behind a trusted TLS terminating proxy.
+func (c *Context) isTLS() bool {
+	if c.request.TLS != nil {
+		return true
+	}
+
+	return c.fromTrustedProxy() && strings.EqualFold(c.GetRequestHeader("X-Forwarded-Proto"), "https")
+}
+
+// fromTrustedProxy checks if the request is sent by one of the proxies which are configured with WithTrustedProxies.
+func (c *Context) fromTrustedProxy() bool {
+	if len(c.kid.trustedProxies) == 0 {
+		return false
+	}
+
+	addrPort, err := netip.ParseAddrPort(c.request.RemoteAddr)
+	if err != nil {
+		return false
+	}
+
+	addr := addrPort.Addr().Unmap()
+	for _, proxy := range c.kid.trustedProxies {
+		if proxy.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,324 @@
+package kid
+
+import (
+	"bytes"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	cookieKey1 = bytes.Repeat([]byte("1"), 32)
+	cookieKey2 = bytes.Repeat([]byte("2"), 32)
+)
+
+// newCookieContext returns a context whose request has the cookies of the response.
+func newCookieContext(k *Kid, res *httptest.ResponseRecorder) *Context {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, cookie := range res.Result().Cookies() {
+		req.AddCookie(cookie)
+	}
+
+	return k.NewContext(req, httptest.NewRecorder())
+}
+
+func TestContext_Cookie(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "theme", Value: "dark"})
+
+	c := New().NewContext(req, httptest.NewRecorder())
+
+	value, err := c.Cookie("theme")
+	assert.NoError(t, err)
+	assert.Equal(t, "dark", value)
+
+	_, err = c.Cookie("missing")
+	assert.ErrorIs(t, err, http.ErrNoCookie)
+}
+
+func TestContext_SetCookie(t *testing.T) {
+	testCases := []struct {
+		name     string
+		cookie   *http.Cookie
+		opts     []CookieOption
+		tls      bool
+		proto    string
+		proxies  []string
+		expected string
+	}{
+		{
+			name:     "defaults",
+			cookie:   &http.Cookie{Name: "theme", Value: "dark"},
+			expected: "theme=dark; Path=/; HttpOnly; SameSite=Lax",
+		},
+		{
+			name:     "tls",
+			cookie:   &http.Cookie{Name: "theme", Value: "dark", Path: "/app", SameSite: http.SameSiteStrictMode},
+			tls:      true,
+			expected: "theme=dark; Path=/app; HttpOnly; Secure; SameSite=Strict",
+		},
+		{
+			name:     "forwarded_proto",
+			cookie:   &http.Cookie{Name: "theme", Value: "dark", MaxAge: 60},
+			proto:    "HTTPS",
+			proxies:  []string{"10.0.0.1", "192.0.2.0/24"},
+			expected: "theme=dark; Path=/; Max-Age=60; HttpOnly; Secure; SameSite=Lax",
+		},
+		{
+			name:     "untrusted_forwarded_proto",
+			cookie:   &http.Cookie{Name: "theme", Value: "dark"},
+			proto:    "https",
+			expected: "theme=dark; Path=/; HttpOnly; SameSite=Lax",
+		},
+		{
+			name:     "untrusted_proxy",
+			cookie:   &http.Cookie{Name: "theme", Value: "dark"},
+			proto:    "https",
+			proxies:  []string{"10.0.0.0/8"},
+			expected: "theme=dark; Path=/; HttpOnly; SameSite=Lax",
+		},
+		{
+			name:     "script_access",
+			cookie:   &http.Cookie{Name: "csrf", Value: "token"},
+			opts:     []CookieOption{AllowScriptAccess()},
+			expected: "csrf=token; Path=/; SameSite=Lax",
+		},
+		{
+			name:     "same_site_none",
+			cookie:   &http.Cookie{Name: "theme", Value: "dark", SameSite: http.SameSiteNoneMode},
+			expected: "theme=dark; Path=/; HttpOnly; Secure; SameSite=None",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.tls {
+				req.TLS = &tls.ConnectionState{}
+			}
+			if tc.proto != "" {
+				req.Header.Set("X-Forwarded-Proto", tc.proto)
+			}
+
+			k := New()
+			k.ApplyOptions(WithTrustedProxies(tc.proxies...))
+
+			res := httptest.NewRecorder()
+			c := k.NewContext(req, res)
+
+			c.SetCookie(tc.cookie, tc.opts...)
+
+			assert.Equal(t, tc.expected, res.Header().Get("Set-Cookie"))
+		})
+	}
+
+	assert.PanicsWithValue(t, "cookie cannot be nil", func() {
+		New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder()).SetCookie(nil)
+	})
+}
+
+func TestContext_DeleteCookie(t *testing.T) {
+	res := httptest.NewRecorder()
+	c := New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), res)
+
+	c.DeleteCookie("theme")
+
+	assert.Equal(
+		t,
+		"theme=; Path=/; Expires=Thu, 01 Jan 1970 00:00:00 GMT; Max-Age=0; HttpOnly; SameSite=Lax",
+		res.Header().Get("Set-Cookie"),
+	)
+}
+
+func TestContext_SignedCookie(t *testing.T) {
+	k := New()
+	k.ApplyOptions(WithCookieKeyRing(NewKeyRing(cookieKey1)))
+
+	res := httptest.NewRecorder()
+	k.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), res).SetSignedCookie(&http.Cookie{Name: "user", Value: "john; admin"})
+
+	c := newCookieContext(k, res)
+
+	value, err := c.SignedCookie("user")
+	assert.NoError(t, err)
+	assert.Equal(t, "john; admin", value)
+
+	_, err = c.SignedCookie("missing")
+	assert.ErrorIs(t, err, http.ErrNoCookie)
+
+	signed := res.Result().Cookies()[0].Value
+	payload, signature, _ := strings.Cut(signed, ".")
+
+	for _, value := range []string{"tampered", "am9obg." + signature, "!." + signature, payload + ".!", payload + "." + payload} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(&http.Cookie{Name: "user", Value: value})
+
+		_, err = k.NewContext(req, httptest.NewRecorder()).SignedCookie("user")
+		assert.ErrorIs(t, err, ErrInvalidCookie)
+	}
+
+	// Cookies can't be swapped, since their names are also signed.
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "other", Value: signed})
+
+	_, err = k.NewContext(req, httptest.NewRecorder()).SignedCookie("other")
+	assert.ErrorIs(t, err, ErrInvalidCookie)
+}
+
+func TestContext_EncryptedCookie(t *testing.T) {
+	k := New()
+	k.ApplyOptions(WithCookieKeyRing(NewKeyRing(cookieKey1)))
+
+	res := httptest.NewRecorder()
+	k.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), res).SetEncryptedCookie(&http.Cookie{Name: "token", Value: "secret"})
+
+	encrypted := res.Result().Cookies()[0].Value
+	assert.NotContains(t, encrypted, "secret")
+
+	c := newCookieContext(k, res)
+
+	value, err := c.EncryptedCookie("token")
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", value)
+
+	_, err = c.EncryptedCookie("missing")
+	assert.ErrorIs(t, err, http.ErrNoCookie)
+
+	for _, cookie := range []*http.Cookie{
+		{Name: "token", Value: "!"},
+		{Name: "token", Value: "c2hvcnQ"},
+		{Name: "token", Value: encrypted[:len(encrypted)-2] + "AA"},
+		{Name: "other", Value: encrypted},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(cookie)
+
+		_, err = k.NewContext(req, httptest.NewRecorder()).EncryptedCookie(cookie.Name)
+		assert.ErrorIs(t, err, ErrInvalidCookie)
+	}
+}
+
+func TestKeyRing_Rotate(t *testing.T) {
+	ring := NewKeyRing(cookieKey1)
+
+	k := New()
+	k.ApplyOptions(WithCookieKeyRing(ring))
+
+	res := httptest.NewRecorder()
+	c := k.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), res)
+	c.SetSignedCookie(&http.Cookie{Name: "signed", Value: "old"})
+	c.SetEncryptedCookie(&http.Cookie{Name: "encrypted", Value: "old"})
+
+	ring.Rotate(cookieKey2)
+
+	// Cookies of the previous keys are still valid.
+	c = newCookieContext(k, res)
+
+	value, err := c.SignedCookie("signed")
+	assert.NoError(t, err)
+	assert.Equal(t, "old", value)
+
+	value, err = c.EncryptedCookie("encrypted")
+	assert.NoError(t, err)
+	assert.Equal(t, "old", value)
+
+	// New cookies are only valid with the new key.
+	res = httptest.NewRecorder()
+	c = k.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), res)
+	c.SetSignedCookie(&http.Cookie{Name: "signed", Value: "new"})
+	c.SetEncryptedCookie(&http.Cookie{Name: "encrypted", Value: "new"})
+
+	k.ApplyOptions(WithCookieKeyRing(NewKeyRing(cookieKey1)))
+
+	c = newCookieContext(k, res)
+
+	_, err = c.SignedCookie("signed")
+	assert.ErrorIs(t, err, ErrInvalidCookie)
+
+	_, err = c.EncryptedCookie("encrypted")
+	assert.ErrorIs(t, err, ErrInvalidCookie)
+}
+
+func TestKeyRing_Retire(t *testing.T) {
+	ring := NewKeyRing(cookieKey1)
+
+	k := New()
+	k.ApplyOptions(WithCookieKeyRing(ring))
+
+	res := httptest.NewRecorder()
+	c := k.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), res)
+	c.SetSignedCookie(&http.Cookie{Name: "signed", Value: "old"}, AllowScriptAccess())
+	c.SetEncryptedCookie(&http.Cookie{Name: "encrypted", Value: "old"})
+
+	assert.NotContains(t, res.Header().Values("Set-Cookie")[0], "HttpOnly")
+	assert.Contains(t, res.Header().Values("Set-Cookie")[1], "HttpOnly")
+
+	ring.Rotate(cookieKey2)
+
+	// Keeping more keys than the ring has is a no-op.
+	ring.Retire(2)
+	assert.Len(t, ring.all(), 2)
+
+	ring.Retire(1)
+	assert.Len(t, ring.all(), 1)
+
+	// Cookies of the retired keys are not valid anymore.
+	c = newCookieContext(k, res)
+
+	_, err := c.SignedCookie("signed")
+	assert.ErrorIs(t, err, ErrInvalidCookie)
+
+	_, err = c.EncryptedCookie("encrypted")
+	assert.ErrorIs(t, err, ErrInvalidCookie)
+
+	assert.PanicsWithValue(t, "key ring needs at least one key", func() {
+		ring.Retire(0)
+	})
+}
+
+func TestNewKeyRing_Panics(t *testing.T) {
+	assert.PanicsWithValue(t, "key ring needs at least one key", func() {
+		NewKeyRing()
+	})
+
+	assert.PanicsWithValue(t, "cookie keys must be at least 32 bytes long", func() {
+		NewKeyRing(cookieKey1, []byte("short"))
+	})
+
+	assert.PanicsWithValue(t, "cookie keys must be at least 32 bytes long", func() {
+		NewKeyRing(cookieKey1).Rotate(nil)
+	})
+}
+
+func TestContext_SignedCookie_NoKeyRing(t *testing.T) {
+	c := New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+
+	for _, f := range []func(){
+		func() { _, _ = c.SignedCookie("user") },
+		func() { c.SetSignedCookie(&http.Cookie{Name: "user"}) },
+		func() { _, _ = c.EncryptedCookie("user") },
+		func() { c.SetEncryptedCookie(&http.Cookie{Name: "user"}) },
+	} {
+		assert.PanicsWithValue(t, "cookie key ring is not configured, use WithCookieKeyRing", f)
+	}
+}
+
+func TestKeyRing_Sign(t *testing.T) {
+	ring := NewKeyRing(cookieKey1)
+
+	signed := ring.Sign("name", []byte("value"))
+
+	value, err := ring.Verify("name", signed)
+	assert.NoError(t, err)
+	assert.Equal(t, "value", string(value))
+
+	_, err = ring.Verify("other", signed)
+	assert.ErrorIs(t, err, ErrInvalidCookie)
+
+	_, err = NewKeyRing(cookieKey2).Verify("name", signed)
+	assert.ErrorIs(t, err, ErrInvalidCookie)
+}
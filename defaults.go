@@ -1,7 +1,10 @@
 package kid
 
 import (
+	"errors"
 	"net/http"
+
+	"github.com/mojixcoder/kid/validator"
 )
 
 var (
@@ -9,13 +12,109 @@ var (
 	//
 	// It will be used when request doesn't match any routes.
 	defaultNotFoundHandler HandlerFunc = func(c *Context) {
-		c.JSON(http.StatusNotFound, Map{"message": http.StatusText(http.StatusNotFound)})
+		writeHTTPError(c, NewHTTPError(http.StatusNotFound, ""), nil)
 	}
 
 	// defaultMethodNotAllowedHandler is Kid's default method not allowed handler.
 	//
 	// It will be used when request matches a route but its method doesn't match route's method.
 	defaultMethodNotAllowedHandler HandlerFunc = func(c *Context) {
-		c.JSON(http.StatusMethodNotAllowed, Map{"message": http.StatusText(http.StatusMethodNotAllowed)})
+		writeHTTPError(c, NewHTTPError(http.StatusMethodNotAllowed, ""), nil)
+	}
+
+	// defaultNotAcceptableHandler is Kid's default not acceptable handler.
+	//
+	// It will be used when none of the offers of Context.Negotiate is acceptable.
+	defaultNotAcceptableHandler HandlerFunc = func(c *Context) {
+		writeHTTPError(c, NewHTTPError(http.StatusNotAcceptable, ""), nil)
+	}
+
+	// defaultOptionsHandler is Kid's default OPTIONS handler.
+	//
+	// It will be used when request matches a route which doesn't have an OPTIONS handler.
+	// The Allow header is already set when it's called.
+	defaultOptionsHandler HandlerFunc = func(c *Context) {
+		c.NoContent(http.StatusNoContent)
+	}
+
+	// defaultErrorHandler is Kid's default error handler.
+	//
+	// Problems are rendered as is, with 500 status code if they don't have a status.
+	// HTTPErrors are rendered with their status code, message and code, and BindingErrors with 400 status code.
+	// validator.ValidationErrors are rendered with 422 status code and the list of field errors.
+	// Other errors are rendered as internal server errors, without exposing their messages to the client.
+	defaultErrorHandler HTTPErrorHandler = func(c *Context, err error) {
+		var problem *Problem
+		if errors.As(err, &problem) {
+			status := problem.Status
+			if status == 0 {
+				status = http.StatusInternalServerError
+			}
+
+			c.Problem(status, *problem)
+			return
+		}
+
+		var httpErr *HTTPError
+		var bindingErr *BindingError
+		var validationErrs validator.ValidationErrors
+
+		switch {
+		case errors.As(err, &httpErr):
+		case errors.As(err, &bindingErr):
+			httpErr = NewHTTPError(http.StatusBadRequest, bindingErr.Error()).WithCause(bindingErr)
+		case errors.As(err, &validationErrs):
+			httpErr = NewHTTPError(http.StatusUnprocessableEntity, "").WithCause(validationErrs)
+			writeHTTPError(c, httpErr, Map{"errors": validationErrs})
+			return
+		default:
+			httpErr = NewHTTPError(http.StatusInternalServerError, "")
+		}
+
+		writeHTTPError(c, httpErr, nil)
 	}
 )
+
+// writeHTTPError writes the HTTP error as a JSON message, or as a problem details document if it's enabled.
+//
+// Extensions are written next to the message, or as the problem's extension members.
+func writeHTTPError(c *Context, err *HTTPError, extensions Map) {
+	if c.kid.problemDetails {
+		problem := err.problem(c)
+		for name, value := range extensions {
+			if problem.Extensions == nil {
+				problem.Extensions = make(Map)
+			}
+			problem.Extensions[name] = value
+		}
+
+		c.Problem(err.Status, problem)
+		return
+	}
+
+	body := Map{"message": err.Message}
+	if err.Code != "" {
+		body["code"] = err.Code
+	}
+
+	for name, value := range extensions {
+		body[name] = value
+	}
+
+	c.JSON(err.Status, body)
+}
+
+// newRedirectHandler returns a handler which redirects the request to the given location.
+//
+// GET and HEAD requests are redirected with 301 status code and the others with 308 to preserve the method and body.
+func newRedirectHandler(location string) HandlerFunc {
+	return func(c *Context) {
+		code := http.StatusPermanentRedirect
+		if c.Method() == http.MethodGet || c.Method() == http.MethodHead {
+			code = http.StatusMovedPermanently
+		}
+
+		c.SetResponseHeader("Location", location)
+		c.NoContent(code)
+	}
+}
@@ -1,10 +1,13 @@
 package kid
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/mojixcoder/kid/validator"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -45,3 +48,149 @@ func TestDefaultMethodNotAllowedHandler(t *testing.T) {
 	assert.Equal(t, "{\"message\":\"Method Not Allowed\"}\n", w.Body.String())
 	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
 }
+
+func TestDefaultOptionsHandler(t *testing.T) {
+	k := setupKid()
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodOptions, "/post", nil)
+	assert.NoError(t, err)
+
+	k.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "POST, OPTIONS", w.Header().Get("Allow"))
+	assert.Empty(t, w.Body.String())
+}
+
+func TestDefaultErrorHandler(t *testing.T) {
+	k := New()
+
+	testCases := []struct {
+		name         string
+		err          error
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "error",
+			err:          errors.New("database is down"),
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: "{\"message\":\"Internal Server Error\"}\n",
+		},
+		{
+			name:         "http_error",
+			err:          NewHTTPError(http.StatusNotFound, "user not found").WithCause(errors.New("no rows")),
+			expectedCode: http.StatusNotFound,
+			expectedBody: "{\"message\":\"user not found\"}\n",
+		},
+		{
+			name:         "http_error_with_code",
+			err:          NewHTTPError(http.StatusConflict, "").WithCode("duplicate_email"),
+			expectedCode: http.StatusConflict,
+			expectedBody: "{\"code\":\"duplicate_email\",\"message\":\"Conflict\"}\n",
+		},
+		{
+			name:         "wrapped_http_error",
+			err:          fmt.Errorf("creating user: %w", NewHTTPError(http.StatusBadRequest, "")),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "{\"message\":\"Bad Request\"}\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			defaultErrorHandler(k.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), res), tc.err)
+
+			assert.Equal(t, tc.expectedCode, res.Code)
+			assert.Equal(t, tc.expectedBody, res.Body.String())
+			assert.Equal(t, "application/json", res.Header().Get("Content-Type"))
+		})
+	}
+}
+
+func TestDefaultHandlers_ProblemDetails(t *testing.T) {
+	k := setupKid()
+	k.ApplyOptions(WithProblemDetails(true))
+
+	k.Get("/error", WrapErrHandlerFunc(func(c *Context) error {
+		return NewHTTPError(http.StatusConflict, "email is taken").WithCode("duplicate_email")
+	}))
+
+	k.Get("/validation", WrapErrHandlerFunc(func(c *Context) error {
+		return validator.ValidationErrors{{Field: "name", Rule: "required", Message: "is required"}}
+	}))
+
+	k.Get("/problem", WrapErrHandlerFunc(func(c *Context) error {
+		return &Problem{Type: "https://example.com/probs/out-of-credit", Status: http.StatusForbidden, Extensions: Map{"balance": 30}}
+	}))
+
+	testCases := []struct {
+		method       string
+		path         string
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			method:       http.MethodGet,
+			path:         "/not-found",
+			expectedCode: http.StatusNotFound,
+			expectedBody: `{"instance":"/not-found","status":404,"title":"Not Found"}`,
+		},
+		{
+			method:       http.MethodGet,
+			path:         "/post",
+			expectedCode: http.StatusMethodNotAllowed,
+			expectedBody: `{"instance":"/post","status":405,"title":"Method Not Allowed"}`,
+		},
+		{
+			method:       http.MethodGet,
+			path:         "/error",
+			expectedCode: http.StatusConflict,
+			expectedBody: `{"code":"duplicate_email","detail":"email is taken","instance":"/error","status":409,"title":"Conflict"}`,
+		},
+		{
+			method:       http.MethodGet,
+			path:         "/validation",
+			expectedCode: http.StatusUnprocessableEntity,
+			expectedBody: `{"errors":[{"field":"name","rule":"required","message":"is required"}],` +
+				`"instance":"/validation","status":422,"title":"Unprocessable Entity"}`,
+		},
+		{
+			method:       http.MethodGet,
+			path:         "/problem",
+			expectedCode: http.StatusForbidden,
+			expectedBody: `{"balance":30,"status":403,"title":"Forbidden","type":"https://example.com/probs/out-of-credit"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			k.ServeHTTP(res, httptest.NewRequest(tc.method, tc.path, nil))
+
+			assert.Equal(t, tc.expectedCode, res.Code)
+			assert.Equal(t, "application/problem+json", res.Header().Get("Content-Type"))
+			assert.Equal(t, tc.expectedBody+"\n", res.Body.String())
+		})
+	}
+}
+
+func TestNewRedirectHandler(t *testing.T) {
+	k := New()
+	handler := newRedirectHandler("/users?page=1")
+
+	for method, code := range map[string]int{
+		http.MethodGet:    http.StatusMovedPermanently,
+		http.MethodHead:   http.StatusMovedPermanently,
+		http.MethodPost:   http.StatusPermanentRedirect,
+		http.MethodDelete: http.StatusPermanentRedirect,
+	} {
+		res := httptest.NewRecorder()
+		handler(k.NewContext(httptest.NewRequest(method, "/users/", nil), res))
+
+		assert.Equal(t, code, res.Code)
+		assert.Equal(t, "/users?page=1", res.Header().Get("Location"))
+	}
+}
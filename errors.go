@@ -0,0 +1,63 @@
+package kid
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// HTTPError is an error which is rendered as an HTTP response by the error handler.
+type HTTPError struct {
+	// Status is the HTTP status code of the response.
+	Status int
+
+	// Code is an optional application specific error code, e.g. user_not_found.
+	Code string
+
+	// Message is the error message which is sent to the client.
+	Message string
+
+	// Err is the internal cause of the error. It's never sent to the client.
+	Err error
+}
+
+// Verifying interface compliance.
+var _ error = (*HTTPError)(nil)
+
+// NewHTTPError returns a new HTTPError with the given status code and message.
+//
+// If the message is empty, the status text of the status code is used.
+func NewHTTPError(status int, message string) *HTTPError {
+	if message == "" {
+		message = http.StatusText(status)
+	}
+
+	return &HTTPError{Status: status, Message: message}
+}
+
+// WithCode returns a copy of the error with the given application specific error code.
+func (e *HTTPError) WithCode(code string) *HTTPError {
+	err := *e
+	err.Code = code
+	return &err
+}
+
+// WithCause returns a copy of the error with the given internal cause.
+func (e *HTTPError) WithCause(cause error) *HTTPError {
+	err := *e
+	err.Err = cause
+	return &err
+}
+
+// Error implements the error interface.
+func (e *HTTPError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%d %s: %s", e.Status, e.Message, e.Err)
+	}
+
+	return fmt.Sprintf("%d %s", e.Status, e.Message)
+}
+
+// Unwrap returns the internal cause of the error.
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,44 @@
+package kid
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHTTPError(t *testing.T) {
+	err := NewHTTPError(http.StatusNotFound, "")
+	assert.Equal(t, &HTTPError{Status: http.StatusNotFound, Message: "Not Found"}, err)
+
+	err = NewHTTPError(http.StatusBadRequest, "invalid id")
+	assert.Equal(t, &HTTPError{Status: http.StatusBadRequest, Message: "invalid id"}, err)
+}
+
+func TestHTTPError_WithCode(t *testing.T) {
+	err := NewHTTPError(http.StatusNotFound, "")
+	withCode := err.WithCode("user_not_found")
+
+	assert.Equal(t, "user_not_found", withCode.Code)
+	assert.Empty(t, err.Code)
+}
+
+func TestHTTPError_WithCause(t *testing.T) {
+	cause := errors.New("connection refused")
+
+	err := NewHTTPError(http.StatusServiceUnavailable, "")
+	withCause := err.WithCause(cause)
+
+	assert.Equal(t, cause, withCause.Err)
+	assert.Nil(t, err.Err)
+	assert.ErrorIs(t, withCause, cause)
+}
+
+func TestHTTPError_Error(t *testing.T) {
+	err := NewHTTPError(http.StatusNotFound, "")
+	assert.Equal(t, "404 Not Found", err.Error())
+
+	err = err.WithCause(errors.New("no rows"))
+	assert.Equal(t, "404 Not Found: no rows", err.Error())
+}
@@ -2,10 +2,15 @@ module github.com/mojixcoder/kid
 
 go 1.21
 
-require github.com/stretchr/testify v1.8.4
+require (
+	github.com/fxamacker/cbor/v2 v2.9.4
+	github.com/stretchr/testify v1.8.4
+	google.golang.org/protobuf v1.34.2
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
+	github.com/x448/float16 v0.8.4 // indirect
 )
@@ -8,6 +8,7 @@ import "net/http"
 // it's kind of an abstraction to make it easier to make a group of routes.
 type Group struct {
 	kid         *Kid
+	host        *hostRouter
 	prefix      string
 	middlewares []MiddlewareFunc
 }
@@ -20,86 +21,113 @@ func newGroup(k *Kid, prefix string, middlewares ...MiddlewareFunc) Group {
 // Get registers a new handler for the given path for GET method.
 //
 // Specifying middlewares is optional. Middlewares will only be applied to this route.
-func (g *Group) Get(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) {
-	g.Add(path, handler, []string{http.MethodGet}, middlewares...)
+func (g *Group) Get(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return g.Add(path, handler, []string{http.MethodGet}, middlewares...)
 }
 
 // Post registers a new handler for the given path for POST method.
 //
 // Specifying middlewares is optional. Middlewares will only be applied to this route.
-func (g *Group) Post(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) {
-	g.Add(path, handler, []string{http.MethodPost}, middlewares...)
+func (g *Group) Post(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return g.Add(path, handler, []string{http.MethodPost}, middlewares...)
 }
 
 // Put registers a new handler for the given path for PUT method.
 //
 // Specifying middlewares is optional. Middlewares will only be applied to this route.
-func (g *Group) Put(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) {
-	g.Add(path, handler, []string{http.MethodPut}, middlewares...)
+func (g *Group) Put(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return g.Add(path, handler, []string{http.MethodPut}, middlewares...)
 }
 
 // Patch registers a new handler for the given path for PATCH method.
 //
 // Specifying middlewares is optional. Middlewares will only be applied to this route.
-func (g *Group) Patch(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) {
-	g.Add(path, handler, []string{http.MethodPatch}, middlewares...)
+func (g *Group) Patch(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return g.Add(path, handler, []string{http.MethodPatch}, middlewares...)
 }
 
 // Delete registers a new handler for the given path for DELETE method.
 //
 // Specifying middlewares is optional. Middlewares will only be applied to this route.
-func (g *Group) Delete(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) {
-	g.Add(path, handler, []string{http.MethodDelete}, middlewares...)
+func (g *Group) Delete(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return g.Add(path, handler, []string{http.MethodDelete}, middlewares...)
 }
 
 // Head registers a new handler for the given path for HEAD method.
 //
 // Specifying middlewares is optional. Middlewares will only be applied to this route.
-func (g *Group) Head(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) {
-	g.Add(path, handler, []string{http.MethodHead}, middlewares...)
+func (g *Group) Head(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return g.Add(path, handler, []string{http.MethodHead}, middlewares...)
 }
 
 // Options registers a new handler for the given path for OPTIONS method.
 //
 // Specifying middlewares is optional. Middlewares will only be applied to this route.
-func (g *Group) Options(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) {
-	g.Add(path, handler, []string{http.MethodOptions}, middlewares...)
+func (g *Group) Options(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return g.Add(path, handler, []string{http.MethodOptions}, middlewares...)
 }
 
 // Connect registers a new handler for the given path for CONNECT method.
 //
 // Specifying middlewares is optional. Middlewares will only be applied to this route.
-func (g *Group) Connect(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) {
-	g.Add(path, handler, []string{http.MethodConnect}, middlewares...)
+func (g *Group) Connect(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return g.Add(path, handler, []string{http.MethodConnect}, middlewares...)
 }
 
 // Trace registers a new handler for the given path for TRACE method.
 //
 // Specifying middlewares is optional. Middlewares will only be applied to this route.
-func (g *Group) Trace(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) {
-	g.Add(path, handler, []string{http.MethodTrace}, middlewares...)
+func (g *Group) Trace(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return g.Add(path, handler, []string{http.MethodTrace}, middlewares...)
 }
 
 // Any registers a new handler for the given path for all of the HTTP methods.
 //
 // Specifying middlewares is optional. Middlewares will only be applied to this route.
-func (g *Group) Any(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) {
-	g.Add(path, handler, allMethods, middlewares...)
+func (g *Group) Any(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return g.Add(path, handler, allMethods, middlewares...)
 }
 
 // Add adds a route to the group routes.
-func (g *Group) Add(path string, handler HandlerFunc, methods []string, middlewares ...MiddlewareFunc) {
+//
+// It returns the registered route which can be used to name it.
+func (g *Group) Add(path string, handler HandlerFunc, methods []string, middlewares ...MiddlewareFunc) *Route {
 	path = g.prefix + path
 	middlewares = g.combineMiddlewares(middlewares)
 
-	g.kid.Add(path, handler, methods, middlewares...)
+	if g.host != nil {
+		return g.kid.addRoute(&g.host.router, path, handler, methods, middlewares)
+	}
+
+	return g.kid.Add(path, handler, methods, middlewares...)
+}
+
+// Mount mounts the given handler under the prefix. All of the HTTP methods will be passed to the handler.
+//
+// The prefix is stripped from the request path before it's passed to the handler.
+//
+// Specifying middlewares is optional. Middlewares will only be applied to the mounted handler.
+func (g *Group) Mount(prefix string, handler http.Handler, middlewares ...MiddlewareFunc) {
+	prefix = g.prefix + prefix
+	middlewares = g.combineMiddlewares(middlewares)
+
+	router := &g.kid.router
+	if g.host != nil {
+		router = &g.host.router
+	}
+
+	g.kid.mount(router, prefix, handler, middlewares)
 }
 
 // Group creates a sub-group for that group.
 func (g *Group) Group(prefix string, middlewares ...MiddlewareFunc) Group {
 	prefix = g.prefix + prefix
 	gMiddlewares := g.combineMiddlewares(middlewares)
-	return newGroup(g.kid, prefix, gMiddlewares...)
+
+	group := newGroup(g.kid, prefix, gMiddlewares...)
+	group.host = g.host
+
+	return group
 }
 
 // combineMiddlewares combines the given middlewares with the group middlewares and returns the combined middlewares.
@@ -0,0 +1,132 @@
+package kid
+
+import (
+	"net"
+	"strings"
+)
+
+// hostRouter is a router scoped to a host pattern.
+type hostRouter struct {
+	// pattern is the host pattern, e.g. {tenant}.example.com.
+	pattern string
+
+	// labels are the parsed labels of the pattern.
+	labels []Node
+
+	// router is the host's routing tree.
+	router Tree
+
+	// middlewares are applied to all of the requests matching the host.
+	middlewares []MiddlewareFunc
+}
+
+// newHostRouter returns a new host router.
+func newHostRouter(pattern string) *hostRouter {
+	if pattern == "" {
+		panic("host pattern cannot be empty")
+	}
+
+	segments := strings.Split(pattern, ".")
+	labels := make([]Node, len(segments))
+
+	for i, segment := range segments {
+		if segment == "" {
+			panic("host pattern cannot have empty labels")
+		}
+
+		labels[i].isParam = isParam(segment)
+		labels[i].isStar = isStar(segment)
+
+		if labels[i].isStar {
+			panic("star parameters are not allowed in host patterns")
+		}
+
+		labels[i].setLabel(segment)
+	}
+
+	return &hostRouter{pattern: pattern, labels: labels, router: newTree()}
+}
+
+// match checks if the host matches the pattern and stores the host parameters in the given params.
+func (h *hostRouter) match(host string, params Params) bool {
+	if !h.matchLabels(host, nil) {
+		return false
+	}
+
+	h.matchLabels(host, params)
+	return true
+}
+
+// matchLabels checks if the host labels match the pattern labels.
+//
+// Host parameters are stored in params if it's not nil.
+func (h *hostRouter) matchLabels(host string, params Params) bool {
+	rest := host
+
+	for i := range h.labels {
+		label, tail, found := strings.Cut(rest, ".")
+		if found != (i < len(h.labels)-1) {
+			return false
+		}
+
+		node := &h.labels[i]
+
+		if node.isParam {
+			if label == "" || !node.matchesConstraint(label) {
+				return false
+			}
+
+			if params != nil {
+				params[node.label] = label
+			}
+		} else if !strings.EqualFold(label, node.label) {
+			return false
+		}
+
+		rest = tail
+	}
+
+	return true
+}
+
+// getHost returns the host router of the pattern and creates it if it doesn't exist.
+func (k *Kid) getHost(pattern string) *hostRouter {
+	for _, host := range k.hosts {
+		if host.pattern == pattern {
+			return host
+		}
+	}
+
+	host := newHostRouter(pattern)
+	k.hosts = append(k.hosts, host)
+
+	return host
+}
+
+// matchHost returns the first host router which matches the request's host.
+//
+// Host parameters are stored in the context's path parameters.
+func (k *Kid) matchHost(c *Context) *hostRouter {
+	if len(k.hosts) == 0 {
+		return nil
+	}
+
+	host := requestHost(c.Request().Host)
+
+	for _, h := range k.hosts {
+		if h.match(host, c.params) {
+			return h
+		}
+	}
+
+	return nil
+}
+
+// requestHost returns the request's host without the port and the trailing dot.
+func requestHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	return strings.TrimSuffix(host, ".")
+}
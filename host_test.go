@@ -0,0 +1,151 @@
+package kid
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHostRouter(t *testing.T) {
+	host := newHostRouter("{tenant:[a-z]+}.Example.com")
+
+	assert.Equal(t, "{tenant:[a-z]+}.Example.com", host.pattern)
+	assert.Len(t, host.labels, 3)
+	assert.True(t, host.labels[0].isParam)
+	assert.Equal(t, "tenant", host.labels[0].label)
+	assert.Equal(t, "[a-z]+", host.labels[0].constraint)
+	assert.False(t, host.labels[1].isParam)
+	assert.Equal(t, "Example", host.labels[1].label)
+	assert.NotNil(t, host.router.root)
+
+	assert.PanicsWithValue(t, "host pattern cannot be empty", func() {
+		newHostRouter("")
+	})
+
+	assert.PanicsWithValue(t, "host pattern cannot have empty labels", func() {
+		newHostRouter("api..example.com")
+	})
+
+	assert.PanicsWithValue(t, "star parameters are not allowed in host patterns", func() {
+		newHostRouter("{*sub}.example.com")
+	})
+}
+
+func TestHostRouter_match(t *testing.T) {
+	host := newHostRouter("{tenant}.{env:dev|prod}.example.com")
+
+	testCases := []struct {
+		host           string
+		expected       bool
+		expectedParams Params
+	}{
+		{host: "acme.dev.example.com", expected: true, expectedParams: Params{"tenant": "acme", "env": "dev"}},
+		{host: "acme.prod.EXAMPLE.com", expected: true, expectedParams: Params{"tenant": "acme", "env": "prod"}},
+		{host: "acme.test.example.com", expected: false, expectedParams: Params{}},
+		{host: "acme.dev.example.org", expected: false, expectedParams: Params{}},
+		{host: "dev.example.com", expected: false, expectedParams: Params{}},
+		{host: "a.acme.dev.example.com", expected: false, expectedParams: Params{}},
+		{host: ".dev.example.com", expected: false, expectedParams: Params{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.host, func(t *testing.T) {
+			params := make(Params)
+
+			assert.Equal(t, tc.expected, host.match(tc.host, params))
+			assert.Equal(t, tc.expectedParams, params)
+		})
+	}
+}
+
+func TestRequestHost(t *testing.T) {
+	assert.Equal(t, "example.com", requestHost("example.com"))
+	assert.Equal(t, "example.com", requestHost("example.com:8080"))
+	assert.Equal(t, "example.com", requestHost("example.com."))
+	assert.Equal(t, "::1", requestHost("[::1]:8080"))
+}
+
+func TestKid_Host(t *testing.T) {
+	k := New()
+
+	k.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			c.SetResponseHeader("X-Global", "true")
+			next(c)
+		}
+	})
+
+	k.Get("/", func(c *Context) {
+		c.String(http.StatusOK, "main")
+	})
+
+	api := k.Host("api.example.com", func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			c.SetResponseHeader("X-Host", "api")
+			next(c)
+		}
+	})
+
+	api.Get("/", func(c *Context) {
+		c.String(http.StatusOK, "api")
+	})
+
+	tenants := k.Host("{tenant}.example.com")
+	v1 := tenants.Group("/v1")
+
+	v1.Get("/users/{id}", func(c *Context) {
+		c.String(http.StatusOK, c.Param("tenant")+" "+c.Param("id"))
+	})
+
+	assert.Equal(t, k.getHost("api.example.com"), api.host)
+	assert.Equal(t, tenants.host, v1.host)
+	assert.Len(t, k.hosts, 2)
+
+	testCases := []struct {
+		name, host, path   string
+		expectedCode       int
+		expectedBody       string
+		expectedHostHeader string
+	}{
+		{name: "main", host: "example.com", path: "/", expectedCode: http.StatusOK, expectedBody: "main"},
+		{name: "api", host: "api.example.com:443", path: "/", expectedCode: http.StatusOK, expectedBody: "api", expectedHostHeader: "api"},
+		{name: "api_not_found", host: "api.example.com", path: "/v1/users/1", expectedCode: http.StatusNotFound, expectedHostHeader: "api"},
+		{name: "tenant", host: "acme.example.com", path: "/v1/users/1", expectedCode: http.StatusOK, expectedBody: "acme 1"},
+		{name: "tenant_not_found", host: "acme.example.com", path: "/", expectedCode: http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.Host = tc.host
+			res := httptest.NewRecorder()
+
+			k.ServeHTTP(res, req)
+
+			assert.Equal(t, tc.expectedCode, res.Code)
+			assert.Equal(t, "true", res.Header().Get("X-Global"))
+			assert.Equal(t, tc.expectedHostHeader, res.Header().Get("X-Host"))
+
+			if tc.expectedBody != "" {
+				assert.Equal(t, tc.expectedBody, res.Body.String())
+			}
+		})
+	}
+}
+
+func TestKid_Host_Routes(t *testing.T) {
+	k := New()
+
+	k.Get("/", testHandlerFunc)
+	api := k.Host("api.example.com")
+	api.Get("/", testHandlerFunc).Name("api.index")
+
+	expected := []RouteInfo{
+		{Method: http.MethodGet, Path: "/", Handler: funcName(testHandlerFunc)},
+		{Method: http.MethodGet, Host: "api.example.com", Path: "/", Name: "api.index", Handler: funcName(testHandlerFunc)},
+	}
+
+	assert.Equal(t, expected, k.Routes())
+}
@@ -0,0 +1,192 @@
+package kid
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// defaultJSONStreamFlushSize is the default number of items which are written before flushing streamed JSON responses.
+const defaultJSONStreamFlushSize int = 100
+
+// JSONStreamDecoder decodes the elements of a streamed JSON request body one at a time.
+//
+// It's returned by Context.DecodeJSONStream.
+type JSONStreamDecoder struct {
+	reader  *bufio.Reader
+	decoder *json.Decoder
+	started bool
+	array   bool
+	done    bool
+}
+
+// StreamJSON streams the items yielded by iter as a JSON response with the given status code.
+//
+// Items are streamed as the elements of a top-level JSON array, or as newline delimited JSON with the
+// application/x-ndjson Content-Type if the request's Accept header prefers it. The Vary header is set to Accept.
+// Items are encoded with encoding/json and the response is flushed after every N items, which is configured with
+// WithJSONStreamFlushSize, and after the last item.
+//
+// Streaming stops when the request's context is done, e.g. when the client disconnects, and its error is returned.
+// Encoding and writing errors are returned too, they can't be sent to the client since the response is already started.
+// iter must stop when yield returns false, it has the same signature as iter.Seq.
+func (c *Context) StreamJSON(code int, iter func(yield func(item any) bool)) error {
+	addVary(c.response.Header(), "Accept")
+
+	ndjson := negotiateMediaType(c.GetRequestHeader("Accept"), []string{MIMEApplicationJSON, MIMEApplicationNDJSON}) == MIMEApplicationNDJSON
+	if ndjson {
+		c.writeContentType(MIMEApplicationNDJSON)
+	} else {
+		c.writeContentType(MIMEApplicationJSON)
+	}
+
+	c.response.WriteHeader(code)
+
+	ctx := c.Request().Context()
+
+	var err error
+	var count int
+
+	iter(func(item any) bool {
+		if err = ctx.Err(); err != nil {
+			return false
+		}
+
+		var data []byte
+		if data, err = json.Marshal(item); err != nil {
+			return false
+		}
+
+		switch {
+		case ndjson:
+			data = append(data, '\n')
+		case count == 0:
+			data = append([]byte{'['}, data...)
+		default:
+			data = append([]byte{','}, data...)
+		}
+
+		if _, err = c.response.Write(data); err != nil {
+			return false
+		}
+
+		count++
+		if count%c.kid.jsonStreamFlushSize == 0 {
+			c.response.Flush()
+		}
+
+		return true
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if !ndjson {
+		closing := "]\n"
+		if count == 0 {
+			closing = "[]\n"
+		}
+
+		if _, err := c.response.Write([]byte(closing)); err != nil {
+			return err
+		}
+	}
+
+	c.response.Flush()
+
+	return nil
+}
+
+// DecodeJSONStream returns a decoder which decodes the request's body as a stream of JSON values.
+//
+// The body can be a top-level JSON array, whose elements are decoded one by one,
+// or newline delimited JSON and other sequences of JSON values.
+func (c *Context) DecodeJSONStream() *JSONStreamDecoder {
+	reader := bufio.NewReader(c.Request().Body)
+
+	return &JSONStreamDecoder{
+		reader:  reader,
+		decoder: json.NewDecoder(reader),
+	}
+}
+
+// Next decodes the next element of the stream into out, which must be a pointer.
+//
+// Returns io.EOF if there are no more elements, and an error if the stream is not valid JSON
+// or there's data after a top-level array.
+func (d *JSONStreamDecoder) Next(out any) error {
+	if d.done {
+		return io.EOF
+	}
+
+	if !d.started {
+		d.started = true
+
+		if err := d.start(); err != nil {
+			return err
+		}
+	}
+
+	if d.array && !d.decoder.More() {
+		d.done = true
+		return d.end()
+	}
+
+	if err := d.decoder.Decode(out); err != nil {
+		if err == io.EOF {
+			d.done = true
+
+			// The array is not closed.
+			if d.array {
+				return io.ErrUnexpectedEOF
+			}
+		}
+		return err
+	}
+
+	return nil
+}
+
+// start detects whether the stream is a top-level array and reads its opening bracket.
+func (d *JSONStreamDecoder) start() error {
+	for {
+		b, err := d.reader.Peek(1)
+		if err != nil {
+			return err
+		}
+
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			d.reader.Discard(1)
+			continue
+		case '[':
+			d.array = true
+			_, err = d.decoder.Token()
+			return err
+		}
+
+		return nil
+	}
+}
+
+// end reads the closing bracket of the top-level array and checks there's no data after it.
+func (d *JSONStreamDecoder) end() error {
+	if _, err := d.decoder.Token(); err != nil {
+		// The array is not closed.
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		return err
+	}
+
+	if _, err := d.decoder.Token(); err != io.EOF {
+		if err == nil {
+			err = errors.New("unexpected data after JSON array")
+		}
+		return err
+	}
+
+	return io.EOF
+}
@@ -0,0 +1,168 @@
+package kid
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// flushRecorder records the size of the body at each flush.
+type flushRecorder struct {
+	*httptest.ResponseRecorder
+	flushes []int
+}
+
+func (r *flushRecorder) Flush() {
+	r.flushes = append(r.flushes, r.Body.Len())
+	r.ResponseRecorder.Flush()
+}
+
+// items returns an iterator which yields the given items.
+func items(values ...any) func(yield func(any) bool) {
+	return func(yield func(any) bool) {
+		for _, v := range values {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+func TestContext_StreamJSON(t *testing.T) {
+	testCases := []struct {
+		name        string
+		accept      string
+		items       []any
+		contentType string
+		body        string
+	}{
+		{name: "array", items: []any{Map{"id": 1}, Map{"id": 2}}, contentType: "application/json", body: "[{\"id\":1},{\"id\":2}]\n"},
+		{name: "empty_array", contentType: "application/json", body: "[]\n"},
+		{name: "ndjson", accept: "application/x-ndjson", items: []any{Map{"id": 1}, "two"}, contentType: "application/x-ndjson", body: "{\"id\":1}\n\"two\"\n"},
+		{name: "empty_ndjson", accept: "application/x-ndjson", contentType: "application/x-ndjson"},
+		{name: "json_preferred", accept: "application/x-ndjson;q=0.5, application/json", items: []any{1}, contentType: "application/json", body: "[1]\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+
+			res := httptest.NewRecorder()
+			err := New().NewContext(req, res).StreamJSON(http.StatusCreated, items(tc.items...))
+
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusCreated, res.Code)
+			assert.Equal(t, tc.contentType, res.Header().Get("Content-Type"))
+			assert.Equal(t, "Accept", res.Header().Get("Vary"))
+			assert.Equal(t, tc.body, res.Body.String())
+			assert.True(t, res.Flushed)
+		})
+	}
+}
+
+func TestContext_StreamJSON_Flush(t *testing.T) {
+	k := New()
+	k.ApplyOptions(WithJSONStreamFlushSize(2))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "application/x-ndjson")
+
+	res := &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	err := k.NewContext(req, res).StreamJSON(http.StatusOK, items(1, 2, 3, 4, 5))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "1\n2\n3\n4\n5\n", res.Body.String())
+	assert.Equal(t, []int{4, 8, 10}, res.flushes)
+}
+
+func TestContext_StreamJSON_Errors(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+
+	res := httptest.NewRecorder()
+
+	var yielded int
+	err := New().NewContext(req, res).StreamJSON(http.StatusOK, func(yield func(any) bool) {
+		for i := 0; ; i++ {
+			if i == 2 {
+				cancel()
+			}
+
+			if !yield(i) {
+				return
+			}
+			yielded++
+		}
+	})
+
+	// The stream stops when the client disconnects and the array is not closed.
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 2, yielded)
+	assert.Equal(t, "[0,1", res.Body.String())
+
+	res = httptest.NewRecorder()
+	err = New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), res).StreamJSON(http.StatusOK, items(1, make(chan int)))
+
+	assert.Error(t, err)
+	assert.Equal(t, "[1", res.Body.String())
+
+	w := errWriter{httptest.NewRecorder()}
+	err = New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), w).StreamJSON(http.StatusOK, items(1))
+
+	assert.Error(t, err)
+}
+
+func TestContext_DecodeJSONStream(t *testing.T) {
+	testCases := []struct {
+		name     string
+		body     string
+		expected []person
+		invalid  bool
+	}{
+		{name: "array", body: ` [{"name":"foo","age":1}, {"name":"bar","age":2}] `, expected: []person{{"foo", 1}, {"bar", 2}}},
+		{name: "empty_array", body: "[]"},
+		{name: "ndjson", body: "{\"name\":\"foo\",\"age\":1}\n{\"name\":\"bar\",\"age\":2}\n", expected: []person{{"foo", 1}, {"bar", 2}}},
+		{name: "empty", body: ""},
+		{name: "whitespace", body: " \n"},
+		{name: "unclosed_array", body: `[{"name":"foo","age":1},`, expected: []person{{"foo", 1}}, invalid: true},
+		{name: "unclosed_empty_array", body: `[`, invalid: true},
+		{name: "trailing_data", body: `[{"name":"foo","age":1}] {}`, expected: []person{{"foo", 1}}, invalid: true},
+		{name: "invalid", body: `{"name":"foo","age":1} {"name"`, expected: []person{{"foo", 1}}, invalid: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tc.body))
+			decoder := New().NewContext(req, httptest.NewRecorder()).DecodeJSONStream()
+
+			var decoded []person
+			var err error
+			for {
+				var p person
+				if err = decoder.Next(&p); err != nil {
+					break
+				}
+				decoded = append(decoded, p)
+			}
+
+			assert.Equal(t, tc.expected, decoded)
+
+			if tc.invalid {
+				assert.Error(t, err)
+				assert.NotEqual(t, io.EOF, err)
+				return
+			}
+
+			assert.Equal(t, io.EOF, err)
+			assert.Equal(t, io.EOF, decoder.Next(&person{}))
+		})
+	}
+}
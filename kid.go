@@ -5,19 +5,31 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/netip"
+	"net/url"
 	"os"
 	"reflect"
 	"runtime"
+	"strings"
 	"sync"
 
 	htmlrenderer "github.com/mojixcoder/kid/html_renderer"
 	"github.com/mojixcoder/kid/serializer"
+	"github.com/mojixcoder/kid/validator"
 )
 
 type (
 	// HandlerFunc is the type which serves HTTP requests.
 	HandlerFunc func(c *Context)
 
+	// ErrHandlerFunc is a handler which returns an error instead of writing it to the response.
+	//
+	// It can be registered by wrapping it with WrapErrHandlerFunc.
+	ErrHandlerFunc func(c *Context) error
+
+	// HTTPErrorHandler renders the errors of handlers as HTTP responses.
+	HTTPErrorHandler func(c *Context, err error)
+
 	// MiddlewareFunc is the type of middlewares.
 	MiddlewareFunc func(next HandlerFunc) HandlerFunc
 
@@ -34,10 +46,26 @@ type (
 		middlewares             []MiddlewareFunc
 		notFoundHandler         HandlerFunc
 		methodNotAllowedHandler HandlerFunc
-		jsonSerializer          serializer.Serializer
-		xmlSerializer           serializer.Serializer
+		notAcceptableHandler    HandlerFunc
+		optionsHandler          HandlerFunc
+		errorHandler            HTTPErrorHandler
+		serializers             map[string]serializer.Serializer
 		htmlRenderer            htmlrenderer.HTMLRenderer
+		validator               validator.Validator
+		multipartLimits         MultipartLimits
+		jsonStreamFlushSize     int
+		cookieKeyRing           *KeyRing
+		trustedProxies          []netip.Prefix
+		hosts                   []*hostRouter
+		namedRoutes             map[string]string
 		debug                   bool
+		autoHead                bool
+		autoOptions             bool
+		redirectTrailingSlash   bool
+		redirectFixedPath       bool
+		caseInsensitiveRouting  bool
+		mounted                 bool
+		problemDetails          bool
 		pool                    sync.Pool
 	}
 )
@@ -54,18 +82,47 @@ var allMethods = []string{
 
 // New returns a new instance of Kid.
 func New() *Kid {
+	htmlRenderer := htmlrenderer.Default(false)
+	xmlSerializer := serializer.NewXMLSerializer()
+	msgpackSerializer := serializer.NewMessagePackSerializer()
+	yamlSerializer := serializer.NewYAMLSerializer()
+	protobufSerializer := serializer.NewProtobufSerializer()
+
+	serializers := map[string]serializer.Serializer{
+		MIMEApplicationJSON:      serializer.NewJSONSerializer(),
+		MIMEApplicationXML:       xmlSerializer,
+		MIMETextXML:              xmlSerializer,
+		MIMEApplicationMsgPack:   msgpackSerializer,
+		MIMEApplicationXMsgPack:  msgpackSerializer,
+		MIMEApplicationCBOR:      serializer.NewCBORSerializer(),
+		MIMEApplicationYAML:      yamlSerializer,
+		MIMEApplicationXYAML:     yamlSerializer,
+		MIMETextYAML:             yamlSerializer,
+		MIMEApplicationProtobuf:  protobufSerializer,
+		MIMEApplicationXProtobuf: protobufSerializer,
+	}
+
 	kid := Kid{
 		router:                  newTree(),
 		middlewares:             make([]MiddlewareFunc, 0),
 		notFoundHandler:         defaultNotFoundHandler,
 		methodNotAllowedHandler: defaultMethodNotAllowedHandler,
-		jsonSerializer:          serializer.NewJSONSerializer(),
-		xmlSerializer:           serializer.NewXMLSerializer(),
-		htmlRenderer:            htmlrenderer.Default(false),
+		notAcceptableHandler:    defaultNotAcceptableHandler,
+		optionsHandler:          defaultOptionsHandler,
+		errorHandler:            defaultErrorHandler,
+		serializers:             serializers,
+		htmlRenderer:            htmlRenderer,
+		validator:               validator.New(),
+		jsonStreamFlushSize:     defaultJSONStreamFlushSize,
+		namedRoutes:             make(map[string]string),
 		debug:                   true,
+		autoHead:                true,
+		autoOptions:             true,
 		mutex:                   sync.Mutex{},
 	}
 
+	htmlRenderer.SetFunc("urlfor", kid.urlFor)
+
 	kid.pool.New = func() any {
 		return newContext(&kid)
 	}
@@ -80,6 +137,7 @@ func (k *Kid) Run(addrs ...string) error {
 	address := k.setUpServer(addrs)
 
 	k.printDebug(os.Stdout, "Kid version %s\n", Version)
+	k.printRoutes(os.Stdout)
 	k.printDebug(os.Stdout, "Starting server at %s\n", address)
 	k.printDebug(os.Stdout, "Quit the server with CONTROL-C\n")
 
@@ -93,6 +151,7 @@ func (k *Kid) RunTLS(certFile, keyFile string, addrs ...string) error {
 	address := k.setUpServer(addrs)
 
 	k.printDebug(os.Stdout, "Kid version %s\n", Version)
+	k.printRoutes(os.Stdout)
 	k.printDebug(os.Stdout, "Starting TLS server at %s\n", address)
 	k.printDebug(os.Stdout, "Quit the server with CONTROL-C\n")
 
@@ -117,71 +176,71 @@ func (k *Kid) Use(middleware MiddlewareFunc) {
 // Get registers a new handler for the given path for GET method.
 //
 // Specifying middlewares is optional. Middlewares will only be applied to this route.
-func (k *Kid) Get(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) {
-	k.router.insertNode(path, []string{http.MethodGet}, middlewares, handler)
+func (k *Kid) Get(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return k.Add(path, handler, []string{http.MethodGet}, middlewares...)
 }
 
 // Post registers a new handler for the given path for POST method.
 //
 // Specifying middlewares is optional. Middlewares will only be applied to this route.
-func (k *Kid) Post(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) {
-	k.router.insertNode(path, []string{http.MethodPost}, middlewares, handler)
+func (k *Kid) Post(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return k.Add(path, handler, []string{http.MethodPost}, middlewares...)
 }
 
 // Put registers a new handler for the given path for PUT method.
 //
 // Specifying middlewares is optional. Middlewares will only be applied to this route.
-func (k *Kid) Put(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) {
-	k.router.insertNode(path, []string{http.MethodPut}, middlewares, handler)
+func (k *Kid) Put(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return k.Add(path, handler, []string{http.MethodPut}, middlewares...)
 }
 
 // Patch registers a new handler for the given path for PATCH method.
 //
 // Specifying middlewares is optional. Middlewares will only be applied to this route.
-func (k *Kid) Patch(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) {
-	k.router.insertNode(path, []string{http.MethodPatch}, middlewares, handler)
+func (k *Kid) Patch(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return k.Add(path, handler, []string{http.MethodPatch}, middlewares...)
 }
 
 // Delete registers a new handler for the given path for DELETE method.
 //
 // Specifying middlewares is optional. Middlewares will only be applied to this route.
-func (k *Kid) Delete(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) {
-	k.router.insertNode(path, []string{http.MethodDelete}, middlewares, handler)
+func (k *Kid) Delete(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return k.Add(path, handler, []string{http.MethodDelete}, middlewares...)
 }
 
 // Head registers a new handler for the given path for HEAD method.
 //
 // Specifying middlewares is optional. Middlewares will only be applied to this route.
-func (k *Kid) Head(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) {
-	k.router.insertNode(path, []string{http.MethodHead}, middlewares, handler)
+func (k *Kid) Head(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return k.Add(path, handler, []string{http.MethodHead}, middlewares...)
 }
 
 // Options registers a new handler for the given path for OPTIONS method.
 //
 // Specifying middlewares is optional. Middlewares will only be applied to this route.
-func (k *Kid) Options(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) {
-	k.router.insertNode(path, []string{http.MethodOptions}, middlewares, handler)
+func (k *Kid) Options(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return k.Add(path, handler, []string{http.MethodOptions}, middlewares...)
 }
 
 // Connect registers a new handler for the given path for CONNECT method.
 //
 // Specifying middlewares is optional. Middlewares will only be applied to this route.
-func (k *Kid) Connect(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) {
-	k.router.insertNode(path, []string{http.MethodConnect}, middlewares, handler)
+func (k *Kid) Connect(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return k.Add(path, handler, []string{http.MethodConnect}, middlewares...)
 }
 
 // Trace registers a new handler for the given path for TRACE method.
 //
 // Specifying middlewares is optional. Middlewares will only be applied to this route.
-func (k *Kid) Trace(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) {
-	k.router.insertNode(path, []string{http.MethodTrace}, middlewares, handler)
+func (k *Kid) Trace(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return k.Add(path, handler, []string{http.MethodTrace}, middlewares...)
 }
 
 // Any registers a new handler for the given path for all of the HTTP methods.
 //
 // Specifying middlewares is optional. Middlewares will only be applied to this route.
-func (k *Kid) Any(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) {
-	k.router.insertNode(path, allMethods, middlewares, handler)
+func (k *Kid) Any(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return k.Add(path, handler, allMethods, middlewares...)
 }
 
 // Group creates a new router group.
@@ -191,12 +250,36 @@ func (k *Kid) Group(prefix string, middlewares ...MiddlewareFunc) Group {
 	return newGroup(k, prefix, middlewares...)
 }
 
+// Host creates a new router group scoped to the given host pattern, e.g. {tenant}.example.com.
+//
+// Host routes have their own routing tree and requests whose host matches the pattern will only be routed to them.
+// Host parameters are accessible using Context.Param like path parameters.
+//
+// Specifying middlewares is optional. Middlewares will be applied to all of the requests matching the host.
+func (k *Kid) Host(pattern string, middlewares ...MiddlewareFunc) Group {
+	host := k.getHost(pattern)
+	host.middlewares = append(host.middlewares, middlewares...)
+
+	g := newGroup(k, "")
+	g.host = host
+
+	return g
+}
+
 // Add registers a new handler for the given path for the given methods.
 // Specifying at least one method is required.
 //
 // Specifying middlewares is optional. Middlewares will only be applied to this route.
-func (k *Kid) Add(path string, handler HandlerFunc, methods []string, middlewares ...MiddlewareFunc) {
-	k.router.insertNode(path, methods, middlewares, handler)
+//
+// It returns the registered route which can be used to name it.
+func (k *Kid) Add(path string, handler HandlerFunc, methods []string, middlewares ...MiddlewareFunc) *Route {
+	return k.addRoute(&k.router, path, handler, methods, middlewares)
+}
+
+// addRoute registers a new handler in the given router.
+func (k *Kid) addRoute(router *Tree, path string, handler HandlerFunc, methods []string, middlewares []MiddlewareFunc) *Route {
+	node := router.insertNode(path, methods, middlewares, handler)
+	return newRoute(k, node, cleanPath(path, false), methods)
 }
 
 // Static registers a new route for serving static files.
@@ -222,33 +305,187 @@ func (k *Kid) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	c := k.pool.Get().(*Context)
 	c.reset(r, w)
 
-	route, params, err := k.router.search(c.Path(), r.Method)
+	handler := k.getHandler(c)
 
-	c.setParams(params)
+	handler(c)
 
-	var handler HandlerFunc
+	// The heartbeat of an SSE writer which is not closed by the handler must not write to the pooled context.
+	c.closeSSE()
 
-	if err == errNotFound {
-		handler = k.applyMiddlewaresToHandler(k.notFoundHandler, k.middlewares...)
-		c.setRouteName("Not Found")
-	} else if err == errMethodNotAllowed {
-		handler = k.applyMiddlewaresToHandler(k.methodNotAllowedHandler, k.middlewares...)
-		c.setRouteName("Method Not Allowed")
-	} else {
-		handler = k.applyMiddlewaresToHandler(route.handler, route.middlewares...)
-		handler = k.applyMiddlewaresToHandler(handler, k.middlewares...)
-		c.setRouteName(route.name)
-	}
-
-	handler(c)
+	// Errors reported by middlewares after the route handler are rendered here.
+	k.handleError(c)
 
 	if !c.Response().Written() {
 		c.Response().WriteHeaderNow()
 	}
 
+	c.removeMultipartFiles()
+
+	k.reportMountedRoute(c)
+
 	k.pool.Put(c)
 }
 
+// getHandler finds the handler of the request and applies the middlewares to it.
+func (k *Kid) getHandler(c *Context) HandlerFunc {
+	router := &k.router
+
+	host := k.matchHost(c)
+	if host != nil {
+		router = &host.router
+	}
+
+	handler := k.getRouteHandler(c, router)
+
+	if host != nil {
+		handler = k.applyMiddlewaresToHandler(handler, host.middlewares...)
+	}
+
+	return k.applyMiddlewaresToHandler(handler, k.middlewares...)
+}
+
+// getRouteHandler finds the handler of the request in the given router and applies the route middlewares to it.
+func (k *Kid) getRouteHandler(c *Context, router *Tree) HandlerFunc {
+	node := router.match(c.Path(), c.params)
+	if node == nil {
+		if location, ok := k.getRedirectLocation(c, router); ok {
+			c.setRouteName("Redirect")
+			return newRedirectHandler(location)
+		}
+
+		c.setRouteName("Not Found")
+		return k.notFoundHandler
+	}
+
+	method := c.Method()
+	hm, ok := node.handlerMap[method]
+
+	// HEAD requests are served by GET handlers, without response body.
+	if !ok && method == http.MethodHead && k.autoHead {
+		if hm, ok = node.handlerMap[http.MethodGet]; ok {
+			c.response.(*response).discardBody = true
+		}
+	}
+
+	if ok {
+		c.setRouteName(hm.name)
+		c.setRouteOptions(hm.options)
+
+		for name, value := range hm.defaults {
+			c.params[name] = value
+		}
+
+		return k.applyMiddlewaresToHandler(k.withErrorHandling(hm.handler), hm.middlewares...)
+	}
+
+	c.SetResponseHeader("Allow", k.allowedMethods(node))
+
+	if method == http.MethodOptions && k.autoOptions {
+		for _, hm := range node.handlerMap {
+			c.setRouteName(hm.name)
+			break
+		}
+		return k.optionsHandler
+	}
+
+	c.setRouteName("Method Not Allowed")
+	return k.methodNotAllowedHandler
+}
+
+// withErrorHandling returns a handler which renders the error reported by the route handler,
+// so middlewares can see the rendered response.
+func (k *Kid) withErrorHandling(handler HandlerFunc) HandlerFunc {
+	return func(c *Context) {
+		handler(c)
+		k.handleError(c)
+	}
+}
+
+// handleError renders the request's error with the error handler, unless the response is already written.
+func (k *Kid) handleError(c *Context) {
+	if c.err != nil && !c.Response().Written() {
+		k.errorHandler(c, c.err)
+	}
+}
+
+// getRedirectLocation returns the location which the request should be redirected to,
+// if a route exists under the corrected path according to the redirect policies.
+func (k *Kid) getRedirectLocation(c *Context, router *Tree) (string, bool) {
+	if c.Method() == http.MethodConnect {
+		return "", false
+	}
+
+	path := c.Path()
+	fixedPath, ok := k.fixPath(router, path, make(Params))
+
+	if !ok {
+		return "", false
+	}
+
+	u := c.Request().URL
+	if u.RawPath == "" {
+		fixedPath = (&url.URL{Path: fixedPath}).EscapedPath()
+	}
+
+	if u.RawQuery != "" {
+		fixedPath += "?" + u.RawQuery
+	}
+
+	return fixedPath, true
+}
+
+// fixPath corrects the path according to the redirect policies and returns it if a route exists under it.
+func (k *Kid) fixPath(router *Tree, path string, params Params) (string, bool) {
+	candidate := path
+	if k.redirectFixedPath {
+		candidate = cleanRequestPath(path)
+	}
+
+	candidates := []string{candidate}
+	if k.redirectTrailingSlash {
+		candidates = append(candidates, toggleTrailingSlash(candidate))
+	}
+
+	for _, candidate := range candidates {
+		if candidate != path && router.match(candidate, params) != nil {
+			return candidate, true
+		}
+
+		if k.caseInsensitiveRouting {
+			if fixedPath, ok := router.matchCaseInsensitive(candidate); ok && fixedPath != path {
+				return fixedPath, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// allowedMethods returns the comma separated list of the methods which are allowed for the node.
+//
+// HEAD and OPTIONS are also included if they are handled automatically.
+func (k *Kid) allowedMethods(node *Node) string {
+	methods := make([]string, 0, len(allMethods))
+
+	for _, method := range allMethods {
+		_, ok := node.handlerMap[method]
+
+		switch method {
+		case http.MethodHead:
+			_, hasGet := node.handlerMap[http.MethodGet]
+			ok = ok || (k.autoHead && hasGet)
+		case http.MethodOptions:
+			ok = ok || k.autoOptions
+		}
+
+		if ok {
+			methods = append(methods, method)
+		}
+	}
+
+	return strings.Join(methods, ", ")
+}
+
 // applyMiddlewaresToHandler applies middlewares to the handler and returns the handler.
 func (k *Kid) applyMiddlewaresToHandler(handler HandlerFunc, middlewares ...MiddlewareFunc) HandlerFunc {
 	for i := len(middlewares) - 1; i >= 0; i-- {
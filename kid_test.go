@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -12,6 +13,7 @@ import (
 	"time"
 
 	"github.com/mojixcoder/kid/serializer"
+	"github.com/mojixcoder/kid/validator"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -21,10 +23,22 @@ func TestNew(t *testing.T) {
 	assert.NotNil(t, k)
 	assert.Equal(t, newTree(), k.router)
 	assert.Equal(t, 0, len(k.middlewares))
-	assert.Equal(t, serializer.NewJSONSerializer(), k.jsonSerializer)
-	assert.Equal(t, serializer.NewXMLSerializer(), k.xmlSerializer)
+	assert.Equal(t, serializer.NewJSONSerializer(), k.serializers["application/json"])
+	assert.Equal(t, serializer.NewXMLSerializer(), k.serializers["application/xml"])
+	assert.Equal(t, serializer.NewXMLSerializer(), k.serializers["text/xml"])
+	assert.Equal(t, serializer.NewMessagePackSerializer(), k.serializers["application/msgpack"])
+	assert.Equal(t, serializer.NewMessagePackSerializer(), k.serializers["application/x-msgpack"])
+	assert.Equal(t, serializer.NewCBORSerializer(), k.serializers["application/cbor"])
+	assert.Equal(t, serializer.NewYAMLSerializer(), k.serializers["application/yaml"])
+	assert.Equal(t, serializer.NewYAMLSerializer(), k.serializers["application/x-yaml"])
+	assert.Equal(t, serializer.NewYAMLSerializer(), k.serializers["text/yaml"])
+	assert.Equal(t, serializer.NewProtobufSerializer(), k.serializers["application/protobuf"])
+	assert.Equal(t, serializer.NewProtobufSerializer(), k.serializers["application/x-protobuf"])
+	assert.Equal(t, validator.New(), k.validator)
 	assert.True(t, funcsAreEqual(defaultNotFoundHandler, k.notFoundHandler))
 	assert.True(t, funcsAreEqual(defaultMethodNotAllowedHandler, k.methodNotAllowedHandler))
+	assert.True(t, funcsAreEqual(defaultNotAcceptableHandler, k.notAcceptableHandler))
+	assert.True(t, funcsAreEqual(defaultErrorHandler, k.errorHandler))
 	assert.True(t, k.Debug())
 }
 
@@ -393,9 +407,276 @@ func TestKid_ServeHTTP_MethodnotAllowed(t *testing.T) {
 
 	assert.Equal(t, http.StatusMethodNotAllowed, res.Code)
 	assert.Equal(t, "application/json", res.Header().Get("Content-Type"))
+	assert.Equal(t, "GET, HEAD, OPTIONS", res.Header().Get("Allow"))
 	assert.Equal(t, "{\"message\":\"Method Not Allowed\"}\n", res.Body.String())
 }
 
+func TestKid_ServeHTTP_OptionalParams(t *testing.T) {
+	k := New()
+
+	k.Get("/reports/{year?}/{page=1:int}", func(c *Context) {
+		c.JSON(http.StatusOK, Map{"route": c.Route(), "params": c.Params(), "page": c.Param("page")})
+	})
+
+	testCases := []struct {
+		path         string
+		expectedBody string
+	}{
+		{path: "/reports", expectedBody: `{"page":"1","params":{"page":"1"},"route":"/reports/{year?}/{page=1:int}"}`},
+		{path: "/reports/2024", expectedBody: `{"page":"1","params":{"page":"1","year":"2024"},"route":"/reports/{year?}/{page=1:int}"}`},
+		{path: "/reports/2024/3", expectedBody: `{"page":"3","params":{"page":"3","year":"2024"},"route":"/reports/{year?}/{page=1:int}"}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			res := httptest.NewRecorder()
+
+			k.ServeHTTP(res, req)
+
+			assert.Equal(t, http.StatusOK, res.Code)
+			assert.Equal(t, tc.expectedBody+"\n", res.Body.String())
+		})
+	}
+}
+
+func TestKid_ServeHTTP_AutoHead(t *testing.T) {
+	k := New()
+
+	k.Get("/test", func(c *Context) {
+		c.SetResponseHeader("X-Method", c.Method())
+		c.String(http.StatusAccepted, "body")
+	})
+
+	req := httptest.NewRequest(http.MethodHead, "/test", nil)
+	res := httptest.NewRecorder()
+
+	k.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusAccepted, res.Code)
+	assert.Equal(t, http.MethodHead, res.Header().Get("X-Method"))
+	assert.Equal(t, "text/plain", res.Header().Get("Content-Type"))
+	assert.Empty(t, res.Body.String())
+
+	k.ApplyOptions(WithAutoHead(false))
+
+	res = httptest.NewRecorder()
+
+	k.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, res.Code)
+	assert.Equal(t, "GET, OPTIONS", res.Header().Get("Allow"))
+}
+
+func TestKid_ServeHTTP_AutoOptions(t *testing.T) {
+	k := New()
+
+	k.Add("/test", testHandlerFunc, []string{http.MethodPost, http.MethodGet, http.MethodDelete})
+	k.Options("/custom", func(c *Context) {
+		c.String(http.StatusOK, "custom")
+	})
+
+	req := httptest.NewRequest(http.MethodOptions, "/test", nil)
+	res := httptest.NewRecorder()
+
+	k.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusNoContent, res.Code)
+	assert.Equal(t, "GET, POST, DELETE, HEAD, OPTIONS", res.Header().Get("Allow"))
+
+	req = httptest.NewRequest(http.MethodOptions, "/custom", nil)
+	res = httptest.NewRecorder()
+
+	k.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "custom", res.Body.String())
+	assert.Empty(t, res.Header().Get("Allow"))
+
+	k.ApplyOptions(WithAutoOptions(false), WithAutoHead(false))
+
+	req = httptest.NewRequest(http.MethodOptions, "/test", nil)
+	res = httptest.NewRecorder()
+
+	k.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, res.Code)
+	assert.Equal(t, "GET, POST, DELETE", res.Header().Get("Allow"))
+}
+
+func TestKid_ServeHTTP_AutoOptions_Middlewares(t *testing.T) {
+	k := New()
+
+	k.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			c.SetResponseHeader("X-Route", c.Route())
+			next(c)
+		}
+	})
+
+	k.Get("/users/{id}", testHandlerFunc)
+
+	req := httptest.NewRequest(http.MethodOptions, "/users/1", nil)
+	res := httptest.NewRecorder()
+
+	k.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusNoContent, res.Code)
+	assert.Equal(t, "/users/{id}", res.Header().Get("X-Route"))
+}
+
+func TestKid_ServeHTTP_Redirects(t *testing.T) {
+	k := New()
+
+	k.Get("/users", testHandlerFunc)
+	k.Post("/posts/", testHandlerFunc)
+	k.Get("/Files/{*path}", testHandlerFunc)
+	k.Get("/a b", testHandlerFunc)
+
+	testCases := []struct {
+		name, method, path string
+		opts               []Option
+		expectedCode       int
+		expectedLocation   string
+	}{
+		{name: "disabled", method: http.MethodGet, path: "/users/", expectedCode: http.StatusNotFound},
+		{
+			name: "trailing_slash_removed", method: http.MethodGet, path: "/users/?page=2",
+			opts:         []Option{WithRedirectTrailingSlash(true)},
+			expectedCode: http.StatusMovedPermanently, expectedLocation: "/users?page=2",
+		},
+		{
+			name: "trailing_slash_added", method: http.MethodPost, path: "/posts",
+			opts:         []Option{WithRedirectTrailingSlash(true)},
+			expectedCode: http.StatusPermanentRedirect, expectedLocation: "/posts/",
+		},
+		{
+			name: "fixed_path", method: http.MethodGet, path: "/a/..//users",
+			opts:         []Option{WithRedirectFixedPath(true)},
+			expectedCode: http.StatusMovedPermanently, expectedLocation: "/users",
+		},
+		{
+			name: "fixed_path_without_trailing_slash_redirect", method: http.MethodGet, path: "//users/",
+			opts:         []Option{WithRedirectFixedPath(true)},
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name: "fixed_path_and_trailing_slash", method: http.MethodGet, path: "//users/",
+			opts:         []Option{WithRedirectFixedPath(true), WithRedirectTrailingSlash(true)},
+			expectedCode: http.StatusMovedPermanently, expectedLocation: "/users",
+		},
+		{
+			name: "case_insensitive", method: http.MethodHead, path: "/files/Static/Main.css",
+			opts:         []Option{WithCaseInsensitiveRouting(true)},
+			expectedCode: http.StatusMovedPermanently, expectedLocation: "/Files/Static/Main.css",
+		},
+		{
+			name: "case_insensitive_and_trailing_slash", method: http.MethodDelete, path: "/POSTS",
+			opts:         []Option{WithCaseInsensitiveRouting(true), WithRedirectTrailingSlash(true)},
+			expectedCode: http.StatusPermanentRedirect, expectedLocation: "/posts/",
+		},
+		{
+			name: "escaped_location", method: http.MethodGet, path: "/a%20b/",
+			opts:         []Option{WithRedirectTrailingSlash(true)},
+			expectedCode: http.StatusMovedPermanently, expectedLocation: "/a%20b",
+		},
+		{
+			name: "not_found", method: http.MethodGet, path: "/unknown/",
+			opts:         []Option{WithRedirectTrailingSlash(true), WithRedirectFixedPath(true), WithCaseInsensitiveRouting(true)},
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			k.ApplyOptions(WithRedirectTrailingSlash(false), WithRedirectFixedPath(false), WithCaseInsensitiveRouting(false))
+			k.ApplyOptions(tc.opts...)
+
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			res := httptest.NewRecorder()
+
+			k.ServeHTTP(res, req)
+
+			assert.Equal(t, tc.expectedCode, res.Code)
+			assert.Equal(t, tc.expectedLocation, res.Header().Get("Location"))
+		})
+	}
+}
+
+func TestKid_ServeHTTP_Errors(t *testing.T) {
+	var handled []error
+
+	k := New()
+	k.ApplyOptions(WithErrorHandler(func(c *Context, err error) {
+		handled = append(handled, err)
+		c.String(http.StatusTeapot, err.Error())
+	}))
+
+	var seenStatus int
+	var seenErr error
+
+	k.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			next(c)
+			seenStatus = c.Response().Status()
+			seenErr = c.Err()
+		}
+	})
+
+	errHandler := errors.New("handler error")
+	errMiddleware := errors.New("middleware error")
+	errWritten := errors.New("written error")
+
+	k.Get("/handler", WrapErrHandlerFunc(func(c *Context) error {
+		return errHandler
+	}))
+
+	k.Get("/middleware", testHandlerFunc, func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			c.Error(errMiddleware)
+		}
+	})
+
+	k.Get("/written", WrapErrHandlerFunc(func(c *Context) error {
+		c.NoContent(http.StatusAccepted)
+		return errWritten
+	}))
+
+	testCases := []struct {
+		path           string
+		expectedCode   int
+		expectedErr    error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{path: "/handler", expectedCode: http.StatusTeapot, expectedErr: errHandler, expectedStatus: http.StatusTeapot, expectedBody: "handler error"},
+		{path: "/middleware", expectedCode: http.StatusTeapot, expectedErr: errMiddleware, expectedStatus: http.StatusOK, expectedBody: "middleware error"},
+		{path: "/written", expectedCode: http.StatusAccepted, expectedErr: errWritten, expectedStatus: http.StatusAccepted},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			handled = nil
+
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			res := httptest.NewRecorder()
+
+			k.ServeHTTP(res, req)
+
+			assert.Equal(t, tc.expectedCode, res.Code)
+			assert.Equal(t, tc.expectedBody, res.Body.String())
+			assert.Equal(t, tc.expectedStatus, seenStatus)
+			assert.Equal(t, tc.expectedErr, seenErr)
+
+			if tc.expectedBody != "" {
+				assert.Equal(t, []error{tc.expectedErr}, handled)
+			} else {
+				assert.Empty(t, handled)
+			}
+		})
+	}
+}
+
 func TestKid_ServeHTTP_WriteStatusCodeIfNotWritten(t *testing.T) {
 	k := New()
 
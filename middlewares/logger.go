@@ -113,6 +113,10 @@ func NewLoggerWithConfig(cfg LoggerConfig) kid.MiddlewareFunc {
 				slog.String("user_agent", c.GetRequestHeader("User-Agent")),
 			}
 
+			if err := c.Err(); err != nil {
+				attrs = append(attrs, slog.String("error", err.Error()))
+			}
+
 			if status < 400 {
 				logger.LogAttrs(context.Background(), successLvl, "SUCCESS", attrs...)
 			} else if status <= 499 {
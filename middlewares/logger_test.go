@@ -5,6 +5,7 @@ package middlewares
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"io"
 	"log/slog"
 	"net/http"
@@ -26,6 +27,7 @@ type logRecord struct {
 	Path      string    `json:"path"`
 	Method    string    `json:"method"`
 	UserAgent string    `json:"user_agent"`
+	Error     string    `json:"error"`
 }
 
 func TestNewLogger(t *testing.T) {
@@ -131,6 +133,33 @@ func TestNewLoggerWithConfig(t *testing.T) {
 	}
 }
 
+func TestLogger_Error(t *testing.T) {
+	var buf bytes.Buffer
+
+	cfg := DefaultLoggerConfig
+	cfg.Out = &buf
+
+	k := kid.New()
+	k.Use(NewLoggerWithConfig(cfg))
+
+	k.Get("/", kid.WrapErrHandlerFunc(func(c *kid.Context) error {
+		return kid.NewHTTPError(http.StatusNotFound, "").WithCause(errors.New("no rows"))
+	}))
+
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	k.ServeHTTP(res, req)
+
+	var logRecord logRecord
+	err := json.Unmarshal(buf.Bytes(), &logRecord)
+	assert.NoError(t, err)
+
+	assert.Equal(t, http.StatusNotFound, logRecord.Status)
+	assert.Equal(t, "CLIENT ERROR", logRecord.Msg)
+	assert.Equal(t, "404 Not Found: no rows", logRecord.Error)
+}
+
 func TestLogger_Skipper(t *testing.T) {
 	var buf bytes.Buffer
 
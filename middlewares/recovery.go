@@ -22,6 +22,8 @@ type RecoveryConfig struct {
 	Writer io.Writer
 
 	// OnRecovery is the function which will be called when a recovery occurs.
+	//
+	// The default one renders an internal server error with Kid's error handler.
 	OnRecovery func(c *kid.Context, err any)
 }
 
@@ -30,7 +32,7 @@ var DefaultRecoveryConfig = RecoveryConfig{
 	LogRecovers: true,
 	Writer:      os.Stdout,
 	OnRecovery: func(c *kid.Context, err any) {
-		c.JSON(http.StatusInternalServerError, kid.Map{"message": http.StatusText(http.StatusInternalServerError)})
+		c.HandleError(kid.NewHTTPError(http.StatusInternalServerError, "").WithCause(fmt.Errorf("panic: %v", err)))
 	},
 }
 
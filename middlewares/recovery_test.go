@@ -61,4 +61,20 @@ func TestNewRecovery(t *testing.T) {
 
 	assert.Equal(t, res.Code, http.StatusInternalServerError)
 	assert.Equal(t, "{\"message\":\"Internal Server Error\"}\n", res.Body.String())
+	assert.EqualError(t, ctx.Err(), "500 Internal Server Error: panic: err")
+}
+
+func TestNewRecovery_ProblemDetails(t *testing.T) {
+	k := kid.New()
+	k.ApplyOptions(kid.WithProblemDetails(true))
+
+	recovery := NewRecovery()
+
+	res := httptest.NewRecorder()
+	ctx := k.NewContext(httptest.NewRequest(http.MethodGet, "/panic", nil), res)
+	recovery(recoveryHandler)(ctx)
+
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+	assert.Equal(t, "application/problem+json", res.Header().Get("Content-Type"))
+	assert.Equal(t, `{"instance":"/panic","status":500,"title":"Internal Server Error"}`+"\n", res.Body.String())
 }
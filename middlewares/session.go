@@ -0,0 +1,564 @@
+package middlewares
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mojixcoder/kid"
+)
+
+// flashesKey is the session key which holds the flash messages.
+const flashesKey = "_flashes"
+
+// SessionConfig is the config used to build a Session middleware.
+type SessionConfig struct {
+	// Store is where sessions are stored.
+	//
+	// Defaults to a new memory store which holds at most DefaultMemoryStoreSize sessions.
+	Store SessionStore
+
+	// ContextKey is the key of the session in the context storage.
+	//
+	// Defaults to "session".
+	ContextKey string
+
+	// CookieName is the name of the session cookie.
+	//
+	// Defaults to "session".
+	CookieName string
+
+	// CookiePath is the path of the session cookie.
+	//
+	// Defaults to "/".
+	CookiePath string
+
+	// CookieDomain is the domain of the session cookie.
+	//
+	// Defaults to "".
+	CookieDomain string
+
+	// CookieSameSite is the SameSite attribute of the session cookie.
+	//
+	// Defaults to Lax.
+	CookieSameSite http.SameSite
+
+	// IdleTimeout is the duration after which inactive sessions expire.
+	// Sessions are saved on every request to keep them alive if it's set.
+	//
+	// Will not be used if 0.
+	// Defaults to 30 minutes.
+	IdleTimeout time.Duration
+
+	// AbsoluteTimeout is the duration after which sessions expire, regardless of their activity.
+	//
+	// Will not be used if 0.
+	// Defaults to 24 hours.
+	AbsoluteTimeout time.Duration
+
+	// OnError is called with the errors of saving sessions just before the response headers are written,
+	// when they can't be rendered with Kid's error handler anymore.
+	//
+	// Defaults to reporting them with Context.Error, so they can be logged by the Logger middleware.
+	OnError func(c *kid.Context, err error)
+}
+
+type (
+	// SessionData is the data of a session which is saved in the stores.
+	SessionData struct {
+		// ID is the unique ID of the session.
+		ID string `json:"id"`
+
+		// Values are the values of the session.
+		Values map[string]any `json:"values"`
+
+		// CreatedAt is when the session was created.
+		CreatedAt time.Time `json:"created_at"`
+
+		// LastSeenAt is when the session was last accessed.
+		LastSeenAt time.Time `json:"last_seen_at"`
+	}
+
+	// Session is the session of the current request.
+	//
+	// Changes are saved with Session.Save, after the handler returns if the response is not written yet,
+	// or just before the response headers are written. It's safe for concurrent use.
+	Session struct {
+		mutex     sync.Mutex
+		data      SessionData
+		isNew     bool
+		dirty     bool
+		destroyed bool
+
+		// cookieDeleted is true if the session cookie of the destroyed session is already deleted.
+		cookieDeleted bool
+
+		// staleIDs are the IDs which should be deleted from the store, e.g. the ID before regeneration.
+		staleIDs []string
+
+		// save saves the session, it's set by the Session middleware.
+		save func() error
+	}
+)
+
+// DefaultSessionConfig is the default Session config.
+var DefaultSessionConfig = SessionConfig{
+	ContextKey:      "session",
+	CookieName:      "session",
+	CookiePath:      "/",
+	IdleTimeout:     30 * time.Minute,
+	AbsoluteTimeout: 24 * time.Hour,
+}
+
+// NewSession returns a new Session middleware.
+func NewSession() kid.MiddlewareFunc {
+	return NewSessionWithConfig(DefaultSessionConfig)
+}
+
+// NewSessionWithConfig returns a new Session middleware with the given config.
+//
+// The session of the request is loaded into the context storage and can be accessed with GetSession.
+// Errors of loading sessions are rendered with Kid's error handler. Errors of saving them are rendered too
+// if the response is not written when the handler returns, otherwise they are passed to OnError.
+// Handlers can save sessions with Session.Save before writing the response, to handle the errors themselves.
+func NewSessionWithConfig(cfg SessionConfig) kid.MiddlewareFunc {
+	setSessionDefaults(&cfg)
+
+	return func(next kid.HandlerFunc) kid.HandlerFunc {
+		return func(c *kid.Context) {
+			session, err := loadSession(c, cfg)
+			if err != nil {
+				c.HandleError(err)
+				return
+			}
+
+			session.save = func() error {
+				return saveSession(c, cfg, session)
+			}
+
+			c.Set(cfg.ContextKey, session)
+
+			c.BeforeWriteHeader(func() {
+				if err := session.save(); err != nil {
+					cfg.OnError(c, err)
+				}
+			})
+
+			next(c)
+
+			// Sessions are saved before the response is written when possible, so the errors can be rendered.
+			if !c.Response().Written() {
+				if err := session.save(); err != nil {
+					c.HandleError(err)
+				}
+			}
+		}
+	}
+}
+
+// GetSession returns the session of the request which is loaded by the Session middleware with the default context key.
+//
+// Panics if the Session middleware is not applied.
+func GetSession(c *kid.Context) *Session {
+	return GetSessionWithKey(c, DefaultSessionConfig.ContextKey)
+}
+
+// GetSessionWithKey returns the session of the request which is loaded by the Session middleware with the given context key.
+//
+// Panics if the Session middleware is not applied.
+func GetSessionWithKey(c *kid.Context, key string) *Session {
+	value, _ := c.Get(key)
+
+	session, ok := value.(*Session)
+	if !ok {
+		panic("session middleware is not applied")
+	}
+
+	return session
+}
+
+// loadSession loads the session of the request, or starts a new one if it doesn't have a valid session.
+func loadSession(c *kid.Context, cfg SessionConfig) (*Session, error) {
+	now := time.Now()
+
+	token, err := c.Cookie(cfg.CookieName)
+	if err != nil {
+		return newSession(now), nil
+	}
+
+	data, err := cfg.Store.Load(c.Request().Context(), token)
+	if err != nil {
+		if errors.Is(err, ErrSessionNotFound) {
+			return newSession(now), nil
+		}
+		return nil, err
+	}
+
+	if isSessionExpired(data, cfg, now) {
+		session := newSession(now)
+		session.staleIDs = append(session.staleIDs, data.ID)
+		return session, nil
+	}
+
+	if data.Values == nil {
+		data.Values = make(map[string]any)
+	}
+
+	session := &Session{data: data}
+
+	// Active sessions are saved to extend their idle timeout.
+	if cfg.IdleTimeout > 0 {
+		session.data.LastSeenAt = now
+		session.dirty = true
+	}
+
+	return session, nil
+}
+
+// saveSession saves the session if it's changed and sets the session cookie.
+func saveSession(c *kid.Context, cfg SessionConfig, session *Session) error {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	ctx := c.Request().Context()
+
+	for _, id := range session.staleIDs {
+		if err := cfg.Store.Delete(ctx, id); err != nil {
+			return err
+		}
+	}
+	session.staleIDs = nil
+
+	if session.destroyed {
+		if !session.isNew && !session.cookieDeleted {
+			c.SetCookie(sessionCookie(cfg, "", -1))
+			session.cookieDeleted = true
+		}
+		return nil
+	}
+
+	if !session.dirty {
+		return nil
+	}
+
+	expiresAt := sessionExpiry(session.data, cfg)
+
+	token, err := cfg.Store.Save(ctx, session.data, expiresAt)
+	if err != nil {
+		return err
+	}
+
+	maxAge := 0
+	if !expiresAt.IsZero() {
+		maxAge = max(int(math.Ceil(time.Until(expiresAt).Seconds())), 1)
+	}
+
+	c.SetCookie(sessionCookie(cfg, token, maxAge))
+
+	session.dirty = false
+	session.isNew = false
+
+	return nil
+}
+
+// sessionCookie returns the session cookie with the given value and max age.
+func sessionCookie(cfg SessionConfig, value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     cfg.CookieName,
+		Value:    value,
+		Path:     cfg.CookiePath,
+		Domain:   cfg.CookieDomain,
+		MaxAge:   maxAge,
+		SameSite: cfg.CookieSameSite,
+	}
+}
+
+// sessionExpiry returns when the session expires, it's zero if the session never expires.
+func sessionExpiry(data SessionData, cfg SessionConfig) time.Time {
+	var expiresAt time.Time
+
+	if cfg.AbsoluteTimeout > 0 {
+		expiresAt = data.CreatedAt.Add(cfg.AbsoluteTimeout)
+	}
+
+	if cfg.IdleTimeout > 0 {
+		idleExpiresAt := data.LastSeenAt.Add(cfg.IdleTimeout)
+		if expiresAt.IsZero() || idleExpiresAt.Before(expiresAt) {
+			expiresAt = idleExpiresAt
+		}
+	}
+
+	return expiresAt
+}
+
+// isSessionExpired checks if the session is expired.
+func isSessionExpired(data SessionData, cfg SessionConfig, now time.Time) bool {
+	expiresAt := sessionExpiry(data, cfg)
+	return !expiresAt.IsZero() && !now.Before(expiresAt)
+}
+
+// setSessionDefaults sets the default values of the Session config.
+func setSessionDefaults(cfg *SessionConfig) {
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryStore(DefaultMemoryStoreSize)
+	}
+
+	if cfg.ContextKey == "" {
+		cfg.ContextKey = DefaultSessionConfig.ContextKey
+	}
+
+	if cfg.CookieName == "" {
+		cfg.CookieName = DefaultSessionConfig.CookieName
+	}
+
+	if cfg.CookiePath == "" {
+		cfg.CookiePath = DefaultSessionConfig.CookiePath
+	}
+
+	if cfg.OnError == nil {
+		cfg.OnError = func(c *kid.Context, err error) {
+			c.Error(err)
+		}
+	}
+}
+
+// newSession returns a new session with a random ID.
+func newSession(now time.Time) *Session {
+	return &Session{
+		data: SessionData{
+			ID:         newSessionID(),
+			Values:     make(map[string]any),
+			CreatedAt:  now,
+			LastSeenAt: now,
+		},
+		isNew: true,
+	}
+}
+
+// newSessionID returns a random session ID.
+func newSessionID() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Save saves the session and sets the session cookie right away, so the errors of the store can be handled
+// before the response is written. Later changes are saved too.
+//
+// Panics if the session is not loaded by the Session middleware.
+func (s *Session) Save() error {
+	if s.save == nil {
+		panic("session is not loaded by the session middleware")
+	}
+
+	return s.save()
+}
+
+// ID returns the session ID.
+func (s *Session) ID() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.data.ID
+}
+
+// IsNew returns true if the session is created in the current request.
+func (s *Session) IsNew() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.isNew
+}
+
+// CreatedAt returns when the session was created.
+func (s *Session) CreatedAt() time.Time {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.data.CreatedAt
+}
+
+// Get returns the value of the key.
+//
+// Values which are decoded from JSON, e.g. in the cookie and file stores, have JSON types.
+// Use the typed accessors to get them regardless of the store.
+func (s *Session) Get(key string) (any, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	value, ok := s.data.Values[key]
+	return value, ok
+}
+
+// Set sets the value of the key.
+//
+// Values must be encodable as JSON if the store encodes sessions, e.g. the cookie and file stores.
+func (s *Session) Set(key string, value any) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.data.Values[key] = value
+	s.dirty = true
+}
+
+// Delete deletes the key.
+func (s *Session) Delete(key string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.data.Values[key]; ok {
+		delete(s.data.Values, key)
+		s.dirty = true
+	}
+}
+
+// Clear deletes all of the keys.
+func (s *Session) Clear() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.data.Values = make(map[string]any)
+	s.dirty = true
+}
+
+// GetString returns the value of the key as a string, or an empty string if it's not a string.
+func (s *Session) GetString(key string) string {
+	value, _ := s.Get(key)
+	str, _ := value.(string)
+	return str
+}
+
+// GetBool returns the value of the key as a bool, or false if it's not a bool.
+func (s *Session) GetBool(key string) bool {
+	value, _ := s.Get(key)
+	b, _ := value.(bool)
+	return b
+}
+
+// GetInt returns the value of the key as an int, or 0 if it's not an integer.
+func (s *Session) GetInt(key string) int {
+	value, _ := s.Get(key)
+
+	switch n := value.(type) {
+	case int:
+		return n
+	case int64:
+		return int(n)
+	case float64:
+		if n == math.Trunc(n) {
+			return int(n)
+		}
+	case json.Number:
+		i, _ := n.Int64()
+		return int(i)
+	}
+
+	return 0
+}
+
+// GetFloat64 returns the value of the key as a float64, or 0 if it's not a number.
+func (s *Session) GetFloat64(key string) float64 {
+	value, _ := s.Get(key)
+
+	switch n := value.(type) {
+	case float64:
+		return n
+	case float32:
+		return float64(n)
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case json.Number:
+		f, _ := n.Float64()
+		return f
+	}
+
+	return 0
+}
+
+// AddFlash adds a flash message, which is kept until it's read with Session.Flashes.
+func (s *Session) AddFlash(message string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// A new slice is stored so the slices of the previous requests are not changed.
+	flashes := append(flashMessages(s.data.Values[flashesKey]), message)
+
+	s.data.Values[flashesKey] = flashes
+	s.dirty = true
+}
+
+// Flashes returns the flash messages and removes them from the session.
+func (s *Session) Flashes() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	value, ok := s.data.Values[flashesKey]
+	if !ok {
+		return nil
+	}
+
+	delete(s.data.Values, flashesKey)
+	s.dirty = true
+
+	return flashMessages(value)
+}
+
+// Regenerate gives the session a new ID and keeps its values, the previous ID is deleted from the store.
+//
+// It should be called when the privilege level changes, e.g. on login, to prevent session fixation.
+// It gives no protection against session fixation with CookieStore, since the session is stored in the cookie
+// and CookieStore.Delete is a no-op, so the previous cookie stays valid until it expires.
+func (s *Session) Regenerate() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.isNew {
+		s.staleIDs = append(s.staleIDs, s.data.ID)
+	}
+
+	s.data.ID = newSessionID()
+	s.dirty = true
+	s.destroyed = false
+}
+
+// Destroy deletes the session from the store and the client, e.g. on logout.
+func (s *Session) Destroy() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.isNew {
+		s.staleIDs = append(s.staleIDs, s.data.ID)
+	}
+
+	s.data.Values = make(map[string]any)
+	s.destroyed = true
+	s.dirty = false
+}
+
+// flashMessages converts the stored flash messages to a new slice of strings.
+func flashMessages(value any) []string {
+	switch flashes := value.(type) {
+	case []string:
+		return append([]string(nil), flashes...)
+	case []any:
+		messages := make([]string, 0, len(flashes))
+		for _, flash := range flashes {
+			if message, ok := flash.(string); ok {
+				messages = append(messages, message)
+			}
+		}
+		return messages
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,366 @@
+package middlewares
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mojixcoder/kid"
+)
+
+// DefaultMemoryStoreSize is the default maximum number of sessions in a memory store.
+const DefaultMemoryStoreSize = 10000
+
+// maxCookieSize is the maximum size of a cookie value which browsers accept.
+const maxCookieSize = 4096
+
+// sessionFileExt is the extension of session files.
+const sessionFileExt = ".json"
+
+var (
+	// ErrSessionNotFound is returned by stores when a session doesn't exist, is expired or is invalid.
+	ErrSessionNotFound = errors.New("session not found")
+
+	// ErrSessionTooLarge is returned by the cookie store when a session doesn't fit in a cookie.
+	ErrSessionTooLarge = errors.New("session is too large to be stored in a cookie")
+)
+
+type (
+	// SessionStore is the interface for storing sessions.
+	//
+	// It can be implemented to store sessions in databases, e.g. Redis.
+	SessionStore interface {
+		// Load returns the session of the token, which is the value of the session cookie.
+		//
+		// Should return ErrSessionNotFound if the session doesn't exist, is expired or the token is invalid.
+		Load(ctx context.Context, token string) (SessionData, error)
+
+		// Save saves the session until it expires and returns the token which is stored in the session cookie.
+		// expiresAt is zero if the session never expires.
+		Save(ctx context.Context, data SessionData, expiresAt time.Time) (string, error)
+
+		// Delete deletes the session with the given ID. Deleting a session which doesn't exist is not an error.
+		Delete(ctx context.Context, id string) error
+	}
+
+	// MemoryStore stores sessions in memory.
+	//
+	// The least recently used sessions are evicted when the store is full.
+	// Sessions are lost when the process restarts and are not shared between processes.
+	MemoryStore struct {
+		mutex    sync.Mutex
+		size     int
+		sessions map[string]*list.Element
+		lru      *list.List
+	}
+
+	// memoryEntry is an entry of the memory store.
+	memoryEntry struct {
+		data      SessionData
+		expiresAt time.Time
+	}
+
+	// CookieStore stores sessions in the session cookie, signed with a key ring.
+	//
+	// Sessions are readable by the client but can't be changed. They can't be deleted on the server either,
+	// so destroyed sessions are only removed from the client. Sessions must fit in a cookie, which is about 4 KB.
+	CookieStore struct {
+		ring *kid.KeyRing
+	}
+
+	// FileStore stores sessions as JSON files in a directory.
+	FileStore struct {
+		dir string
+	}
+
+	// encodedSession is the JSON encoded session of the cookie and file stores.
+	encodedSession struct {
+		Data      SessionData `json:"data"`
+		ExpiresAt time.Time   `json:"expires_at"`
+	}
+)
+
+// Verifying interface compliance.
+var (
+	_ SessionStore = (*MemoryStore)(nil)
+	_ SessionStore = (*CookieStore)(nil)
+	_ SessionStore = (*FileStore)(nil)
+)
+
+// NewMemoryStore returns a new memory store which holds at most size sessions.
+//
+// Panics if size is not positive.
+func NewMemoryStore(size int) *MemoryStore {
+	if size <= 0 {
+		panic("memory store size must be positive")
+	}
+
+	return &MemoryStore{size: size, sessions: make(map[string]*list.Element), lru: list.New()}
+}
+
+// Load implements the SessionStore interface.
+func (s *MemoryStore) Load(_ context.Context, token string) (SessionData, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	element, ok := s.sessions[token]
+	if !ok {
+		return SessionData{}, ErrSessionNotFound
+	}
+
+	entry := element.Value.(*memoryEntry)
+	if isExpired(entry.expiresAt) {
+		s.remove(element)
+		return SessionData{}, ErrSessionNotFound
+	}
+
+	s.lru.MoveToFront(element)
+
+	return copySessionData(entry.data), nil
+}
+
+// Save implements the SessionStore interface.
+func (s *MemoryStore) Save(_ context.Context, data SessionData, expiresAt time.Time) (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entry := &memoryEntry{data: copySessionData(data), expiresAt: expiresAt}
+
+	if element, ok := s.sessions[data.ID]; ok {
+		element.Value = entry
+		s.lru.MoveToFront(element)
+		return data.ID, nil
+	}
+
+	s.sessions[data.ID] = s.lru.PushFront(entry)
+
+	for s.lru.Len() > s.size {
+		s.remove(s.lru.Back())
+	}
+
+	return data.ID, nil
+}
+
+// Delete implements the SessionStore interface.
+func (s *MemoryStore) Delete(_ context.Context, id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if element, ok := s.sessions[id]; ok {
+		s.remove(element)
+	}
+
+	return nil
+}
+
+// Len returns the number of sessions in the store, including the expired ones which are not evicted yet.
+func (s *MemoryStore) Len() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.lru.Len()
+}
+
+// remove removes the element from the store.
+func (s *MemoryStore) remove(element *list.Element) {
+	s.lru.Remove(element)
+	delete(s.sessions, element.Value.(*memoryEntry).data.ID)
+}
+
+// NewCookieStore returns a new cookie store which signs sessions with the given key ring.
+//
+// Panics if the key ring is nil.
+func NewCookieStore(ring *kid.KeyRing) *CookieStore {
+	if ring == nil {
+		panic("key ring cannot be nil")
+	}
+
+	return &CookieStore{ring: ring}
+}
+
+// Load implements the SessionStore interface.
+func (s *CookieStore) Load(_ context.Context, token string) (SessionData, error) {
+	value, err := s.ring.Verify("session", token)
+	if err != nil {
+		return SessionData{}, ErrSessionNotFound
+	}
+
+	var entry encodedSession
+	if err := json.Unmarshal(value, &entry); err != nil || isExpired(entry.ExpiresAt) {
+		return SessionData{}, ErrSessionNotFound
+	}
+
+	return entry.Data, nil
+}
+
+// Save implements the SessionStore interface.
+func (s *CookieStore) Save(_ context.Context, data SessionData, expiresAt time.Time) (string, error) {
+	value, err := json.Marshal(encodedSession{Data: data, ExpiresAt: expiresAt})
+	if err != nil {
+		return "", err
+	}
+
+	token := s.ring.Sign("session", value)
+	if len(token) > maxCookieSize {
+		return "", ErrSessionTooLarge
+	}
+
+	return token, nil
+}
+
+// Delete implements the SessionStore interface.
+//
+// It does nothing, since sessions are only stored in the client.
+func (s *CookieStore) Delete(_ context.Context, _ string) error {
+	return nil
+}
+
+// NewFileStore returns a new file store which stores sessions in the given directory.
+// The directory is created if it doesn't exist.
+//
+// Panics if the directory can't be created.
+func NewFileStore(dir string) *FileStore {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		panic(err)
+	}
+
+	return &FileStore{dir: dir}
+}
+
+// Load implements the SessionStore interface.
+func (s *FileStore) Load(_ context.Context, token string) (SessionData, error) {
+	path, ok := s.path(token)
+	if !ok {
+		return SessionData{}, ErrSessionNotFound
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return SessionData{}, ErrSessionNotFound
+		}
+		return SessionData{}, err
+	}
+
+	var entry encodedSession
+	if err := json.Unmarshal(content, &entry); err != nil {
+		return SessionData{}, err
+	}
+
+	if isExpired(entry.ExpiresAt) {
+		_ = os.Remove(path)
+		return SessionData{}, ErrSessionNotFound
+	}
+
+	return entry.Data, nil
+}
+
+// Save implements the SessionStore interface.
+//
+// The session is written to a temporary file first and then renamed, so sessions are never partially written.
+func (s *FileStore) Save(_ context.Context, data SessionData, expiresAt time.Time) (string, error) {
+	path, ok := s.path(data.ID)
+	if !ok {
+		return "", errors.New("invalid session id")
+	}
+
+	content, err := json.Marshal(encodedSession{Data: data, ExpiresAt: expiresAt})
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.CreateTemp(s.dir, ".session-*")
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := file.Write(content); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return "", err
+	}
+
+	if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+
+	if err := os.Rename(file.Name(), path); err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+
+	return data.ID, nil
+}
+
+// Delete implements the SessionStore interface.
+func (s *FileStore) Delete(_ context.Context, id string) error {
+	path, ok := s.path(id)
+	if !ok {
+		return nil
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteExpired deletes the files of the expired sessions.
+//
+// Expired sessions are also deleted when they are loaded, it can be called periodically to delete the abandoned ones.
+func (s *FileStore) DeleteExpired() error {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*"+sessionFileExt))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		var entry encodedSession
+		if err := json.Unmarshal(content, &entry); err != nil || isExpired(entry.ExpiresAt) {
+			if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// path returns the file path of the session, it reports false if the ID is not a valid session ID.
+//
+// IDs are validated so they can't be used to access files outside of the store's directory.
+func (s *FileStore) path(id string) (string, bool) {
+	if id == "" || strings.Trim(id, base64URLAlphabet) != "" {
+		return "", false
+	}
+
+	return filepath.Join(s.dir, id+sessionFileExt), true
+}
+
+// base64URLAlphabet is the alphabet of the session IDs, which are base64 URL encoded.
+const base64URLAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
+
+// isExpired checks if the expiry time has passed, zero means it never expires.
+func isExpired(expiresAt time.Time) bool {
+	return !expiresAt.IsZero() && !time.Now().Before(expiresAt)
+}
+
+// copySessionData copies the session data, so the stored values are not shared with the requests.
+func copySessionData(data SessionData) SessionData {
+	data.Values = maps.Clone(data.Values)
+	return data
+}
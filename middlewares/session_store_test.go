@@ -0,0 +1,177 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mojixcoder/kid"
+	"github.com/stretchr/testify/assert"
+)
+
+func newSessionData(id string) SessionData {
+	now := time.Now().Truncate(time.Second)
+	return SessionData{ID: id, Values: map[string]any{"user": "john"}, CreatedAt: now, LastSeenAt: now}
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(2)
+
+	data := newSessionData("a")
+
+	token, err := store.Save(ctx, data, time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t, "a", token)
+
+	loaded, err := store.Load(ctx, token)
+	assert.NoError(t, err)
+	assert.Equal(t, data, loaded)
+
+	// Stored values are not shared.
+	data.Values["user"] = "jane"
+	loaded.Values["user"] = "jane"
+
+	loaded, err = store.Load(ctx, token)
+	assert.NoError(t, err)
+	assert.Equal(t, "john", loaded.Values["user"])
+
+	// b is the least recently used session after loading a.
+	_, err = store.Save(ctx, newSessionData("b"), time.Time{})
+	assert.NoError(t, err)
+
+	_, err = store.Load(ctx, "a")
+	assert.NoError(t, err)
+
+	_, err = store.Save(ctx, newSessionData("c"), time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, store.Len())
+
+	_, err = store.Load(ctx, "b")
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+
+	_, err = store.Save(ctx, newSessionData("c"), time.Now().Add(-time.Second))
+	assert.NoError(t, err)
+
+	_, err = store.Load(ctx, "c")
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+	assert.Equal(t, 1, store.Len())
+
+	assert.NoError(t, store.Delete(ctx, "a"))
+	assert.NoError(t, store.Delete(ctx, "a"))
+	assert.Zero(t, store.Len())
+
+	assert.PanicsWithValue(t, "memory store size must be positive", func() {
+		NewMemoryStore(0)
+	})
+}
+
+func TestCookieStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewCookieStore(kid.NewKeyRing(bytes.Repeat([]byte("k"), 32)))
+
+	data := newSessionData("a")
+
+	token, err := store.Save(ctx, data, time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+
+	loaded, err := store.Load(ctx, token)
+	assert.NoError(t, err)
+	assert.Equal(t, data.ID, loaded.ID)
+	assert.Equal(t, data.Values, loaded.Values)
+	assert.True(t, data.CreatedAt.Equal(loaded.CreatedAt))
+
+	for _, token := range []string{"", "invalid", token[:len(token)-2] + "AA"} {
+		_, err = store.Load(ctx, token)
+		assert.ErrorIs(t, err, ErrSessionNotFound)
+	}
+
+	// Tokens of other key rings are invalid.
+	_, err = NewCookieStore(kid.NewKeyRing(bytes.Repeat([]byte("o"), 32))).Load(ctx, token)
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+
+	token, err = store.Save(ctx, data, time.Now().Add(-time.Second))
+	assert.NoError(t, err)
+
+	_, err = store.Load(ctx, token)
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+
+	data.Values["user"] = strings.Repeat("a", maxCookieSize)
+	_, err = store.Save(ctx, data, time.Time{})
+	assert.ErrorIs(t, err, ErrSessionTooLarge)
+
+	assert.NoError(t, store.Delete(ctx, "a"))
+
+	assert.PanicsWithValue(t, "key ring cannot be nil", func() {
+		NewCookieStore(nil)
+	})
+}
+
+func TestFileStore(t *testing.T) {
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "sessions")
+	store := NewFileStore(dir)
+
+	data := newSessionData(newSessionID())
+
+	token, err := store.Save(ctx, data, time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, data.ID, token)
+	assert.FileExists(t, filepath.Join(dir, data.ID+sessionFileExt))
+
+	loaded, err := store.Load(ctx, token)
+	assert.NoError(t, err)
+	assert.Equal(t, data.ID, loaded.ID)
+	assert.Equal(t, data.Values, loaded.Values)
+
+	// IDs can't be used to access files outside of the directory.
+	for _, id := range []string{"", "../sessions/" + data.ID, "a/b", "a.b"} {
+		_, err = store.Load(ctx, id)
+		assert.ErrorIs(t, err, ErrSessionNotFound)
+
+		_, err = store.Save(ctx, newSessionData(id), time.Time{})
+		assert.Error(t, err)
+
+		assert.NoError(t, store.Delete(ctx, id))
+	}
+
+	_, err = store.Load(ctx, "missing")
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "corrupted"+sessionFileExt), []byte("{"), 0o600))
+	_, err = store.Load(ctx, "corrupted")
+	assert.Error(t, err)
+
+	expired := newSessionData("expired")
+	_, err = store.Save(ctx, expired, time.Now().Add(-time.Second))
+	assert.NoError(t, err)
+
+	_, err = store.Load(ctx, "expired")
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+	assert.NoFileExists(t, filepath.Join(dir, "expired"+sessionFileExt))
+
+	_, err = store.Save(ctx, expired, time.Now().Add(-time.Second))
+	assert.NoError(t, err)
+
+	assert.NoError(t, store.DeleteExpired())
+	assert.NoFileExists(t, filepath.Join(dir, "expired"+sessionFileExt))
+	assert.NoFileExists(t, filepath.Join(dir, "corrupted"+sessionFileExt))
+	assert.FileExists(t, filepath.Join(dir, data.ID+sessionFileExt))
+
+	assert.NoError(t, store.Delete(ctx, data.ID))
+	assert.NoError(t, store.Delete(ctx, data.ID))
+
+	_, err = store.Load(ctx, data.ID)
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+
+	file := filepath.Join(t.TempDir(), "file")
+	assert.NoError(t, os.WriteFile(file, nil, 0o600))
+
+	assert.Panics(t, func() {
+		NewFileStore(filepath.Join(file, "sessions"))
+	})
+}
@@ -0,0 +1,428 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mojixcoder/kid"
+	"github.com/stretchr/testify/assert"
+)
+
+// failingStore is a session store whose operations fail.
+type failingStore struct {
+	*MemoryStore
+	loadErr, saveErr error
+}
+
+func (s *failingStore) Load(ctx context.Context, token string) (SessionData, error) {
+	if s.loadErr != nil {
+		return SessionData{}, s.loadErr
+	}
+	return s.MemoryStore.Load(ctx, token)
+}
+
+func (s *failingStore) Save(ctx context.Context, data SessionData, expiresAt time.Time) (string, error) {
+	if s.saveErr != nil {
+		return "", s.saveErr
+	}
+	return s.MemoryStore.Save(ctx, data, expiresAt)
+}
+
+// sessionClient sends requests to the app and keeps the session cookie like a browser.
+type sessionClient struct {
+	k      *kid.Kid
+	cookie *http.Cookie
+}
+
+func (sc *sessionClient) do(path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if sc.cookie != nil {
+		req.AddCookie(sc.cookie)
+	}
+
+	res := httptest.NewRecorder()
+	sc.k.ServeHTTP(res, req)
+
+	for _, cookie := range res.Result().Cookies() {
+		if cookie.Name != "session" {
+			continue
+		}
+
+		if cookie.MaxAge < 0 {
+			sc.cookie = nil
+		} else {
+			sc.cookie = cookie
+		}
+	}
+
+	return res
+}
+
+func newSessionApp(cfg SessionConfig) *kid.Kid {
+	k := kid.New()
+	k.Use(NewSessionWithConfig(cfg))
+
+	k.Get("/set", func(c *kid.Context) {
+		session := GetSession(c)
+		session.Set("user", "john")
+		session.Set("visits", 2)
+		session.Set("admin", true)
+		session.Set("score", 9.5)
+		c.NoContent(http.StatusNoContent)
+	})
+
+	k.Get("/get", func(c *kid.Context) {
+		session := GetSession(c)
+		c.JSON(http.StatusOK, kid.Map{
+			"user":   session.GetString("user"),
+			"visits": session.GetInt("visits"),
+			"admin":  session.GetBool("admin"),
+			"score":  session.GetFloat64("score"),
+			"new":    session.IsNew(),
+		})
+	})
+
+	k.Get("/flash", func(c *kid.Context) {
+		GetSession(c).AddFlash("saved")
+		GetSession(c).AddFlash("again")
+		c.NoContent(http.StatusNoContent)
+	})
+
+	k.Get("/flashes", func(c *kid.Context) {
+		c.JSON(http.StatusOK, GetSession(c).Flashes())
+	})
+
+	k.Get("/login", func(c *kid.Context) {
+		GetSession(c).Regenerate()
+		c.String(http.StatusOK, GetSession(c).ID())
+	})
+
+	k.Get("/logout", func(c *kid.Context) {
+		GetSession(c).Destroy()
+		c.NoContent(http.StatusNoContent)
+	})
+
+	k.Get("/noop", func(c *kid.Context) {
+		c.String(http.StatusOK, GetSession(c).ID())
+	})
+
+	return k
+}
+
+func TestNewSession(t *testing.T) {
+	stores := map[string]SessionStore{
+		"memory": NewMemoryStore(10),
+		"cookie": NewCookieStore(kid.NewKeyRing(bytes.Repeat([]byte("k"), 32))),
+		"file":   NewFileStore(t.TempDir()),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			client := sessionClient{k: newSessionApp(SessionConfig{Store: store, IdleTimeout: time.Hour, AbsoluteTimeout: 24 * time.Hour})}
+
+			// Sessions which are not changed are not saved.
+			res := client.do("/noop")
+			assert.Empty(t, res.Header().Values("Set-Cookie"))
+
+			res = client.do("/set")
+			assert.Equal(t, http.StatusNoContent, res.Code)
+			assert.NotNil(t, client.cookie)
+			assert.True(t, client.cookie.HttpOnly)
+			assert.Equal(t, http.SameSiteLaxMode, client.cookie.SameSite)
+			assert.InDelta(t, time.Hour.Seconds(), client.cookie.MaxAge, 1)
+
+			res = client.do("/get")
+			assert.JSONEq(t, `{"user": "john", "visits": 2, "admin": true, "score": 9.5, "new": false}`, res.Body.String())
+
+			client.do("/flash")
+
+			res = client.do("/flashes")
+			assert.JSONEq(t, `["saved", "again"]`, res.Body.String())
+
+			res = client.do("/flashes")
+			assert.JSONEq(t, `null`, res.Body.String())
+
+			oldID := client.do("/noop").Body.String()
+			newID := client.do("/login").Body.String()
+			assert.NotEqual(t, oldID, newID)
+			assert.Equal(t, newID, client.do("/noop").Body.String())
+
+			// Values are kept after regeneration.
+			res = client.do("/get")
+			assert.JSONEq(t, `{"user": "john", "visits": 2, "admin": true, "score": 9.5, "new": false}`, res.Body.String())
+
+			// The previous ID is deleted from the store.
+			if name != "cookie" {
+				_, err := store.Load(context.Background(), oldID)
+				assert.ErrorIs(t, err, ErrSessionNotFound)
+			}
+
+			client.do("/logout")
+			assert.Nil(t, client.cookie)
+
+			if name != "cookie" {
+				_, err := store.Load(context.Background(), newID)
+				assert.ErrorIs(t, err, ErrSessionNotFound)
+			}
+
+			res = client.do("/get")
+			assert.JSONEq(t, `{"user": "", "visits": 0, "admin": false, "score": 0, "new": true}`, res.Body.String())
+		})
+	}
+}
+
+func TestNewSession_Expiry(t *testing.T) {
+	testCases := []struct {
+		name       string
+		createdAt  time.Duration
+		lastSeenAt time.Duration
+		expired    bool
+	}{
+		{name: "active", createdAt: -time.Hour, lastSeenAt: -time.Minute},
+		{name: "idle", createdAt: -time.Hour, lastSeenAt: -31 * time.Minute, expired: true},
+		{name: "absolute", createdAt: -25 * time.Hour, lastSeenAt: -time.Minute, expired: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := NewMemoryStore(10)
+			now := time.Now()
+
+			_, err := store.Save(context.Background(), SessionData{
+				ID:         "id",
+				Values:     map[string]any{"user": "john"},
+				CreatedAt:  now.Add(tc.createdAt),
+				LastSeenAt: now.Add(tc.lastSeenAt),
+			}, time.Time{})
+			assert.NoError(t, err)
+
+			client := sessionClient{k: newSessionApp(SessionConfig{Store: store, IdleTimeout: 30 * time.Minute, AbsoluteTimeout: 24 * time.Hour})}
+			client.cookie = &http.Cookie{Name: "session", Value: "id"}
+
+			res := client.do("/get")
+
+			if tc.expired {
+				assert.JSONEq(t, `{"user": "", "visits": 0, "admin": false, "score": 0, "new": true}`, res.Body.String())
+				assert.Zero(t, store.Len())
+				return
+			}
+
+			assert.JSONEq(t, `{"user": "john", "visits": 0, "admin": false, "score": 0, "new": false}`, res.Body.String())
+
+			// Active sessions are touched to extend their idle timeout.
+			data, err := store.Load(context.Background(), "id")
+			assert.NoError(t, err)
+			assert.WithinDuration(t, now, data.LastSeenAt, time.Second)
+			assert.InDelta(t, (30 * time.Minute).Seconds(), client.cookie.MaxAge, 1)
+		})
+	}
+}
+
+func TestSessionExpiry(t *testing.T) {
+	now := time.Now()
+	data := SessionData{CreatedAt: now, LastSeenAt: now.Add(time.Hour)}
+
+	assert.True(t, sessionExpiry(data, SessionConfig{}).IsZero())
+	assert.Equal(t, now.Add(2*time.Hour), sessionExpiry(data, SessionConfig{AbsoluteTimeout: 2 * time.Hour}))
+	assert.Equal(t, now.Add(90*time.Minute), sessionExpiry(data, SessionConfig{IdleTimeout: 30 * time.Minute}))
+	assert.Equal(t, now.Add(90*time.Minute), sessionExpiry(data, SessionConfig{IdleTimeout: 30 * time.Minute, AbsoluteTimeout: 2 * time.Hour}))
+	assert.Equal(t, now.Add(time.Hour), sessionExpiry(data, SessionConfig{IdleTimeout: 30 * time.Minute, AbsoluteTimeout: time.Hour}))
+}
+
+func TestNewSession_StoreErrors(t *testing.T) {
+	loadErr := errors.New("load failed")
+
+	store := &failingStore{MemoryStore: NewMemoryStore(10), loadErr: loadErr}
+	client := sessionClient{k: newSessionApp(SessionConfig{Store: store}), cookie: &http.Cookie{Name: "session", Value: "id"}}
+
+	res := client.do("/get")
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+
+	saveErr := errors.New("save failed")
+	store = &failingStore{MemoryStore: NewMemoryStore(10), saveErr: saveErr}
+
+	k := kid.New()
+	k.Use(func(next kid.HandlerFunc) kid.HandlerFunc {
+		return func(c *kid.Context) {
+			next(c)
+			assert.ErrorIs(t, c.Err(), saveErr)
+		}
+	})
+	k.Use(NewSessionWithConfig(SessionConfig{Store: store}))
+	k.Get("/", func(c *kid.Context) {
+		GetSession(c).Set("user", "john")
+		c.String(http.StatusOK, "ok")
+	})
+
+	res = httptest.NewRecorder()
+	k.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Empty(t, res.Header().Values("Set-Cookie"))
+}
+
+func TestNewSession_SaveErrors(t *testing.T) {
+	saveErr := errors.New("save failed")
+	store := &failingStore{MemoryStore: NewMemoryStore(10), saveErr: saveErr}
+
+	var onErrorErr error
+
+	k := kid.New()
+	k.Use(NewSessionWithConfig(SessionConfig{
+		Store: store,
+		OnError: func(c *kid.Context, err error) {
+			onErrorErr = err
+		},
+	}))
+
+	// The response is written before the session is saved.
+	k.Get("/written", func(c *kid.Context) {
+		GetSession(c).Set("user", "john")
+		c.String(http.StatusOK, "ok")
+	})
+
+	// The response is not written when the handler returns.
+	k.Get("/not_written", func(c *kid.Context) {
+		GetSession(c).Set("user", "john")
+	})
+
+	// The session is saved explicitly before the response is written.
+	k.Get("/saved", func(c *kid.Context) {
+		session := GetSession(c)
+		session.Set("user", "john")
+
+		if err := session.Save(); err != nil {
+			c.String(http.StatusServiceUnavailable, err.Error())
+			return
+		}
+
+		c.String(http.StatusOK, "ok")
+	})
+
+	res := httptest.NewRecorder()
+	k.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/written", nil))
+
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.ErrorIs(t, onErrorErr, saveErr)
+	assert.Empty(t, res.Header().Values("Set-Cookie"))
+
+	onErrorErr = nil
+
+	res = httptest.NewRecorder()
+	k.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/not_written", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+	assert.Equal(t, "{\"message\":\"Internal Server Error\"}\n", res.Body.String())
+	assert.Empty(t, res.Header().Values("Set-Cookie"))
+
+	res = httptest.NewRecorder()
+	k.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/saved", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, res.Code)
+	assert.Equal(t, "save failed", res.Body.String())
+
+	// The session is still changed, so saving it is retried before the response headers are written.
+	assert.ErrorIs(t, onErrorErr, saveErr)
+
+	// Sessions are saved once, even if they are saved explicitly.
+	store.saveErr = nil
+
+	res = httptest.NewRecorder()
+	k.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/saved", nil))
+
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Len(t, res.Header().Values("Set-Cookie"), 1)
+
+	assert.PanicsWithValue(t, "session is not loaded by the session middleware", func() {
+		(&Session{}).Save()
+	})
+}
+
+func TestNewSession_Defaults(t *testing.T) {
+	cfg := SessionConfig{}
+	setSessionDefaults(&cfg)
+
+	assert.IsType(t, &MemoryStore{}, cfg.Store)
+	assert.Equal(t, "session", cfg.ContextKey)
+	assert.Equal(t, "session", cfg.CookieName)
+	assert.Equal(t, "/", cfg.CookiePath)
+
+	k := kid.New()
+	k.Use(NewSession())
+	k.Get("/", func(c *kid.Context) {
+		GetSession(c).Set("user", "john")
+		c.NoContent(http.StatusNoContent)
+	})
+
+	res := httptest.NewRecorder()
+	k.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	cookies := res.Result().Cookies()
+	assert.Len(t, cookies, 1)
+	assert.Equal(t, "session", cookies[0].Name)
+	assert.InDelta(t, (30 * time.Minute).Seconds(), cookies[0].MaxAge, 1)
+}
+
+func TestGetSession(t *testing.T) {
+	c := kid.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+
+	assert.PanicsWithValue(t, "session middleware is not applied", func() {
+		GetSession(c)
+	})
+
+	session := newSession(time.Now())
+	c.Set("custom", session)
+
+	assert.Same(t, session, GetSessionWithKey(c, "custom"))
+}
+
+func TestSession(t *testing.T) {
+	session := newSession(time.Now())
+
+	assert.True(t, session.IsNew())
+	assert.NotEmpty(t, session.ID())
+	assert.WithinDuration(t, time.Now(), session.CreatedAt(), time.Second)
+
+	session.Set("key", "value")
+
+	value, ok := session.Get("key")
+	assert.True(t, ok)
+	assert.Equal(t, "value", value)
+
+	session.Delete("key")
+	_, ok = session.Get("key")
+	assert.False(t, ok)
+
+	session.Set("key", "value")
+	session.Clear()
+	_, ok = session.Get("key")
+	assert.False(t, ok)
+
+	// Values decoded from JSON are converted by the typed accessors.
+	session.data.Values = map[string]any{
+		"int":      float64(3),
+		"float":    2.5,
+		"int64":    int64(4),
+		"number":   json.Number("5"),
+		flashesKey: []any{"a", 1, "b"},
+	}
+
+	assert.Equal(t, 3, session.GetInt("int"))
+	assert.Equal(t, 0, session.GetInt("float"))
+	assert.Equal(t, 4, session.GetInt("int64"))
+	assert.Equal(t, 5, session.GetInt("number"))
+	assert.Equal(t, 2.5, session.GetFloat64("float"))
+	assert.Equal(t, float64(4), session.GetFloat64("int64"))
+	assert.Equal(t, float64(5), session.GetFloat64("number"))
+	assert.Equal(t, float64(0), session.GetFloat64("missing"))
+	assert.Empty(t, session.GetString("int"))
+	assert.False(t, session.GetBool("int"))
+	assert.Equal(t, []string{"a", "b"}, session.Flashes())
+	assert.Nil(t, session.Flashes())
+}
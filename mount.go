@@ -0,0 +1,108 @@
+package kid
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// mountPathParam is the name of the star path parameter which holds the path of mounted handlers.
+const mountPathParam = "mountPath"
+
+type (
+	// mountContextKey is the request context key of mountInfo.
+	mountContextKey struct{}
+
+	// mountInfo is shared between a parent app and a mounted Kid app during a request.
+	mountInfo struct {
+		// route is the route of the mounted app which served the request.
+		route string
+	}
+)
+
+// Mount mounts the given handler under the prefix. All of the HTTP methods will be passed to the handler.
+//
+// The prefix is stripped from the request path before it's passed to the handler.
+// Global middlewares are applied around the mounted handler.
+// If the handler is a Kid instance, its routes will also be reported by Kid.Routes and Context.Route.
+//
+// Specifying middlewares is optional. Middlewares will only be applied to the mounted handler.
+func (k *Kid) Mount(prefix string, handler http.Handler, middlewares ...MiddlewareFunc) {
+	k.mount(&k.router, prefix, handler, middlewares)
+}
+
+// mount mounts the handler under the prefix in the given router.
+func (k *Kid) mount(router *Tree, prefix string, handler http.Handler, middlewares []MiddlewareFunc) {
+	panicIfNil(handler, "mounted handler cannot be nil")
+
+	if sub, ok := handler.(*Kid); ok {
+		if sub == k {
+			panic("kid cannot be mounted on itself")
+		}
+		sub.mounted = true
+	}
+
+	prefix = strings.TrimSuffix(cleanPath(prefix, false), "/")
+	path := prefix + "/" + starParamPrefix + mountPathParam + paramSuffix
+
+	node := router.insertNode(path, allMethods, middlewares, newMountHandler(prefix, handler))
+
+	for method, hm := range node.handlerMap {
+		hm.mount = handler
+		node.handlerMap[method] = hm
+	}
+}
+
+// newMountHandler returns a handler which strips the prefix and passes the request to the mounted handler.
+func newMountHandler(prefix string, handler http.Handler) HandlerFunc {
+	sub, isKid := handler.(*Kid)
+
+	return func(c *Context) {
+		req := c.Request()
+		rest := "/" + c.Param(mountPathParam)
+
+		r := new(http.Request)
+		*r = *req
+
+		r.URL = new(url.URL)
+		*r.URL = *req.URL
+
+		if req.URL.RawPath != "" {
+			r.URL.RawPath = rest
+			if path, err := url.PathUnescape(rest); err == nil {
+				r.URL.Path = path
+			}
+		} else {
+			r.URL.Path = rest
+		}
+
+		if !isKid {
+			handler.ServeHTTP(c.Response(), r)
+			return
+		}
+
+		var info mountInfo
+		r = r.WithContext(context.WithValue(r.Context(), mountContextKey{}, &info))
+
+		sub.ServeHTTP(c.Response(), r)
+
+		// Special route names like "Not Found" are reported as is.
+		if strings.HasPrefix(info.route, "/") {
+			c.setRouteName(prefix + info.route)
+		} else if info.route != "" {
+			c.setRouteName(info.route)
+		}
+	}
+}
+
+// reportMountedRoute reports the route of a mounted Kid app to its parent app.
+func (k *Kid) reportMountedRoute(c *Context) {
+	if !k.mounted {
+		return
+	}
+
+	if info, ok := c.Request().Context().Value(mountContextKey{}).(*mountInfo); ok {
+		info.route = c.Route()
+	}
+}
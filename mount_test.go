@@ -0,0 +1,144 @@
+package kid
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKid_Mount_Handler(t *testing.T) {
+	k := New()
+
+	k.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			next(c)
+			c.SetResponseHeader("X-Route", c.Route())
+		}
+	})
+
+	k.Mount("/admin/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Path", r.URL.Path)
+		w.Header().Set("X-Raw-Path", r.URL.RawPath)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	testCases := []struct {
+		method, path                  string
+		expectedPath, expectedRawPath string
+	}{
+		{method: http.MethodGet, path: "/admin", expectedPath: "/"},
+		{method: http.MethodPost, path: "/admin/", expectedPath: "/"},
+		{method: http.MethodDelete, path: "/admin/users/1?x=1", expectedPath: "/users/1"},
+		{method: http.MethodPatch, path: "/admin/a%2Fb", expectedPath: "/a/b", expectedRawPath: "/a%2Fb"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			res := httptest.NewRecorder()
+
+			k.ServeHTTP(res, req)
+
+			assert.Equal(t, http.StatusAccepted, res.Code)
+			assert.Equal(t, tc.expectedPath, res.Header().Get("X-Path"))
+			assert.Equal(t, tc.expectedRawPath, res.Header().Get("X-Raw-Path"))
+			assert.Equal(t, "/admin/{*mountPath}", res.Header().Get("X-Route"))
+		})
+	}
+
+	assert.PanicsWithValue(t, "mounted handler cannot be nil", func() {
+		k.Mount("/nil", nil)
+	})
+
+	assert.PanicsWithValue(t, "kid cannot be mounted on itself", func() {
+		k.Mount("/self", k)
+	})
+}
+
+func TestKid_Mount_Kid(t *testing.T) {
+	k := New()
+
+	k.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			next(c)
+			c.SetResponseHeader("X-Route", c.Route())
+		}
+	})
+
+	sub := New()
+	sub.Get("/users/{id}", mountTestHandler, testMiddlewareFunc)
+
+	g := k.Group("/api")
+	g.Mount("/v1", sub, testMiddlewareFunc)
+
+	assert.True(t, sub.mounted)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/12", nil)
+	res := httptest.NewRecorder()
+
+	k.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "12", res.Body.String())
+	assert.Equal(t, "/api/v1/users/{id}", res.Header().Get("X-Route"))
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/unknown", nil)
+	res = httptest.NewRecorder()
+
+	k.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusNotFound, res.Code)
+	assert.Equal(t, "Not Found", res.Header().Get("X-Route"))
+
+	expected := []RouteInfo{
+		{Method: http.MethodGet, Path: "/api/v1/users/{id}", Handler: funcName(mountTestHandler), Middlewares: 2},
+	}
+
+	assert.Equal(t, expected, k.Routes())
+}
+
+func TestKid_Mount_Routes(t *testing.T) {
+	k := New()
+
+	k.Mount("/files", http.NotFoundHandler())
+
+	routes := k.Routes()
+
+	assert.Len(t, routes, len(allMethods))
+	for _, route := range routes {
+		assert.Equal(t, "/files/{*mountPath}", route.Path)
+		assert.Equal(t, "http.HandlerFunc", route.Handler)
+	}
+}
+
+func TestGroup_Mount_Host(t *testing.T) {
+	k := New()
+
+	admin := k.Host("admin.example.com")
+	admin.Mount("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/dashboard", nil)
+	req.Host = "admin.example.com"
+	res := httptest.NewRecorder()
+
+	k.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "/dashboard", res.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/dashboard", nil)
+	res = httptest.NewRecorder()
+
+	k.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusNotFound, res.Code)
+}
+
+// mountTestHandler is a named handler used for testing mounted apps.
+func mountTestHandler(c *Context) {
+	c.String(http.StatusOK, c.Param("id"))
+}
@@ -0,0 +1,369 @@
+package kid
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// defaultMultipartMemory is the maximum memory used for parsing multipart forms, the rest is stored in temporary files.
+	defaultMultipartMemory = 32 << 20
+
+	// sniffLen is the number of bytes used for detecting the content type of uploaded files.
+	sniffLen = 512
+
+	// maxMultipartParts is the maximum number of parts of multipart forms, like multipart.Reader.ReadForm.
+	maxMultipartParts = 1000
+)
+
+// ErrPartTooLarge is the cause of the errors returned when a part of a multipart form exceeds MaxPartSize.
+var ErrPartTooLarge = errors.New("multipart: part too large")
+
+// MultipartLimits are the limits of parsing multipart forms.
+//
+// Zero values mean no limit, except MaxMemory which defaults to 32 MB.
+type MultipartLimits struct {
+	// MaxBodySize is the maximum size of the request body in bytes.
+	MaxBodySize int64
+
+	// MaxFiles is the maximum number of uploaded files.
+	MaxFiles int
+
+	// MaxPartSize is the maximum size of each part of the form in bytes.
+	MaxPartSize int64
+
+	// MaxMemory is the maximum number of bytes stored in memory, the rest of the files are stored in temporary files on disk.
+	MaxMemory int64
+
+	// AllowedTypes are the allowed MIME types of the uploaded files, e.g. image/png or image/*.
+	// Types are sniffed from the content of the files, the Content-Type sent by the client is not trusted.
+	AllowedTypes []string
+}
+
+// validate panics if any of the limits is negative.
+func (l MultipartLimits) validate() {
+	if l.MaxBodySize < 0 || l.MaxFiles < 0 || l.MaxPartSize < 0 || l.MaxMemory < 0 {
+		panic("multipart limits cannot be negative")
+	}
+}
+
+// maxMemory returns the maximum memory used for parsing the form.
+func (l MultipartLimits) maxMemory() int64 {
+	if l.MaxMemory == 0 {
+		return defaultMultipartMemory
+	}
+	return l.MaxMemory
+}
+
+// allows checks if the MIME type is allowed.
+func (l MultipartLimits) allows(mediaType string) bool {
+	if len(l.AllowedTypes) == 0 {
+		return true
+	}
+
+	for _, allowed := range l.AllowedTypes {
+		allowed = strings.ToLower(allowed)
+
+		if allowed == mediaType || allowed == "*/*" {
+			return true
+		}
+
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok && strings.HasPrefix(mediaType, prefix+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// multipartLimits returns the multipart limits of the current route, or Kid's limits if the route doesn't override them.
+func (c *Context) multipartLimits() MultipartLimits {
+	if c.options != nil && c.options.multipartLimits != nil {
+		return *c.options.multipartLimits
+	}
+	return c.kid.multipartLimits
+}
+
+// MultipartForm parses the request's multipart form with the route's multipart limits and returns it.
+// The form is parsed only once, subsequent calls return the parsed form or the error of parsing it.
+//
+// The limits are enforced while the parts are read, before they are stored in memory or on disk.
+// The Content-Type header of each uploaded file is replaced by the type sniffed from its content.
+// Temporary files of the form are removed after the request is served, use Context.SaveUploadedFile to keep them.
+//
+// Returns an *HTTPError with 415 status code if the request is not multipart or a file's type is not allowed,
+// 413 if the body or a part is too large or there are too many files or parts, and 400 if the form is malformed.
+func (c *Context) MultipartForm() (*multipart.Form, error) {
+	if c.multipartErr != nil {
+		return nil, c.multipartErr
+	}
+
+	if c.request.MultipartForm != nil {
+		return c.request.MultipartForm, nil
+	}
+
+	form, err := c.parseMultipartForm()
+	if err != nil {
+		c.multipartErr = err
+
+		// The rest of the body is dropped, so it's not parsed without the limits later, e.g. by http.Request.FormValue.
+		c.request.Body = http.NoBody
+
+		return nil, err
+	}
+
+	return form, nil
+}
+
+// parseMultipartForm parses the request's multipart form and stores it in the request, like http.Request.ParseMultipartForm.
+//
+// The parts are read with a MultipartReader, which enforces the limits while they are read.
+func (c *Context) parseMultipartForm() (*multipart.Form, error) {
+	mediaType, params, err := mime.ParseMediaType(c.GetRequestHeader(contentTypeHeader))
+	if err != nil || mediaType != "multipart/form-data" {
+		return nil, multipartError(http.ErrNotMultipart)
+	}
+
+	boundary, ok := params["boundary"]
+	if !ok {
+		return nil, multipartError(http.ErrMissingBoundary)
+	}
+
+	// Query parameters are parsed, the body is not parsed since it's multipart.
+	if err := c.request.ParseForm(); err != nil {
+		return nil, NewHTTPError(http.StatusBadRequest, "").WithCause(err)
+	}
+
+	limits := c.multipartLimits()
+
+	c.limitBody(limits)
+
+	reader := &MultipartReader{reader: multipart.NewReader(c.request.Body, boundary), limits: limits, kid: c.kid}
+
+	form, err := readForm(reader, limits.maxMemory())
+	if err != nil {
+		var httpErr *HTTPError
+		if errors.As(err, &httpErr) {
+			return nil, httpErr
+		}
+		return nil, multipartError(err)
+	}
+
+	if c.request.PostForm == nil {
+		c.request.PostForm = make(url.Values)
+	}
+
+	for name, values := range form.Value {
+		c.request.Form[name] = append(c.request.Form[name], values...)
+		c.request.PostForm[name] = append(c.request.PostForm[name], values...)
+	}
+
+	c.request.MultipartForm = form
+
+	return form, nil
+}
+
+// readForm reads the parts of the reader into a form, like multipart.Reader.ReadForm.
+//
+// Values are stored in memory, and they are limited to 10 MB more than maxMemory like multipart.Reader.ReadForm.
+// Files are stored in memory until maxMemory is used, the rest of them are stored in temporary files.
+func readForm(reader *MultipartReader, maxMemory int64) (_ *multipart.Form, err error) {
+	form := &multipart.Form{Value: make(map[string][]string), File: make(map[string][]*multipart.FileHeader)}
+
+	defer func() {
+		if err != nil {
+			_ = form.RemoveAll()
+		}
+	}()
+
+	maxValueBytes := maxMemory + 10<<20
+
+	for parts := 0; ; parts++ {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return form, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if parts == maxMultipartParts {
+			return nil, multipart.ErrMessageTooLarge
+		}
+
+		name := part.FormName()
+		if name == "" {
+			continue
+		}
+
+		if part.FileName() != "" {
+			file, err := readFormFile(part, maxMemory)
+			if err != nil {
+				return nil, err
+			}
+
+			// Files which are not larger than the remaining memory are stored in memory.
+			if file.Size <= maxMemory {
+				maxMemory -= file.Size
+			}
+
+			form.File[name] = append(form.File[name], file)
+			continue
+		}
+
+		// Each value also costs its name and the overhead of storing it, like multipart.Reader.ReadForm.
+		maxValueBytes -= int64(len(name)) + 200
+
+		var b strings.Builder
+		n, err := io.CopyN(&b, part, maxValueBytes+1)
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		maxValueBytes -= n
+		if maxValueBytes < 0 {
+			return nil, multipart.ErrMessageTooLarge
+		}
+
+		form.Value[name] = append(form.Value[name], b.String())
+	}
+}
+
+// readFormFile stores the file part in memory if it's not larger than maxMemory, or in a temporary file otherwise.
+//
+// multipart.FileHeader can only be created by the multipart package, so the part is framed as a form with a single part
+// and read with multipart.Reader.ReadForm, which reads the part's data as it's streamed without buffering it.
+func readFormFile(part *Part, maxMemory int64) (*multipart.FileHeader, error) {
+	var header bytes.Buffer
+
+	writer := multipart.NewWriter(&header)
+	if _, err := writer.CreatePart(part.Header); err != nil {
+		return nil, err
+	}
+
+	body := io.MultiReader(&header, part, strings.NewReader("\r\n--"+writer.Boundary()+"--\r\n"))
+
+	form, err := multipart.NewReader(body, writer.Boundary()).ReadForm(maxMemory)
+	if err != nil {
+		return nil, err
+	}
+
+	return form.File[part.FormName()][0], nil
+}
+
+// FormValue returns the first value of the form field.
+//
+// Multipart forms are parsed with Context.MultipartForm. Query parameters are also included,
+// but body values take precedence. Returns an empty string if the field doesn't exist or the form can't be parsed.
+func (c *Context) FormValue(name string) string {
+	if c.request.Form == nil {
+		if c.isMultipart() {
+			_, _ = c.MultipartForm()
+		} else {
+			c.limitBody(c.multipartLimits())
+			_ = c.request.ParseForm()
+		}
+	}
+
+	return c.request.FormValue(name)
+}
+
+// FormFile returns the first uploaded file of the form field.
+//
+// Returns http.ErrMissingFile if the field has no files, or the error of Context.MultipartForm.
+func (c *Context) FormFile(name string) (*multipart.FileHeader, error) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		return nil, err
+	}
+
+	headers := form.File[name]
+	if len(headers) == 0 {
+		return nil, http.ErrMissingFile
+	}
+
+	return headers[0], nil
+}
+
+// SaveUploadedFile saves the uploaded file to dst. Parent directories of dst are created if they don't exist.
+func (c *Context) SaveUploadedFile(file *multipart.FileHeader, dst string) error {
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o750); err != nil {
+		return err
+	}
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, src); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
+
+// isMultipart checks if the request's body is a multipart form.
+func (c *Context) isMultipart() bool {
+	mediaType, _, err := mime.ParseMediaType(c.GetRequestHeader(contentTypeHeader))
+	return err == nil && mediaType == "multipart/form-data"
+}
+
+// limitBody limits the size of the request body, if the limits have a maximum body size.
+func (c *Context) limitBody(limits MultipartLimits) {
+	if limits.MaxBodySize > 0 && c.request.Body != nil {
+		c.request.Body = http.MaxBytesReader(c.response, c.request.Body, limits.MaxBodySize)
+	}
+}
+
+// removeMultipartFiles removes the temporary files of the request's multipart form.
+func (c *Context) removeMultipartFiles() {
+	if c.request.MultipartForm != nil {
+		_ = c.request.MultipartForm.RemoveAll()
+	}
+}
+
+// multipartError converts the error of parsing a multipart body to an HTTP error.
+func multipartError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+
+	switch {
+	case errors.Is(err, http.ErrNotMultipart):
+		return NewHTTPError(http.StatusUnsupportedMediaType, "").WithCause(err)
+	case errors.As(err, &maxBytesErr), errors.Is(err, multipart.ErrMessageTooLarge):
+		return NewHTTPError(http.StatusRequestEntityTooLarge, "").WithCause(err)
+	default:
+		return NewHTTPError(http.StatusBadRequest, "").WithCause(err)
+	}
+}
+
+// tooManyFilesError returns the error of exceeding the maximum number of files.
+func tooManyFilesError(maxFiles int) error {
+	return NewHTTPError(http.StatusRequestEntityTooLarge, fmt.Sprintf("too many files, at most %d files are allowed", maxFiles))
+}
+
+// partTooLargeError returns the error of exceeding the maximum size of a part.
+func partTooLargeError(name string) error {
+	return NewHTTPError(http.StatusRequestEntityTooLarge, fmt.Sprintf("part %s is too large", name)).WithCause(ErrPartTooLarge)
+}
+
+// typeNotAllowedError returns the error of uploading a file whose type is not allowed.
+func typeNotAllowedError(mediaType, filename string) error {
+	return NewHTTPError(http.StatusUnsupportedMediaType, fmt.Sprintf("type %s of file %s is not allowed", mediaType, filename))
+}
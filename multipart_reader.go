@@ -0,0 +1,186 @@
+package kid
+
+import (
+	"bufio"
+	"hash"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/mojixcoder/kid/serializer"
+)
+
+type (
+	// MultipartReader streams the parts of a multipart form one at a time, without buffering them in memory or on disk.
+	//
+	// It's returned by Context.MultipartReader and applies the route's multipart limits.
+	MultipartReader struct {
+		reader *multipart.Reader
+		limits MultipartLimits
+		kid    *Kid
+		files  int
+	}
+
+	// Part is a part of a streamed multipart form.
+	//
+	// Reading from a part applies its size limit and writes the read bytes to its hashes.
+	Part struct {
+		*multipart.Part
+
+		source io.Reader
+		kid    *Kid
+		limit  int64
+		read   int64
+		hashes []hash.Hash
+	}
+)
+
+// Verifying interface compliance.
+var _ io.Reader = (*Part)(nil)
+
+// MultipartReader returns a reader which streams the parts of the request's multipart form.
+// It can't be used together with Context.MultipartForm, since the request body can only be read once.
+//
+// Returns an *HTTPError with 415 status code if the request is not multipart, or 400 if the body is already read.
+func (c *Context) MultipartReader() (*MultipartReader, error) {
+	limits := c.multipartLimits()
+
+	c.limitBody(limits)
+
+	reader, err := c.request.MultipartReader()
+	if err != nil {
+		return nil, multipartError(err)
+	}
+
+	return &MultipartReader{reader: reader, limits: limits, kid: c.kid}, nil
+}
+
+// NextPart returns the next part of the form, or io.EOF if there are no more parts.
+// The unread data of the previous part is discarded.
+//
+// Files are limited to MaxFiles and their types are sniffed from their first bytes and checked against AllowedTypes.
+// The Content-Type header of file parts is replaced by the sniffed type.
+// Returns an *HTTPError for the other errors, with the same status codes as Context.MultipartForm.
+func (r *MultipartReader) NextPart() (*Part, error) {
+	part, err := r.reader.NextPart()
+	if err != nil {
+		if err == io.EOF {
+			return nil, err
+		}
+		return nil, multipartError(err)
+	}
+
+	p := &Part{Part: part, source: part, kid: r.kid, limit: r.limits.MaxPartSize}
+
+	if part.FileName() == "" {
+		return p, nil
+	}
+
+	r.files++
+	if r.limits.MaxFiles > 0 && r.files > r.limits.MaxFiles {
+		return nil, tooManyFilesError(r.limits.MaxFiles)
+	}
+
+	// The sniffed bytes are buffered, so they are still read from the part.
+	buffered := bufio.NewReaderSize(part, sniffLen)
+
+	head, err := buffered.Peek(sniffLen)
+	if err != nil && err != io.EOF {
+		return nil, multipartError(err)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	if !r.limits.allows(mediaType) {
+		return nil, typeNotAllowedError(mediaType, part.FileName())
+	}
+
+	part.Header.Set(contentTypeHeader, mediaType)
+	p.source = buffered
+
+	return p, nil
+}
+
+// Limit sets the maximum size of the part in bytes, which overrides MaxPartSize.
+// Zero means no limit. It must be called before reading the part.
+func (p *Part) Limit(n int64) *Part {
+	if n < 0 {
+		panic("part limit cannot be negative")
+	}
+
+	p.limit = n
+	return p
+}
+
+// AddHash adds hashes which are written with the part's data while it's read,
+// e.g. to compute the checksum of a file while streaming it to its destination.
+func (p *Part) AddHash(hashes ...hash.Hash) *Part {
+	for _, h := range hashes {
+		panicIfNil(h, "hash cannot be nil")
+	}
+
+	p.hashes = append(p.hashes, hashes...)
+	return p
+}
+
+// ContentType returns the Content-Type of the part. It's the sniffed type for files.
+func (p *Part) ContentType() string {
+	return p.Header.Get(contentTypeHeader)
+}
+
+// Size returns the number of bytes read from the part so far.
+func (p *Part) Size() int64 {
+	return p.read
+}
+
+// Read reads the part's data.
+//
+// Returns an *HTTPError with 413 status code, caused by ErrPartTooLarge, if the part exceeds its limit.
+func (p *Part) Read(b []byte) (int, error) {
+	// One more byte than the limit is read to detect whether the part exceeds it.
+	if p.limit > 0 && int64(len(b)) > p.limit-p.read+1 {
+		b = b[:p.limit-p.read+1]
+	}
+
+	n, err := p.source.Read(b)
+	p.read += int64(n)
+
+	if p.limit > 0 && p.read > p.limit {
+		n -= int(p.read - p.limit)
+		p.read = p.limit
+		err = partTooLargeError(p.FormName())
+	}
+
+	for _, h := range p.hashes {
+		h.Write(b[:n])
+	}
+
+	return n, err
+}
+
+// WriteTo writes the part's data to w, e.g. a file or an object storage upload, and returns the number of written bytes.
+func (p *Part) WriteTo(w io.Writer) (int64, error) {
+	// The reader is wrapped to prevent io.Copy from calling WriteTo again.
+	return io.Copy(w, struct{ io.Reader }{p})
+}
+
+// ReadJSON decodes the part's data as JSON with Kid's JSON serializer, e.g. for metadata parts.
+func (p *Part) ReadJSON(out any) error {
+	return p.Decode(p.kid.serializers[MIMEApplicationJSON], out)
+}
+
+// ReadXML decodes the part's data as XML with Kid's XML serializer.
+func (p *Part) ReadXML(out any) error {
+	return p.Decode(p.kid.serializers[MIMEApplicationXML], out)
+}
+
+// Decode decodes the part's data with the given serializer.
+//
+// The serializer reads the part as the body of a request which has the part's headers.
+func (p *Part) Decode(s serializer.Serializer, out any) error {
+	panicIfNil(s, "serializer cannot be nil")
+
+	req := &http.Request{Header: http.Header(p.Header), Body: io.NopCloser(p)}
+
+	return s.Read(req, out)
+}
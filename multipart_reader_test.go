@@ -0,0 +1,226 @@
+package kid
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type uploadMetadata struct {
+	Title string `json:"title" xml:"title"`
+}
+
+func newStreamingRequest(t *testing.T) *http.Request {
+	var body bytes.Buffer
+
+	writer := multipart.NewWriter(&body)
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", `form-data; name="metadata"`)
+	header.Set(contentTypeHeader, "application/json")
+
+	part, err := writer.CreatePart(header)
+	assert.NoError(t, err)
+	_, err = part.Write([]byte(`{"title": "Holiday"}`))
+	assert.NoError(t, err)
+
+	part, err = writer.CreateFormFile("video", "video.mp4")
+	assert.NoError(t, err)
+	_, err = part.Write([]byte(strings.Repeat("frame", 1000)))
+	assert.NoError(t, err)
+
+	assert.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	req.Header.Set(contentTypeHeader, writer.FormDataContentType())
+
+	return req
+}
+
+func TestContext_MultipartReader(t *testing.T) {
+	c := New().NewContext(newStreamingRequest(t), httptest.NewRecorder())
+
+	reader, err := c.MultipartReader()
+	assert.NoError(t, err)
+
+	part, err := reader.NextPart()
+	assert.NoError(t, err)
+	assert.Equal(t, "metadata", part.FormName())
+	assert.Equal(t, "application/json", part.ContentType())
+
+	var metadata uploadMetadata
+	assert.NoError(t, part.ReadJSON(&metadata))
+	assert.Equal(t, uploadMetadata{Title: "Holiday"}, metadata)
+
+	part, err = reader.NextPart()
+	assert.NoError(t, err)
+	assert.Equal(t, "video.mp4", part.FileName())
+	assert.Equal(t, "text/plain", part.ContentType())
+
+	h := sha256.New()
+	var dst bytes.Buffer
+
+	n, err := part.AddHash(h).WriteTo(&dst)
+	assert.NoError(t, err)
+
+	content := strings.Repeat("frame", 1000)
+	sum := sha256.Sum256([]byte(content))
+
+	assert.Equal(t, int64(len(content)), n)
+	assert.Equal(t, int64(len(content)), part.Size())
+	assert.Equal(t, content, dst.String())
+	assert.Equal(t, hex.EncodeToString(sum[:]), hex.EncodeToString(h.Sum(nil)))
+
+	_, err = reader.NextPart()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestContext_MultipartReader_Errors(t *testing.T) {
+	c := New().NewContext(httptest.NewRequest(http.MethodPost, "/", strings.NewReader("a=b")), httptest.NewRecorder())
+
+	_, err := c.MultipartReader()
+
+	var httpErr *HTTPError
+	assert.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusUnsupportedMediaType, httpErr.Status)
+
+	c = New().NewContext(newStreamingRequest(t), httptest.NewRecorder())
+
+	_, err = c.MultipartForm()
+	assert.NoError(t, err)
+
+	_, err = c.MultipartReader()
+	assert.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusBadRequest, httpErr.Status)
+}
+
+func TestMultipartReader_NextPart_Limits(t *testing.T) {
+	file := uploadedFile{field: "file", name: "file.txt", content: []byte("hello")}
+
+	testCases := []struct {
+		name           string
+		limits         MultipartLimits
+		req            *http.Request
+		expectedStatus int
+		expectedMsg    string
+	}{
+		{
+			name:           "too_many_files",
+			limits:         MultipartLimits{MaxFiles: 1},
+			req:            newMultipartRequest(t, nil, file, file),
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedMsg:    "too many files, at most 1 files are allowed",
+		},
+		{
+			name:           "type_not_allowed",
+			limits:         MultipartLimits{AllowedTypes: []string{"video/*"}},
+			req:            newStreamingRequest(t),
+			expectedStatus: http.StatusUnsupportedMediaType,
+			expectedMsg:    "type text/plain of file video.mp4 is not allowed",
+		},
+		{
+			name:           "body_too_large",
+			limits:         MultipartLimits{MaxBodySize: 256},
+			req:            newStreamingRequest(t),
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedMsg:    "Request Entity Too Large",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			k := New()
+			k.ApplyOptions(WithMultipartLimits(tc.limits))
+
+			reader, err := k.NewContext(tc.req, httptest.NewRecorder()).MultipartReader()
+			assert.NoError(t, err)
+
+			for err == nil {
+				_, err = reader.NextPart()
+			}
+
+			var httpErr *HTTPError
+			assert.ErrorAs(t, err, &httpErr)
+			assert.Equal(t, tc.expectedStatus, httpErr.Status)
+			assert.Equal(t, tc.expectedMsg, httpErr.Message)
+		})
+	}
+}
+
+func TestPart_Limit(t *testing.T) {
+	k := New()
+	k.ApplyOptions(WithMultipartLimits(MultipartLimits{MaxPartSize: 10}))
+
+	reader, err := k.NewContext(newStreamingRequest(t), httptest.NewRecorder()).MultipartReader()
+	assert.NoError(t, err)
+
+	part, err := reader.NextPart()
+	assert.NoError(t, err)
+
+	// The metadata part is larger than MaxPartSize.
+	var metadata uploadMetadata
+	err = part.ReadJSON(&metadata)
+	assert.ErrorIs(t, err, ErrPartTooLarge)
+
+	var httpErr *HTTPError
+	assert.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, httpErr.Status)
+	assert.Equal(t, "part metadata is too large", httpErr.Message)
+
+	part, err = reader.NextPart()
+	assert.NoError(t, err)
+
+	h := sha256.New()
+	var dst bytes.Buffer
+
+	n, err := part.Limit(100).AddHash(h).WriteTo(&dst)
+	assert.ErrorIs(t, err, ErrPartTooLarge)
+	assert.Equal(t, int64(100), n)
+	assert.Equal(t, int64(100), part.Size())
+
+	// Only the bytes within the limit are hashed.
+	sum := sha256.Sum256(dst.Bytes())
+	assert.Equal(t, sum[:], h.Sum(nil))
+
+	assert.PanicsWithValue(t, "part limit cannot be negative", func() {
+		part.Limit(-1)
+	})
+
+	assert.PanicsWithValue(t, "hash cannot be nil", func() {
+		part.AddHash(nil)
+	})
+}
+
+func TestPart_Decode(t *testing.T) {
+	var body bytes.Buffer
+
+	writer := multipart.NewWriter(&body)
+	assert.NoError(t, writer.WriteField("metadata", "<uploadMetadata><title>Holiday</title></uploadMetadata>"))
+	assert.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/", &body)
+	req.Header.Set(contentTypeHeader, writer.FormDataContentType())
+
+	reader, err := New().NewContext(req, httptest.NewRecorder()).MultipartReader()
+	assert.NoError(t, err)
+
+	part, err := reader.NextPart()
+	assert.NoError(t, err)
+
+	var metadata uploadMetadata
+	assert.NoError(t, part.ReadXML(&metadata))
+	assert.Equal(t, uploadMetadata{Title: "Holiday"}, metadata)
+
+	assert.PanicsWithValue(t, "serializer cannot be nil", func() {
+		_ = part.Decode(nil, &metadata)
+	})
+}
@@ -0,0 +1,431 @@
+package kid
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// pngHeader is the signature of PNG files.
+var pngHeader = []byte("\x89PNG\r\n\x1a\n")
+
+type uploadedFile struct {
+	field, name string
+	content     []byte
+}
+
+func newMultipartRequest(t *testing.T, fields map[string]string, files ...uploadedFile) *http.Request {
+	var body bytes.Buffer
+
+	writer := multipart.NewWriter(&body)
+	for name, value := range fields {
+		assert.NoError(t, writer.WriteField(name, value))
+	}
+
+	for _, file := range files {
+		part, err := writer.CreateFormFile(file.field, file.name)
+		assert.NoError(t, err)
+
+		_, err = part.Write(file.content)
+		assert.NoError(t, err)
+	}
+
+	assert.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/upload?source=query", &body)
+	req.Header.Set(contentTypeHeader, writer.FormDataContentType())
+
+	return req
+}
+
+func TestContext_MultipartForm(t *testing.T) {
+	req := newMultipartRequest(
+		t,
+		map[string]string{"title": "Avatar"},
+		uploadedFile{field: "avatar", name: "avatar.txt", content: pngHeader},
+		uploadedFile{field: "docs", name: "a.txt", content: []byte("hello")},
+	)
+
+	c := New().NewContext(req, httptest.NewRecorder())
+
+	form, err := c.MultipartForm()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Avatar"}, form.Value["title"])
+
+	// Content-Type is sniffed from the content instead of trusting the client.
+	assert.Equal(t, "image/png", form.File["avatar"][0].Header.Get(contentTypeHeader))
+	assert.Equal(t, "text/plain", form.File["docs"][0].Header.Get(contentTypeHeader))
+
+	parsed, err := c.MultipartForm()
+	assert.NoError(t, err)
+	assert.Same(t, form, parsed)
+
+	assert.Equal(t, "Avatar", c.FormValue("title"))
+	assert.Equal(t, "query", c.FormValue("source"))
+
+	file, err := c.FormFile("avatar")
+	assert.NoError(t, err)
+	assert.Equal(t, "avatar.txt", file.Filename)
+
+	_, err = c.FormFile("missing")
+	assert.ErrorIs(t, err, http.ErrMissingFile)
+}
+
+func TestContext_MultipartForm_Errors(t *testing.T) {
+	file := uploadedFile{field: "file", name: "file.txt", content: []byte(strings.Repeat("a", 1024))}
+
+	testCases := []struct {
+		name           string
+		limits         MultipartLimits
+		req            *http.Request
+		expectedStatus int
+		expectedMsg    string
+	}{
+		{
+			name:           "not_multipart",
+			req:            httptest.NewRequest(http.MethodPost, "/", strings.NewReader("a=b")),
+			expectedStatus: http.StatusUnsupportedMediaType,
+			expectedMsg:    "Unsupported Media Type",
+		},
+		{
+			name:           "body_too_large",
+			limits:         MultipartLimits{MaxBodySize: 512},
+			req:            newMultipartRequest(t, nil, file),
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedMsg:    "Request Entity Too Large",
+		},
+		{
+			name:           "part_too_large",
+			limits:         MultipartLimits{MaxPartSize: 512},
+			req:            newMultipartRequest(t, nil, file),
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedMsg:    "part file is too large",
+		},
+		{
+			name:           "too_many_files",
+			limits:         MultipartLimits{MaxFiles: 1},
+			req:            newMultipartRequest(t, nil, file, file),
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedMsg:    "too many files, at most 1 files are allowed",
+		},
+		{
+			name:           "type_not_allowed",
+			limits:         MultipartLimits{AllowedTypes: []string{"image/*"}},
+			req:            newMultipartRequest(t, nil, file),
+			expectedStatus: http.StatusUnsupportedMediaType,
+			expectedMsg:    "type text/plain of file file.txt is not allowed",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			k := New()
+			k.ApplyOptions(WithMultipartLimits(tc.limits))
+
+			c := k.NewContext(tc.req, httptest.NewRecorder())
+
+			_, err := c.MultipartForm()
+
+			var httpErr *HTTPError
+			assert.ErrorAs(t, err, &httpErr)
+			assert.Equal(t, tc.expectedStatus, httpErr.Status)
+			assert.Equal(t, tc.expectedMsg, httpErr.Message)
+		})
+	}
+}
+
+func TestMultipartLimits_allows(t *testing.T) {
+	limits := MultipartLimits{AllowedTypes: []string{"image/*", "Application/PDF"}}
+
+	assert.True(t, limits.allows("image/png"))
+	assert.True(t, limits.allows("application/pdf"))
+	assert.False(t, limits.allows("text/plain"))
+	assert.False(t, limits.allows("imagex/png"))
+
+	assert.True(t, MultipartLimits{}.allows("text/plain"))
+	assert.True(t, MultipartLimits{AllowedTypes: []string{"*/*"}}.allows("text/plain"))
+}
+
+func TestMultipartLimits_validate(t *testing.T) {
+	for _, limits := range []MultipartLimits{{MaxBodySize: -1}, {MaxFiles: -1}, {MaxPartSize: -1}, {MaxMemory: -1}} {
+		assert.PanicsWithValue(t, "multipart limits cannot be negative", func() {
+			limits.validate()
+		})
+	}
+
+	assert.NotPanics(t, func() {
+		MultipartLimits{}.validate()
+	})
+}
+
+func TestContext_FormValue_URLEncoded(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/?name=query", strings.NewReader("name=body"))
+	req.Header.Set(contentTypeHeader, "application/x-www-form-urlencoded")
+
+	c := New().NewContext(req, httptest.NewRecorder())
+
+	assert.Equal(t, "body", c.FormValue("name"))
+	assert.Empty(t, c.FormValue("missing"))
+}
+
+func TestContext_SaveUploadedFile(t *testing.T) {
+	req := newMultipartRequest(t, nil, uploadedFile{field: "file", name: "file.txt", content: []byte("hello")})
+	c := New().NewContext(req, httptest.NewRecorder())
+
+	file, err := c.FormFile("file")
+	assert.NoError(t, err)
+
+	dst := filepath.Join(t.TempDir(), "uploads", "file.txt")
+
+	err = c.SaveUploadedFile(file, dst)
+	assert.NoError(t, err)
+
+	content, err := os.ReadFile(dst)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(content))
+
+	err = c.SaveUploadedFile(file, filepath.Join(t.TempDir(), "missing", "\x00"))
+	assert.Error(t, err)
+}
+
+func TestContext_MultipartForm_CachedError(t *testing.T) {
+	k := New()
+	k.ApplyOptions(WithMultipartLimits(MultipartLimits{MaxFiles: 1}))
+
+	k.Post("/upload", WrapErrHandlerFunc(func(c *Context) error {
+		// The error of parsing the form is dropped by FormValue.
+		_ = c.FormValue("name")
+
+		form, err := c.MultipartForm()
+		if err != nil {
+			return err
+		}
+
+		c.String(http.StatusOK, fmt.Sprintf("%d files", len(form.File["f"])))
+		return nil
+	}))
+
+	k.Post("/file", WrapErrHandlerFunc(func(c *Context) error {
+		_ = c.FormValue("name")
+
+		if _, err := c.FormFile("f"); err != nil {
+			return err
+		}
+
+		c.String(http.StatusOK, "ok")
+		return nil
+	}))
+
+	k.Post("/bind", WrapErrHandlerFunc(func(c *Context) error {
+		_ = c.FormValue("name")
+
+		var out bindBody
+		if err := c.Bind(&out); err != nil {
+			return err
+		}
+
+		c.String(http.StatusOK, out.Name)
+		return nil
+	}))
+
+	file := uploadedFile{field: "f", name: "f.txt", content: []byte("hello")}
+
+	for _, path := range []string{"/upload", "/file", "/bind"} {
+		req := newMultipartRequest(t, map[string]string{"name": "foo"}, file, file, file)
+		req.URL.Path = path
+
+		res := httptest.NewRecorder()
+		k.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, res.Code, path)
+		assert.Equal(t, `{"message":"too many files, at most 1 files are allowed"}`+"\n", res.Body.String(), path)
+	}
+
+	// The rest of the body is not parsed without the limits.
+	req := newMultipartRequest(t, map[string]string{"name": "foo"}, file, file, file)
+	c := k.NewContext(req, httptest.NewRecorder())
+
+	_, err := c.MultipartForm()
+	assert.Error(t, err)
+
+	assert.Empty(t, c.FormValue("f"))
+	assert.Nil(t, req.MultipartForm)
+}
+
+// countingBody counts the bytes read from the body.
+type countingBody struct {
+	io.Reader
+	read int
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.Reader.Read(p)
+	b.read += n
+	return n, err
+}
+
+func TestContext_MultipartForm_LimitsWhileReading(t *testing.T) {
+	large := uploadedFile{field: "f", name: "f.txt", content: bytes.Repeat([]byte("a"), 4<<20)}
+
+	testCases := []struct {
+		name   string
+		limits MultipartLimits
+		files  []uploadedFile
+	}{
+		{name: "part_too_large", limits: MultipartLimits{MaxPartSize: 1024}, files: []uploadedFile{large}},
+		{name: "too_many_files", limits: MultipartLimits{MaxFiles: 1}, files: []uploadedFile{{field: "f", name: "a.txt", content: []byte("a")}, large, large}},
+		{name: "type_not_allowed", limits: MultipartLimits{AllowedTypes: []string{"image/*"}}, files: []uploadedFile{large}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := newMultipartRequest(t, nil, tc.files...)
+
+			body := &countingBody{Reader: req.Body}
+			req.Body = io.NopCloser(body)
+
+			k := New()
+			k.ApplyOptions(WithMultipartLimits(tc.limits))
+
+			_, err := k.NewContext(req, httptest.NewRecorder()).MultipartForm()
+			assert.Error(t, err)
+
+			// Reading stops at the violating part, instead of storing the whole body first.
+			assert.Less(t, body.read, 1<<20)
+		})
+	}
+}
+
+func TestContext_MultipartForm_MaxMemory(t *testing.T) {
+	req := newMultipartRequest(
+		t, map[string]string{"name": "value"},
+		uploadedFile{field: "a", name: "a.txt", content: []byte("12345")},
+		uploadedFile{field: "b", name: "b.txt", content: []byte("12345678")},
+		uploadedFile{field: "c", name: "c.txt", content: []byte("123")},
+	)
+
+	k := New()
+	k.ApplyOptions(WithMultipartLimits(MultipartLimits{MaxMemory: 10}))
+
+	c := k.NewContext(req, httptest.NewRecorder())
+	defer c.removeMultipartFiles()
+
+	form, err := c.MultipartForm()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"value"}, form.Value["name"])
+
+	// Files are stored in memory until MaxMemory is used, the rest are stored in temporary files.
+	for field, onDisk := range map[string]bool{"a": false, "b": true, "c": false} {
+		file, err := form.File[field][0].Open()
+		assert.NoError(t, err)
+
+		_, isFile := file.(*os.File)
+		assert.Equal(t, onDisk, isFile, field)
+
+		content, err := io.ReadAll(file)
+		assert.NoError(t, err)
+		assert.Equal(t, form.File[field][0].Size, int64(len(content)))
+		assert.NoError(t, file.Close())
+	}
+}
+
+func TestContext_MultipartForm_TooManyParts(t *testing.T) {
+	fields := make(map[string]string, maxMultipartParts+1)
+	for i := 0; i <= maxMultipartParts; i++ {
+		fields[fmt.Sprintf("field%d", i)] = "value"
+	}
+
+	_, err := New().NewContext(newMultipartRequest(t, fields), httptest.NewRecorder()).MultipartForm()
+
+	var httpErr *HTTPError
+	assert.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, httpErr.Status)
+	assert.ErrorIs(t, err, multipart.ErrMessageTooLarge)
+}
+
+func TestKid_ServeHTTP_MultipartLimits(t *testing.T) {
+	k := New()
+	k.ApplyOptions(WithMultipartLimits(MultipartLimits{MaxMemory: 1}))
+
+	var tempFile string
+
+	upload := WrapErrHandlerFunc(func(c *Context) error {
+		file, err := c.FormFile("file")
+		if err != nil {
+			return err
+		}
+
+		f, err := file.Open()
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		// Files larger than MaxMemory are stored on disk.
+		osFile, ok := f.(*os.File)
+		if !ok {
+			return errors.New("file is not stored on disk")
+		}
+		tempFile = osFile.Name()
+
+		content, err := io.ReadAll(f)
+		if err != nil {
+			return err
+		}
+
+		c.String(http.StatusOK, string(content))
+		return nil
+	})
+
+	k.Post("/upload", upload)
+	k.Post("/avatars/{kind?}", upload).MultipartLimits(MultipartLimits{MaxFiles: 1, AllowedTypes: []string{"image/*"}})
+
+	res := httptest.NewRecorder()
+	k.ServeHTTP(res, newMultipartRequest(t, nil, uploadedFile{field: "file", name: "file.txt", content: []byte("hello")}))
+
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "hello", res.Body.String())
+
+	// Temporary files are removed after the request is served.
+	assert.NotEmpty(t, tempFile)
+	_, err := os.Stat(tempFile)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	// Route limits override Kid's limits, also for the routes expanded from optional parameters.
+	for _, path := range []string{"/avatars", "/avatars/user"} {
+		req := newMultipartRequest(t, nil, uploadedFile{field: "file", name: "file.txt", content: []byte("hello")})
+		req.URL.Path = path
+
+		res = httptest.NewRecorder()
+		k.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusUnsupportedMediaType, res.Code)
+		assert.Equal(t, `{"message":"type text/plain of file file.txt is not allowed"}`+"\n", res.Body.String())
+	}
+}
+
+func TestRoute_MultipartLimits(t *testing.T) {
+	k := New()
+	route := k.Add("/upload", func(c *Context) {}, []string{http.MethodPost, http.MethodPut})
+
+	limits := MultipartLimits{MaxFiles: 3}
+	assert.Same(t, route, route.MultipartLimits(limits))
+
+	for _, method := range []string{http.MethodPost, http.MethodPut} {
+		assert.Equal(t, &limits, route.node.handlerMap[method].options.multipartLimits)
+	}
+
+	assert.PanicsWithValue(t, "multipart limits cannot be negative", func() {
+		route.MultipartLimits(MultipartLimits{MaxFiles: -1})
+	})
+}
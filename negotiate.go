@@ -0,0 +1,271 @@
+package kid
+
+import (
+	"encoding"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type (
+	// HTMLTemplate is the data of negotiated responses which can be rendered as HTML.
+	//
+	// HTML responses are rendered with the template and the data, the other formats are rendered with the data.
+	HTMLTemplate struct {
+		// Name is the template's name, relative to the templates root directory.
+		Name string
+
+		// Data is the data which is passed to the template.
+		Data any
+	}
+
+	// acceptRange is a media range of the Accept header.
+	acceptRange struct {
+		mediaType string
+		subtype   string
+		quality   float64
+	}
+)
+
+// Negotiate sends the data in the format which the request's Accept header prefers, among the offered media types.
+//
+// Offers are MIMEApplicationJSON, MIMEApplicationXML, MIMETextHTML, MIMETextPlain and the media types which have a serializer
+// registered with WithSerializer, in the order of the server's preference.
+// If no offer is given, JSON, XML and plain text are offered, and HTML is offered first if the data is an HTMLTemplate.
+// XML is skipped for the next acceptable offer if the data's type can't be marshalled to XML, e.g. maps.
+// Proto messages are offered as JSON and protobuf instead.
+// Plain text responses are formatted with fmt.Sprint.
+//
+// Media ranges can have wildcards and q-values, ties are broken by the order of the offers.
+// A missing Accept header accepts any offer. The Vary header is set to Accept since the response depends on it.
+// If none of the offers is acceptable, Kid's not acceptable handler is called, which responds with 406 status code by default.
+//
+// Panics if an offer is not supported, or HTML is offered but the data is not an HTMLTemplate.
+func (c *Context) Negotiate(code int, data any, offers ...string) {
+	template, isTemplate := data.(HTMLTemplate)
+
+	defaultOffers := len(offers) == 0
+	if defaultOffers {
+		_, isProto := data.(ProtoMessage)
+
+		switch {
+		case isProto:
+			offers = []string{MIMEApplicationJSON, MIMEApplicationXProtobuf, MIMEApplicationProtobuf}
+		case isTemplate:
+			offers = []string{MIMETextHTML, MIMEApplicationJSON, MIMEApplicationXML, MIMETextPlain}
+		default:
+			offers = []string{MIMEApplicationJSON, MIMEApplicationXML, MIMETextPlain}
+		}
+	}
+
+	for _, offer := range offers {
+		switch offer {
+		case MIMEApplicationJSON, MIMEApplicationXML, MIMETextPlain:
+		case MIMETextHTML:
+			if !isTemplate {
+				panic("html offers need HTMLTemplate data")
+			}
+		default:
+			if _, ok := c.kid.serializers[offer]; !ok {
+				panic(fmt.Sprintf("offer %q is not supported", offer))
+			}
+		}
+	}
+
+	addVary(c.response.Header(), "Accept")
+
+	if isTemplate {
+		data = template.Data
+	}
+
+	accept := c.GetRequestHeader("Accept")
+	offer := negotiateMediaType(accept, offers)
+
+	if offer == MIMEApplicationXML && defaultOffers && !isXMLMarshalable(data) {
+		offer = negotiateMediaType(accept, withoutOffer(offers, MIMEApplicationXML))
+	}
+
+	switch offer {
+	case "":
+		c.kid.notAcceptableHandler(c)
+	case MIMEApplicationJSON:
+		c.JSON(code, data)
+	case MIMEApplicationXML:
+		c.XML(code, data)
+	case MIMETextHTML:
+		c.HTML(code, template.Name, template.Data)
+	case MIMETextPlain:
+		c.String(code, fmt.Sprint(data))
+	default:
+		c.Render(code, offer, data)
+	}
+}
+
+var (
+	xmlMarshalerType  = reflect.TypeOf((*xml.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+	// xmlMarshalableCache caches whether types can be marshalled to XML.
+	xmlMarshalableCache sync.Map
+)
+
+// isXMLMarshalable reports whether the data can be marshalled to XML.
+//
+// Only the data's type is checked, so the data isn't encoded before it's sent. Values of interface fields are not checked.
+func isXMLMarshalable(data any) bool {
+	if data == nil {
+		return true
+	}
+
+	t := reflect.TypeOf(data)
+	if ok, found := xmlMarshalableCache.Load(t); found {
+		return ok.(bool)
+	}
+
+	ok := isXMLMarshalableType(t, make(map[reflect.Type]bool))
+	xmlMarshalableCache.Store(t, ok)
+
+	return ok
+}
+
+// isXMLMarshalableType reports whether values of the type can be marshalled to XML, like encoding/xml does.
+//
+// Maps, channels, functions and complex numbers can't be marshalled unless they implement xml.Marshaler or encoding.TextMarshaler.
+// visited holds the struct types which are being checked, so recursive types are checked once.
+func isXMLMarshalableType(t reflect.Type, visited map[reflect.Type]bool) bool {
+	for _, marshaler := range []reflect.Type{xmlMarshalerType, textMarshalerType} {
+		if t.Implements(marshaler) || reflect.PointerTo(t).Implements(marshaler) {
+			return true
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Map, reflect.Chan, reflect.Func, reflect.Complex64, reflect.Complex128, reflect.UnsafePointer:
+		return false
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		return isXMLMarshalableType(t.Elem(), visited)
+	case reflect.Struct:
+		if visited[t] {
+			return true
+		}
+		visited[t] = true
+
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if (!field.IsExported() && !field.Anonymous) || field.Tag.Get("xml") == "-" {
+				continue
+			}
+
+			if !isXMLMarshalableType(field.Type, visited) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// withoutOffer returns the offers except the given one.
+func withoutOffer(offers []string, offer string) []string {
+	filtered := make([]string, 0, len(offers))
+	for _, o := range offers {
+		if o != offer {
+			filtered = append(filtered, o)
+		}
+	}
+	return filtered
+}
+
+// negotiateMediaType returns the offer with the highest quality in the Accept header, or an empty string if none is acceptable.
+//
+// Each offer gets the quality of the most specific media range which matches it.
+func negotiateMediaType(accept string, offers []string) string {
+	if strings.TrimSpace(accept) == "" {
+		return offers[0]
+	}
+
+	ranges := parseAccept(accept)
+
+	var best string
+	var bestQuality float64
+
+	for _, offer := range offers {
+		mediaType, subtype, _ := strings.Cut(offer, "/")
+
+		quality, specificity := 0.0, -1
+		for _, r := range ranges {
+			var s int
+			switch {
+			case r.mediaType == mediaType && r.subtype == subtype:
+				s = 2
+			case r.mediaType == mediaType && r.subtype == "*":
+				s = 1
+			case r.mediaType == "*" && r.subtype == "*":
+				s = 0
+			default:
+				continue
+			}
+
+			if s > specificity {
+				quality, specificity = r.quality, s
+			}
+		}
+
+		if quality > bestQuality {
+			best, bestQuality = offer, quality
+		}
+	}
+
+	return best
+}
+
+// parseAccept parses the media ranges of the Accept header.
+//
+// Invalid media ranges are ignored, and invalid q-values are treated as 1.
+func parseAccept(accept string) []acceptRange {
+	ranges := make([]acceptRange, 0)
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaRange, params, _ := strings.Cut(part, ";")
+
+		mediaType, subtype, ok := strings.Cut(strings.ToLower(strings.TrimSpace(mediaRange)), "/")
+		if !ok || mediaType == "" || subtype == "" || (mediaType == "*" && subtype != "*") {
+			continue
+		}
+
+		r := acceptRange{mediaType: mediaType, subtype: subtype, quality: 1}
+
+		for _, param := range strings.Split(params, ";") {
+			key, value, _ := strings.Cut(param, "=")
+			if !strings.EqualFold(strings.TrimSpace(key), "q") {
+				continue
+			}
+
+			if q, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil && q >= 0 && q <= 1 {
+				r.quality = q
+			}
+			break
+		}
+
+		ranges = append(ranges, r)
+	}
+
+	return ranges
+}
+
+// addVary adds the value to the Vary header if it's not already there.
+func addVary(header http.Header, value string) {
+	for _, vary := range header.Values("Vary") {
+		for _, v := range strings.Split(vary, ",") {
+			if v = strings.TrimSpace(v); v == "*" || strings.EqualFold(v, value) {
+				return
+			}
+		}
+	}
+
+	header.Add("Vary", value)
+}
@@ -0,0 +1,287 @@
+package kid
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	htmlrenderer "github.com/mojixcoder/kid/html_renderer"
+	"github.com/mojixcoder/kid/serializer"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/apipb"
+)
+
+func TestContext_Negotiate(t *testing.T) {
+	k := New()
+	renderer := htmlrenderer.New("testdata/templates/", "layouts/", ".html", false)
+	renderer.SetFunc("greet", func() int { return 1 })
+	k.htmlRenderer = renderer
+
+	data := HTMLTemplate{Name: "pages/page.html", Data: Map{"key": "value"}}
+
+	testCases := []struct {
+		name        string
+		accept      string
+		offers      []string
+		contentType string
+		body        string
+	}{
+		{name: "no_accept", contentType: "text/html"},
+		{name: "no_accept_offers", offers: []string{MIMETextPlain, MIMEApplicationJSON}, contentType: "text/plain"},
+		{name: "json", accept: "application/json", contentType: "application/json", body: "{\"key\":\"value\"}\n"},
+		{name: "text", accept: "text/plain", contentType: "text/plain", body: "map[key:value]"},
+		{name: "html", accept: "text/html,application/xhtml+xml,*/*;q=0.8", contentType: "text/html"},
+		{name: "quality", accept: "application/json;q=0.5, text/plain;q=0.9", contentType: "text/plain"},
+		{name: "wildcard", accept: "*/*", offers: []string{MIMEApplicationJSON, MIMETextPlain}, contentType: "application/json"},
+		{name: "type_wildcard", accept: "text/*", offers: []string{MIMEApplicationJSON, MIMETextPlain}, contentType: "text/plain"},
+		{name: "specific_range_wins", accept: "text/*;q=0.9, text/html;q=0.1", contentType: "text/plain"},
+		{name: "excluded", accept: "*/*, text/html;q=0", contentType: "application/json"},
+		{name: "case_insensitive", accept: "Application/JSON; Q=1", contentType: "application/json"},
+		{name: "invalid_ranges", accept: "json, */json, application/json;q=abc", contentType: "application/json"},
+		{name: "not_acceptable", accept: "image/png", contentType: "application/json", body: "{\"message\":\"Not Acceptable\"}\n"},
+		{name: "zero_quality", accept: "application/json;q=0", offers: []string{MIMEApplicationJSON}, contentType: "application/json", body: "{\"message\":\"Not Acceptable\"}\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+
+			res := httptest.NewRecorder()
+			c := k.NewContext(req, res)
+
+			c.Negotiate(http.StatusCreated, data, tc.offers...)
+
+			assert.Equal(t, tc.contentType, res.Header().Get("Content-Type"))
+			assert.Equal(t, "Accept", res.Header().Get("Vary"))
+
+			if tc.body != "" {
+				assert.Equal(t, tc.body, res.Body.String())
+			}
+
+			if tc.contentType == "text/html" {
+				assert.Contains(t, res.Body.String(), "<p>value</p>")
+			}
+
+			if tc.name == "not_acceptable" || tc.name == "zero_quality" {
+				assert.Equal(t, http.StatusNotAcceptable, res.Code)
+			} else {
+				assert.Equal(t, http.StatusCreated, res.Code)
+			}
+		})
+	}
+}
+
+func TestContext_Negotiate_Data(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "application/xml, text/html")
+	req.Header.Set("Vary", "Origin")
+
+	res := httptest.NewRecorder()
+	res.Header().Set("Vary", "Origin, accept")
+
+	c := New().NewContext(req, res)
+
+	// HTML is not offered if the data is not a template.
+	c.Negotiate(http.StatusOK, person{Name: "foo", Age: 1999})
+
+	assert.Equal(t, "application/xml", res.Header().Get("Content-Type"))
+	assert.Equal(t, "<person><name>foo</name><age>1999</age></person>", res.Body.String())
+	assert.Equal(t, []string{"Origin, accept"}, res.Header().Values("Vary"))
+
+	assert.PanicsWithValue(t, "html offers need HTMLTemplate data", func() {
+		c.Negotiate(http.StatusOK, person{}, MIMETextHTML)
+	})
+
+	assert.PanicsWithValue(t, "offer \"image/png\" is not supported", func() {
+		c.Negotiate(http.StatusOK, person{}, "image/png")
+	})
+}
+
+func TestContext_Negotiate_XMLFallback(t *testing.T) {
+	testCases := []struct {
+		name        string
+		accept      string
+		data        any
+		code        int
+		contentType string
+		body        string
+	}{
+		{name: "map_xml_only", accept: "application/xml", data: Map{"key": "value"}, code: http.StatusNotAcceptable, contentType: "application/json", body: "{\"message\":\"Not Acceptable\"}\n"},
+		{name: "map_next_offer", accept: "application/xml, text/plain;q=0.5", data: Map{"key": "value"}, code: http.StatusOK, contentType: "text/plain", body: "map[key:value]"},
+		{name: "template_map", accept: "application/xml, application/json;q=0.5", data: HTMLTemplate{Data: Map{"key": "value"}}, code: http.StatusOK, contentType: "application/json", body: "{\"key\":\"value\"}\n"},
+		{name: "struct", accept: "application/xml", data: person{Name: "foo", Age: 1999}, code: http.StatusOK, contentType: "application/xml", body: "<person><name>foo</name><age>1999</age></person>"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept", tc.accept)
+
+			res := httptest.NewRecorder()
+			New().NewContext(req, res).Negotiate(http.StatusOK, tc.data)
+
+			assert.Equal(t, tc.code, res.Code)
+			assert.Equal(t, tc.contentType, res.Header().Get("Content-Type"))
+			assert.Equal(t, tc.body, res.Body.String())
+		})
+	}
+
+	// Explicit XML offers are not skipped.
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "application/xml")
+
+	assert.Panics(t, func() {
+		New().NewContext(req, httptest.NewRecorder()).Negotiate(http.StatusOK, Map{"key": "value"}, MIMEApplicationXML)
+	})
+}
+
+// countingXMLMarshaler counts how many times it's marshalled to XML.
+type countingXMLMarshaler struct {
+	count *int
+}
+
+func (m countingXMLMarshaler) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	*m.count++
+	return e.EncodeElement("value", start)
+}
+
+func TestContext_Negotiate_XMLEncodedOnce(t *testing.T) {
+	var count int
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "application/xml")
+
+	res := httptest.NewRecorder()
+	New().NewContext(req, res).Negotiate(http.StatusOK, countingXMLMarshaler{count: &count})
+
+	assert.Equal(t, 1, count)
+	assert.Equal(t, "<countingXMLMarshaler>value</countingXMLMarshaler>", res.Body.String())
+}
+
+func TestIsXMLMarshalable(t *testing.T) {
+	type recursive struct {
+		Name     string       `xml:"name"`
+		Children []*recursive `xml:"child"`
+	}
+
+	type ignored struct {
+		Name  string         `xml:"name"`
+		Attrs map[string]int `xml:"-"`
+		attrs map[string]int
+	}
+
+	type nested struct {
+		Attrs map[string]int `xml:"attrs"`
+	}
+
+	testCases := []struct {
+		name string
+		data any
+	}{
+		{name: "nil", data: nil},
+		{name: "string", data: "value"},
+		{name: "struct", data: person{Name: "foo", Age: 1}},
+		{name: "pointer", data: &person{Name: "foo"}},
+		{name: "slice", data: []person{{Name: "foo"}}},
+		{name: "recursive", data: recursive{Name: "a", Children: []*recursive{{Name: "b"}}}},
+		{name: "ignored_fields", data: ignored{Name: "a"}},
+		{name: "marshaler", data: countingXMLMarshaler{count: new(int)}},
+		{name: "map", data: Map{"key": "value"}},
+		{name: "nested_map", data: nested{Attrs: map[string]int{"a": 1}}},
+		{name: "slice_of_maps", data: []Map{{"key": "value"}}},
+		{name: "channel", data: make(chan int)},
+		{name: "func", data: func() {}},
+		{name: "complex", data: complex(1, 2)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := xml.Marshal(tc.data)
+
+			assert.Equal(t, err == nil, isXMLMarshalable(tc.data))
+		})
+	}
+}
+
+func TestContext_Negotiate_Protobuf(t *testing.T) {
+	k := New()
+	k.ApplyOptions(WithJSONSerializer(serializer.NewProtoJSONSerializer()))
+
+	msg := &apipb.Method{Name: "foo"}
+
+	testCases := []struct {
+		name        string
+		accept      string
+		contentType string
+		status      int
+	}{
+		{name: "no_accept", contentType: "application/json", status: http.StatusOK},
+		{name: "json", accept: "application/json", contentType: "application/json", status: http.StatusOK},
+		{name: "protobuf", accept: "application/protobuf", contentType: "application/protobuf", status: http.StatusOK},
+		{name: "x_protobuf", accept: "application/x-protobuf, application/json;q=0.5", contentType: "application/x-protobuf", status: http.StatusOK},
+		{name: "xml_not_offered", accept: "application/xml", contentType: "application/json", status: http.StatusNotAcceptable},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+
+			res := httptest.NewRecorder()
+			k.NewContext(req, res).Negotiate(http.StatusOK, msg)
+
+			assert.Equal(t, tc.contentType, res.Header().Get("Content-Type"))
+			assert.Equal(t, tc.status, res.Code)
+
+			if tc.status != http.StatusOK {
+				return
+			}
+
+			var out apipb.Method
+			if tc.contentType == "application/json" {
+				assert.NoError(t, protojson.Unmarshal(res.Body.Bytes(), &out))
+			} else {
+				assert.NoError(t, proto.Unmarshal(res.Body.Bytes(), &out))
+			}
+
+			assert.Equal(t, "foo", out.GetName())
+		})
+	}
+}
+
+func TestContext_Negotiate_NotAcceptableHandler(t *testing.T) {
+	k := New()
+	k.ApplyOptions(WithNotAcceptableHandler(func(c *Context) {
+		c.String(http.StatusNotAcceptable, "json only")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "text/html")
+
+	res := httptest.NewRecorder()
+	k.NewContext(req, res).Negotiate(http.StatusOK, Map{}, MIMEApplicationJSON)
+
+	assert.Equal(t, http.StatusNotAcceptable, res.Code)
+	assert.Equal(t, "json only", res.Body.String())
+}
+
+func TestAddVary(t *testing.T) {
+	header := make(http.Header)
+
+	addVary(header, "Accept")
+	addVary(header, "Origin")
+	addVary(header, "accept")
+	assert.Equal(t, []string{"Accept", "Origin"}, header.Values("Vary"))
+
+	header = http.Header{"Vary": {"*"}}
+	addVary(header, "Accept")
+	assert.Equal(t, []string{"*"}, header.Values("Vary"))
+}
@@ -1,8 +1,12 @@
 package kid
 
 import (
+	"fmt"
+	"net/netip"
+
 	htmlrenderer "github.com/mojixcoder/kid/html_renderer"
 	"github.com/mojixcoder/kid/serializer"
+	"github.com/mojixcoder/kid/validator"
 )
 
 type (
@@ -36,20 +40,72 @@ func WithHTMLRenderer(renderer htmlrenderer.HTMLRenderer) Option {
 }
 
 // WithXMLSerializer configures Kid's XML serializer.
+//
+// It's registered for application/xml and text/xml.
 func WithXMLSerializer(serializer serializer.Serializer) Option {
 	panicIfNil(serializer, "xml serializer cannot be nil")
 
 	return optionImpl(func(k *Kid) {
-		k.xmlSerializer = serializer
+		k.serializers[MIMEApplicationXML] = serializer
+		k.serializers[MIMETextXML] = serializer
 	})
 }
 
 // WithJSONSerializer configures Kid's JSON serializer.
+//
+// It's registered for application/json.
 func WithJSONSerializer(serializer serializer.Serializer) Option {
 	panicIfNil(serializer, "json serializer cannot be nil")
 
 	return optionImpl(func(k *Kid) {
-		k.jsonSerializer = serializer
+		k.serializers[MIMEApplicationJSON] = serializer
+	})
+}
+
+// WithSerializer registers the serializer for the media type, e.g. application/yaml or a vendor type.
+//
+// Registered serializers are used by Context.Render, Context.Read, Context.Bind and Context.Negotiate.
+// Parameters of the media type are ignored. Panics if the media type is invalid or the serializer is nil.
+func WithSerializer(mediaType string, serializer serializer.Serializer) Option {
+	panicIfNil(serializer, "serializer cannot be nil")
+
+	base, ok := parseMediaType(mediaType)
+	if !ok {
+		panic(fmt.Sprintf("invalid media type %q", mediaType))
+	}
+
+	return optionImpl(func(k *Kid) {
+		k.serializers[base] = serializer
+	})
+}
+
+// WithErrorHandler configures Kid's error handler.
+//
+// It's called with the errors returned by ErrHandlerFuncs or reported with Context.Error.
+func WithErrorHandler(handler HTTPErrorHandler) Option {
+	panicIfNil(handler, "error handler cannot be nil")
+
+	return optionImpl(func(k *Kid) {
+		k.errorHandler = handler
+	})
+}
+
+// WithProblemDetails configures whether the built-in handlers send RFC 7807 problem details documents.
+//
+// It affects the default not found, method not allowed and error handlers, and the recovery middleware's default behavior.
+// Defaults to false, which sends JSON messages.
+func WithProblemDetails(enabled bool) Option {
+	return optionImpl(func(k *Kid) {
+		k.problemDetails = enabled
+	})
+}
+
+// WithValidator configures Kid's validator.
+func WithValidator(validator validator.Validator) Option {
+	panicIfNil(validator, "validator cannot be nil")
+
+	return optionImpl(func(k *Kid) {
+		k.validator = validator
 	})
 }
 
@@ -70,3 +126,135 @@ func WithMethodNotAllowedHandler(handler HandlerFunc) Option {
 		k.methodNotAllowedHandler = handler
 	})
 }
+
+// WithNotAcceptableHandler configures Kid's not acceptable handler.
+//
+// It's called by Context.Negotiate when none of the offers is acceptable.
+func WithNotAcceptableHandler(handler HandlerFunc) Option {
+	panicIfNil(handler, "not acceptable handler cannot be nil")
+
+	return optionImpl(func(k *Kid) {
+		k.notAcceptableHandler = handler
+	})
+}
+
+// WithOptionsHandler configures Kid's automatic OPTIONS handler.
+//
+// The Allow header is already set when the handler is called.
+func WithOptionsHandler(handler HandlerFunc) Option {
+	panicIfNil(handler, "options handler cannot be nil")
+
+	return optionImpl(func(k *Kid) {
+		k.optionsHandler = handler
+	})
+}
+
+// WithAutoHead configures whether HEAD requests should be served by GET handlers
+// when the route doesn't have a HEAD handler. Response body will be discarded.
+//
+// It's enabled by default.
+func WithAutoHead(enabled bool) Option {
+	return optionImpl(func(k *Kid) {
+		k.autoHead = enabled
+	})
+}
+
+// WithAutoOptions configures whether OPTIONS requests should be answered automatically
+// when the route doesn't have an OPTIONS handler.
+//
+// It's enabled by default.
+func WithAutoOptions(enabled bool) Option {
+	return optionImpl(func(k *Kid) {
+		k.autoOptions = enabled
+	})
+}
+
+// WithRedirectTrailingSlash configures whether requests should be redirected to the same path
+// with or without the trailing slash, when there is no route for the path but one exists for the other one.
+//
+// GET and HEAD requests are redirected with 301 and the others with 308 status code.
+func WithRedirectTrailingSlash(enabled bool) Option {
+	return optionImpl(func(k *Kid) {
+		k.redirectTrailingSlash = enabled
+	})
+}
+
+// WithRedirectFixedPath configures whether requests should be redirected to the cleaned path,
+// e.g. //users or /a/../users to /users, when there is no route for the path but one exists for the cleaned path.
+//
+// GET and HEAD requests are redirected with 301 and the others with 308 status code.
+func WithRedirectFixedPath(enabled bool) Option {
+	return optionImpl(func(k *Kid) {
+		k.redirectFixedPath = enabled
+	})
+}
+
+// WithCaseInsensitiveRouting configures whether requests should be redirected to the route
+// which matches the path case-insensitively, when there is no route for the exact path.
+//
+// It's a redirect policy like WithRedirectTrailingSlash and WithRedirectFixedPath: routes are still matched case-sensitively,
+// and requests are never served under a path with a different case, e.g. /USERS is redirected to /users instead of being served by it.
+// GET and HEAD requests are redirected with 301 and the others with 308 status code.
+func WithCaseInsensitiveRouting(enabled bool) Option {
+	return optionImpl(func(k *Kid) {
+		k.caseInsensitiveRouting = enabled
+	})
+}
+
+// WithMultipartLimits configures the limits of parsing multipart forms.
+//
+// They can be overridden per route with Route.MultipartLimits. Panics if any of the limits is negative.
+func WithMultipartLimits(limits MultipartLimits) Option {
+	limits.validate()
+
+	return optionImpl(func(k *Kid) {
+		k.multipartLimits = limits
+	})
+}
+
+// WithJSONStreamFlushSize configures the number of items which are written before flushing streamed JSON responses.
+//
+// It's 100 by default. Panics if the size is not positive.
+func WithJSONStreamFlushSize(size int) Option {
+	if size < 1 {
+		panic("json stream flush size must be positive")
+	}
+
+	return optionImpl(func(k *Kid) {
+		k.jsonStreamFlushSize = size
+	})
+}
+
+// WithCookieKeyRing configures the key ring of signed and encrypted cookies.
+func WithCookieKeyRing(ring *KeyRing) Option {
+	panicIfNil(ring, "key ring cannot be nil")
+
+	return optionImpl(func(k *Kid) {
+		k.cookieKeyRing = ring
+	})
+}
+
+// WithTrustedProxies configures the proxies whose X-Forwarded-Proto header is trusted, e.g. for securing cookies.
+//
+// Proxies are IP addresses or CIDR ranges, e.g. 10.0.0.1 or 10.0.0.0/8. No proxy is trusted by default.
+// Panics if a proxy is not a valid IP address or CIDR range.
+func WithTrustedProxies(proxies ...string) Option {
+	prefixes := make([]netip.Prefix, len(proxies))
+
+	for i, proxy := range proxies {
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(proxy)
+			if addrErr != nil {
+				panic(fmt.Sprintf("invalid trusted proxy %q", proxy))
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+
+		prefixes[i] = prefix.Masked()
+	}
+
+	return optionImpl(func(k *Kid) {
+		k.trustedProxies = prefixes
+	})
+}
@@ -1,7 +1,9 @@
 package kid
 
 import (
+	"bytes"
 	"net/http"
+	"net/netip"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -17,6 +19,10 @@ func (*mockEverything) Read(req *http.Request, out any) error {
 	return nil
 }
 
+func (*mockEverything) Validate(v any) error {
+	return nil
+}
+
 func TestWithDebug(t *testing.T) {
 	k := New()
 
@@ -53,7 +59,8 @@ func TestWithXMLSerializer(t *testing.T) {
 	opt := WithXMLSerializer(serializer)
 	opt.apply(k)
 
-	assert.Equal(t, serializer, k.xmlSerializer)
+	assert.Equal(t, serializer, k.serializers["application/xml"])
+	assert.Equal(t, serializer, k.serializers["text/xml"])
 }
 
 func TestWithJSONSerializer(t *testing.T) {
@@ -68,7 +75,46 @@ func TestWithJSONSerializer(t *testing.T) {
 	opt := WithJSONSerializer(serializer)
 	opt.apply(k)
 
-	assert.Equal(t, serializer, k.jsonSerializer)
+	assert.Equal(t, serializer, k.serializers["application/json"])
+}
+
+func TestWithErrorHandler(t *testing.T) {
+	k := New()
+
+	assert.PanicsWithValue(t, "error handler cannot be nil", func() {
+		WithErrorHandler(nil)
+	})
+
+	hanlder := func(c *Context, err error) {}
+
+	opt := WithErrorHandler(hanlder)
+	opt.apply(k)
+
+	assert.True(t, funcsAreEqual(hanlder, k.errorHandler))
+}
+
+func TestWithProblemDetails(t *testing.T) {
+	k := New()
+	assert.False(t, k.problemDetails)
+
+	opt := WithProblemDetails(true)
+	opt.apply(k)
+	assert.True(t, k.problemDetails)
+}
+
+func TestWithValidator(t *testing.T) {
+	k := New()
+
+	assert.PanicsWithValue(t, "validator cannot be nil", func() {
+		WithValidator(nil)
+	})
+
+	validator := &mockEverything{}
+
+	opt := WithValidator(validator)
+	opt.apply(k)
+
+	assert.Equal(t, validator, k.validator)
 }
 
 func TestWithNotFoundHandler(t *testing.T) {
@@ -100,3 +146,156 @@ func TestWithMethodNotAllowedHandler(t *testing.T) {
 
 	assert.True(t, funcsAreEqual(hanlder, k.methodNotAllowedHandler))
 }
+
+func TestWithNotAcceptableHandler(t *testing.T) {
+	k := New()
+
+	assert.PanicsWithValue(t, "not acceptable handler cannot be nil", func() {
+		WithNotAcceptableHandler(nil)
+	})
+
+	hanlder := func(c *Context) {}
+
+	opt := WithNotAcceptableHandler(hanlder)
+	opt.apply(k)
+
+	assert.True(t, funcsAreEqual(hanlder, k.notAcceptableHandler))
+}
+
+func TestWithOptionsHandler(t *testing.T) {
+	k := New()
+
+	assert.PanicsWithValue(t, "options handler cannot be nil", func() {
+		WithOptionsHandler(nil)
+	})
+
+	hanlder := func(c *Context) {}
+
+	opt := WithOptionsHandler(hanlder)
+	opt.apply(k)
+
+	assert.True(t, funcsAreEqual(hanlder, k.optionsHandler))
+}
+
+func TestWithAutoHead(t *testing.T) {
+	k := New()
+
+	assert.True(t, k.autoHead)
+
+	opt := WithAutoHead(false)
+	opt.apply(k)
+
+	assert.False(t, k.autoHead)
+}
+
+func TestWithAutoOptions(t *testing.T) {
+	k := New()
+
+	assert.True(t, k.autoOptions)
+
+	opt := WithAutoOptions(false)
+	opt.apply(k)
+
+	assert.False(t, k.autoOptions)
+}
+
+func TestWithRedirectTrailingSlash(t *testing.T) {
+	k := New()
+
+	assert.False(t, k.redirectTrailingSlash)
+
+	opt := WithRedirectTrailingSlash(true)
+	opt.apply(k)
+
+	assert.True(t, k.redirectTrailingSlash)
+}
+
+func TestWithRedirectFixedPath(t *testing.T) {
+	k := New()
+
+	assert.False(t, k.redirectFixedPath)
+
+	opt := WithRedirectFixedPath(true)
+	opt.apply(k)
+
+	assert.True(t, k.redirectFixedPath)
+}
+
+func TestWithCaseInsensitiveRouting(t *testing.T) {
+	k := New()
+
+	assert.False(t, k.caseInsensitiveRouting)
+
+	opt := WithCaseInsensitiveRouting(true)
+	opt.apply(k)
+
+	assert.True(t, k.caseInsensitiveRouting)
+}
+
+func TestWithMultipartLimits(t *testing.T) {
+	k := New()
+
+	assert.Equal(t, MultipartLimits{}, k.multipartLimits)
+
+	assert.PanicsWithValue(t, "multipart limits cannot be negative", func() {
+		WithMultipartLimits(MultipartLimits{MaxMemory: -1})
+	})
+
+	limits := MultipartLimits{MaxBodySize: 1 << 20, MaxFiles: 2, AllowedTypes: []string{"image/png"}}
+
+	opt := WithMultipartLimits(limits)
+	opt.apply(k)
+
+	assert.Equal(t, limits, k.multipartLimits)
+}
+
+func TestWithJSONStreamFlushSize(t *testing.T) {
+	k := New()
+
+	assert.Equal(t, 100, k.jsonStreamFlushSize)
+
+	assert.PanicsWithValue(t, "json stream flush size must be positive", func() {
+		WithJSONStreamFlushSize(0)
+	})
+
+	opt := WithJSONStreamFlushSize(10)
+	opt.apply(k)
+
+	assert.Equal(t, 10, k.jsonStreamFlushSize)
+}
+
+func TestWithCookieKeyRing(t *testing.T) {
+	k := New()
+
+	assert.Nil(t, k.cookieKeyRing)
+
+	assert.PanicsWithValue(t, "key ring cannot be nil", func() {
+		WithCookieKeyRing(nil)
+	})
+
+	ring := NewKeyRing(bytes.Repeat([]byte("k"), 32))
+
+	opt := WithCookieKeyRing(ring)
+	opt.apply(k)
+
+	assert.Same(t, ring, k.cookieKeyRing)
+}
+
+func TestWithTrustedProxies(t *testing.T) {
+	k := New()
+
+	assert.Nil(t, k.trustedProxies)
+
+	assert.PanicsWithValue(t, "invalid trusted proxy \"proxy\"", func() {
+		WithTrustedProxies("10.0.0.1", "proxy")
+	})
+
+	opt := WithTrustedProxies("10.0.0.1", "192.168.1.1/16", "::1")
+	opt.apply(k)
+
+	assert.Equal(t, []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.1/32"),
+		netip.MustParsePrefix("192.168.0.0/16"),
+		netip.MustParsePrefix("::1/128"),
+	}, k.trustedProxies)
+}
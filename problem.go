@@ -0,0 +1,158 @@
+package kid
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"sort"
+)
+
+// problemNamespace is the XML namespace of problem details documents.
+const problemNamespace = "urn:ietf:rfc:7807"
+
+// Problem is a problem details document as defined in RFC 7807.
+//
+// It can also be returned as an error by ErrHandlerFuncs, the default error handler renders it as is.
+type Problem struct {
+	// Type is a URI reference which identifies the problem type. It's assumed to be about:blank if it's empty.
+	Type string
+
+	// Title is a short summary of the problem type.
+	Title string
+
+	// Status is the HTTP status code.
+	Status int
+
+	// Detail is an explanation specific to this occurrence of the problem.
+	Detail string
+
+	// Instance is a URI reference which identifies this occurrence of the problem.
+	Instance string
+
+	// Extensions are the additional members of the problem, written next to the standard members.
+	Extensions Map
+}
+
+// Verifying interface compliance.
+var (
+	_ error          = (*Problem)(nil)
+	_ json.Marshaler = Problem{}
+	_ xml.Marshaler  = Problem{}
+)
+
+// Error implements the error interface.
+func (p *Problem) Error() string {
+	title := p.Title
+	if title == "" {
+		title = http.StatusText(p.Status)
+	}
+
+	if p.Detail != "" {
+		return title + ": " + p.Detail
+	}
+
+	return title
+}
+
+// members returns the members of the problem. Extensions named like the standard members are ignored.
+func (p Problem) members() Map {
+	members := make(Map, len(p.Extensions)+5)
+
+	for name, value := range p.Extensions {
+		if !isStandardProblemMember(name) {
+			members[name] = value
+		}
+	}
+
+	if p.Type != "" {
+		members["type"] = p.Type
+	}
+
+	if p.Title != "" {
+		members["title"] = p.Title
+	}
+
+	if p.Status != 0 {
+		members["status"] = p.Status
+	}
+
+	if p.Detail != "" {
+		members["detail"] = p.Detail
+	}
+
+	if p.Instance != "" {
+		members["instance"] = p.Instance
+	}
+
+	return members
+}
+
+// MarshalJSON implements json.Marshaler.
+func (p Problem) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.members())
+}
+
+// MarshalXML implements xml.Marshaler.
+//
+// Members are written in the urn:ietf:rfc:7807 namespace, standard members first and then the extensions sorted by name.
+// Extension values must be encodable by encoding/xml.
+func (p Problem) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start = xml.StartElement{Name: xml.Name{Space: problemNamespace, Local: "problem"}}
+
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+
+	members := p.members()
+
+	extensions := make([]string, 0, len(p.Extensions))
+	for name := range p.Extensions {
+		if !isStandardProblemMember(name) {
+			extensions = append(extensions, name)
+		}
+	}
+	sort.Strings(extensions)
+
+	names := append([]string{"type", "title", "status", "detail", "instance"}, extensions...)
+
+	for _, name := range names {
+		value, ok := members[name]
+		if !ok {
+			continue
+		}
+
+		if err := e.EncodeElement(value, xml.StartElement{Name: xml.Name{Local: name}}); err != nil {
+			return err
+		}
+	}
+
+	return e.EncodeToken(start.End())
+}
+
+// isStandardProblemMember checks if the name is one of the standard problem details members.
+func isStandardProblemMember(name string) bool {
+	switch name {
+	case "type", "title", "status", "detail", "instance":
+		return true
+	}
+	return false
+}
+
+// problem converts the HTTP error to a problem details document of the request.
+func (e *HTTPError) problem(c *Context) Problem {
+	problem := Problem{Status: e.Status, Title: http.StatusText(e.Status)}
+
+	if e.Message != problem.Title {
+		problem.Detail = e.Message
+	}
+
+	if c.Request() != nil {
+		problem.Instance = c.Request().URL.Path
+	}
+
+	if e.Code != "" {
+		problem.Extensions = Map{"code": e.Code}
+	}
+
+	return problem
+}
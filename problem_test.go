@@ -0,0 +1,82 @@
+package kid
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProblem_Error(t *testing.T) {
+	assert.Equal(t, "Not Found", (&Problem{Status: http.StatusNotFound}).Error())
+	assert.Equal(t, "Out of credit: Balance is 30", (&Problem{Title: "Out of credit", Detail: "Balance is 30"}).Error())
+}
+
+func TestProblem_MarshalJSON(t *testing.T) {
+	problem := Problem{
+		Type:       "https://example.com/probs/out-of-credit",
+		Title:      "You do not have enough credit.",
+		Status:     http.StatusForbidden,
+		Detail:     "Your current balance is 30, but that costs 50.",
+		Instance:   "/account/12345/msgs/abc",
+		Extensions: Map{"balance": 30, "accounts": []string{"/account/12345"}, "title": "ignored"},
+	}
+
+	blob, err := json.Marshal(problem)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "https://example.com/probs/out-of-credit",
+		"title": "You do not have enough credit.",
+		"status": 403,
+		"detail": "Your current balance is 30, but that costs 50.",
+		"instance": "/account/12345/msgs/abc",
+		"balance": 30,
+		"accounts": ["/account/12345"]
+	}`, string(blob))
+
+	blob, err = json.Marshal(Problem{Title: "Not Found"})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"title":"Not Found"}`, string(blob))
+}
+
+func TestProblem_MarshalXML(t *testing.T) {
+	problem := Problem{
+		Title:      "You do not have enough credit.",
+		Status:     http.StatusForbidden,
+		Detail:     "Your current balance is 30.",
+		Extensions: Map{"balance": 30, "account": "/account/12345"},
+	}
+
+	blob, err := xml.Marshal(problem)
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		`<problem xmlns="urn:ietf:rfc:7807"><title>You do not have enough credit.</title><status>403</status>`+
+			`<detail>Your current balance is 30.</detail><account>/account/12345</account><balance>30</balance></problem>`,
+		string(blob),
+	)
+
+	_, err = xml.Marshal(Problem{Extensions: Map{"invalid": map[string]any{}}})
+	assert.Error(t, err)
+}
+
+func TestHTTPError_problem(t *testing.T) {
+	k := New()
+	c := k.NewContext(httptest.NewRequest(http.MethodGet, "/users/1?q=1", nil), httptest.NewRecorder())
+
+	problem := NewHTTPError(http.StatusNotFound, "").problem(c)
+	assert.Equal(t, Problem{Status: http.StatusNotFound, Title: "Not Found", Instance: "/users/1"}, problem)
+
+	problem = NewHTTPError(http.StatusNotFound, "user not found").WithCode("user_not_found").WithCause(errors.New("no rows")).problem(c)
+	assert.Equal(t, Problem{
+		Status:     http.StatusNotFound,
+		Title:      "Not Found",
+		Detail:     "user not found",
+		Instance:   "/users/1",
+		Extensions: Map{"code": "user_not_found"},
+	}, problem)
+}
@@ -33,6 +33,12 @@ type (
 		written bool
 		status  int
 		size    int
+
+		// discardBody discards response body, e.g. when a HEAD request is served by a GET handler.
+		discardBody bool
+
+		// beforeWriteHeader are the hooks which are called just before the status code is written.
+		beforeWriteHeader []func()
 	}
 )
 
@@ -64,6 +70,18 @@ func (r *response) WriteHeaderNow() {
 		return
 	}
 
+	// Hooks are removed before being called, so they are called only once even if they write to the response.
+	hooks := r.beforeWriteHeader
+	r.beforeWriteHeader = nil
+
+	for _, hook := range hooks {
+		hook()
+	}
+
+	if r.Written() {
+		return
+	}
+
 	r.written = true
 	r.ResponseWriter.WriteHeader(r.status)
 }
@@ -72,6 +90,10 @@ func (r *response) WriteHeaderNow() {
 func (r *response) Write(b []byte) (int, error) {
 	r.WriteHeaderNow()
 
+	if r.discardBody {
+		return len(b), nil
+	}
+
 	n, err := r.ResponseWriter.Write(b)
 	r.size += n
 
@@ -110,5 +132,11 @@ func (r *response) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 // No writes are permitted.
 func (r response) clone() *response {
 	r.ResponseWriter = nil
+	r.beforeWriteHeader = nil
 	return &r
 }
+
+// onBeforeWriteHeader registers a hook which is called just before the status code is written.
+func (r *response) onBeforeWriteHeader(hook func()) {
+	r.beforeWriteHeader = append(r.beforeWriteHeader, hook)
+}
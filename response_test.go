@@ -120,3 +120,55 @@ func TestResponse_clone(t *testing.T) {
 	assert.Equal(t, res.written, clonedRes.written)
 	assert.Equal(t, res.status, clonedRes.status)
 }
+
+func TestResponseWriter_Write_DiscardBody(t *testing.T) {
+	w := httptest.NewRecorder()
+	res := newResponse(w).(*response)
+	res.discardBody = true
+
+	n, err := res.Write([]byte("body"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4, n)
+	assert.True(t, res.Written())
+	assert.Zero(t, res.Size())
+	assert.Empty(t, w.Body.String())
+}
+
+func TestResponseWriter_WriteHeaderNow_BeforeWriteHeader(t *testing.T) {
+	w := httptest.NewRecorder()
+	res := newResponse(w).(*response)
+
+	var calls []string
+
+	res.onBeforeWriteHeader(func() {
+		calls = append(calls, "first")
+		res.Header().Set("X-Hook", "1")
+	})
+	res.onBeforeWriteHeader(func() {
+		calls = append(calls, "second")
+	})
+
+	res.WriteHeader(http.StatusCreated)
+	res.WriteHeaderNow()
+	res.WriteHeaderNow()
+
+	assert.Equal(t, []string{"first", "second"}, calls)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "1", w.Header().Get("X-Hook"))
+	assert.Nil(t, res.clone().beforeWriteHeader)
+
+	// Hooks can write the response themselves.
+	w = httptest.NewRecorder()
+	res = newResponse(w).(*response)
+
+	res.onBeforeWriteHeader(func() {
+		res.WriteHeader(http.StatusInternalServerError)
+		_, _ = res.Write([]byte("error"))
+	})
+
+	res.WriteHeaderNow()
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "error", w.Body.String())
+}
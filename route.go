@@ -0,0 +1,328 @@
+package kid
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Reverse routing errors.
+var (
+	// ErrRouteNotFound is returned when no route is registered with the given name.
+	ErrRouteNotFound = errors.New("route not found")
+
+	// ErrMissingParam is returned when a path parameter is missing while building a URL.
+	ErrMissingParam = errors.New("missing path parameter")
+
+	// ErrInvalidParam is returned when a path parameter doesn't satisfy its constraint while building a URL.
+	ErrInvalidParam = errors.New("invalid path parameter")
+
+	// ErrOddParams is returned when path parameters are not given in key-value pairs.
+	ErrOddParams = errors.New("path parameters must be key-value pairs")
+)
+
+// Route is a registered route.
+//
+// It's returned when a route is registered and can be used to name the route.
+type Route struct {
+	kid     *Kid
+	node    *Node
+	path    string
+	methods []string
+}
+
+// routeOptions are the options which are configured per route.
+type routeOptions struct {
+	// multipartLimits overrides Kid's multipart limits if it's not nil.
+	multipartLimits *MultipartLimits
+}
+
+// RouteInfo describes a registered route for a single HTTP method.
+type RouteInfo struct {
+	// Method is the HTTP method.
+	Method string
+
+	// Host is the route's host pattern. It's empty if the route is not scoped to a host.
+	Host string
+
+	// Path is the route's path pattern, e.g. /users/{id}.
+	Path string
+
+	// Name is the route's name. It's empty if the route is not named.
+	Name string
+
+	// Handler is the name of the route's handler function.
+	Handler string
+
+	// Middlewares is the number of route middlewares. Global middlewares are not included.
+	Middlewares int
+}
+
+// newRoute returns a new route.
+func newRoute(k *Kid, node *Node, path string, methods []string) *Route {
+	return &Route{kid: k, node: node, path: path, methods: methods}
+}
+
+// Name sets a name for the route so its URL can be generated by name.
+//
+// Panics if the name is empty or it's already taken by another route.
+func (r *Route) Name(name string) *Route {
+	if name == "" {
+		panic("route name cannot be empty")
+	}
+
+	if path, ok := r.kid.namedRoutes[name]; ok && path != r.path {
+		panic(fmt.Sprintf("route name %q is already registered for path %s", name, path))
+	}
+
+	for _, method := range r.methods {
+		hm := r.node.handlerMap[method]
+		hm.alias = name
+		r.node.handlerMap[method] = hm
+	}
+
+	r.kid.namedRoutes[name] = r.path
+
+	return r
+}
+
+// MultipartLimits sets the limits of parsing the route's multipart forms.
+// They override the limits configured with WithMultipartLimits.
+//
+// Panics if any of the limits is negative.
+func (r *Route) MultipartLimits(limits MultipartLimits) *Route {
+	limits.validate()
+
+	for _, method := range r.methods {
+		r.node.handlerMap[method].options.multipartLimits = &limits
+	}
+
+	return r
+}
+
+// Path returns the route's path.
+func (r *Route) Path() string {
+	return r.path
+}
+
+// Routes returns all of the registered routes, sorted by host, path and method.
+func (k *Kid) Routes() []RouteInfo {
+	routes := make([]RouteInfo, 0)
+
+	routes = appendRoutes(routes, "", k.router)
+	for _, host := range k.hosts {
+		routes = appendRoutes(routes, host.pattern, host.router)
+	}
+
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Host != routes[j].Host {
+			return routes[i].Host < routes[j].Host
+		}
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return slices.Index(allMethods, routes[i].Method) < slices.Index(allMethods, routes[j].Method)
+	})
+
+	return routes
+}
+
+// appendRoutes appends the routes of the given router to routes.
+//
+// Routes of mounted Kid apps are reported with the mount prefix.
+func appendRoutes(routes []RouteInfo, host string, router Tree) []RouteInfo {
+	router.root.walk(func(n *Node) {
+		for method, hm := range n.handlerMap {
+			// Expansions of routes with optional path parameters are reported by the original route.
+			if hm.alternate {
+				continue
+			}
+
+			if sub, ok := hm.mount.(*Kid); ok {
+				routes = appendMountedRoutes(routes, host, hm, sub)
+				// A mount point is registered for all of the methods, but mounted routes should only be reported once.
+				break
+			}
+
+			handler := funcName(hm.handler)
+			if hm.mount != nil {
+				handler = fmt.Sprintf("%T", hm.mount)
+			}
+
+			routes = append(routes, RouteInfo{
+				Method:      method,
+				Host:        host,
+				Path:        hm.name,
+				Name:        hm.alias,
+				Handler:     handler,
+				Middlewares: len(hm.middlewares),
+			})
+		}
+	})
+
+	return routes
+}
+
+// appendMountedRoutes appends the routes of the mounted Kid app to routes.
+func appendMountedRoutes(routes []RouteInfo, host string, hm handlerMiddleware, sub *Kid) []RouteInfo {
+	prefix := strings.TrimSuffix(hm.name, "/"+starParamPrefix+mountPathParam+paramSuffix)
+
+	for _, route := range sub.Routes() {
+		route.Path = prefix + route.Path
+		route.Middlewares += len(hm.middlewares)
+		if route.Host == "" {
+			route.Host = host
+		}
+
+		routes = append(routes, route)
+	}
+
+	return routes
+}
+
+// printRoutes prints the route table, only in debug mode.
+func (k *Kid) printRoutes(w io.Writer) {
+	routes := k.Routes()
+
+	var pathWidth int
+	for _, route := range routes {
+		pathWidth = max(pathWidth, len(route.Host+route.Path))
+	}
+
+	for _, route := range routes {
+		name := ""
+		if route.Name != "" {
+			name = fmt.Sprintf(" (%s)", route.Name)
+		}
+
+		k.printDebug(
+			w, "%-7s %-*s --> %s%s, %d middlewares\n",
+			route.Method, pathWidth, route.Host+route.Path, route.Handler, name, route.Middlewares,
+		)
+	}
+}
+
+// URL builds the URL of the route with the given name.
+//
+// Path parameters must be given in key-value pairs, e.g. URL("user", "id", "1").
+// Optional path parameters can be omitted from the end of the path, and an ErrMissingParam is returned
+// if an optional path parameter is omitted while a later one is given.
+func (k *Kid) URL(name string, params ...string) (string, error) {
+	path, ok := k.namedRoutes[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrRouteNotFound, name)
+	}
+
+	if len(params)%2 != 0 {
+		return "", ErrOddParams
+	}
+
+	values := make(Params, len(params)/2)
+	for i := 0; i < len(params); i += 2 {
+		values[params[i]] = params[i+1]
+	}
+
+	return buildPath(path, values)
+}
+
+// urlFor is the template function version of Kid.URL.
+//
+// Parameter values are converted to strings with fmt.Sprint.
+func (k *Kid) urlFor(name string, params ...any) (string, error) {
+	strParams := make([]string, len(params))
+	for i, param := range params {
+		strParams[i] = fmt.Sprint(param)
+	}
+
+	return k.URL(name, strParams...)
+}
+
+// buildPath fills the path parameters of the given path with the given values.
+func buildPath(path string, values Params) (string, error) {
+	segments := strings.Split(path, "/")[1:]
+
+	var sb strings.Builder
+
+	for i, segment := range segments {
+		sb.WriteByte('/')
+
+		for _, part := range splitSegment(segment) {
+			if !isParam(part) {
+				sb.WriteString(part)
+				continue
+			}
+
+			star := isStar(part)
+
+			node := Node{isParam: true, isStar: star}
+			node.setLabel(part)
+
+			value, ok := values[node.label]
+
+			// Optional path parameters are the last segments, so the rest of the path is omitted,
+			// unless a value is given for a later one which can't be omitted with it.
+			if node.optional && value == "" {
+				if given := givenParam(segments[i+1:], values); given != "" {
+					return "", fmt.Errorf("%w: %s is required since %s is given", ErrMissingParam, node.label, given)
+				}
+				return cleanPath(strings.TrimSuffix(sb.String(), "/"), false), nil
+			}
+
+			if !ok || (value == "" && !star) {
+				return "", fmt.Errorf("%w: %s", ErrMissingParam, node.label)
+			}
+
+			if !node.matchesConstraint(value) {
+				return "", fmt.Errorf("%w: %s must match %s", ErrInvalidParam, node.label, node.constraint)
+			}
+
+			if star {
+				sb.WriteString(escapeStarParam(value))
+			} else {
+				sb.WriteString(url.PathEscape(value))
+			}
+		}
+	}
+
+	return sb.String(), nil
+}
+
+// givenParam returns the name of the first path parameter of the given segments which has a value, or an empty string.
+func givenParam(segments []string, values Params) string {
+	for _, segment := range segments {
+		for _, part := range splitSegment(segment) {
+			if !isParam(part) {
+				continue
+			}
+
+			node := Node{isParam: true, isStar: isStar(part)}
+			node.setLabel(part)
+
+			if values[node.label] != "" {
+				return node.label
+			}
+		}
+	}
+
+	return ""
+}
+
+// escapeStarParam escapes each part of a star path parameter and keeps the slashes.
+func escapeStarParam(value string) string {
+	parts := strings.Split(value, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
+
+// funcName returns the name of the given function.
+func funcName(f any) string {
+	return runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+}
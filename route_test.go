@@ -0,0 +1,169 @@
+package kid
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	htmlrenderer "github.com/mojixcoder/kid/html_renderer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoute_Name(t *testing.T) {
+	k := New()
+
+	route := k.Add("/users/{id}", testHandlerFunc, []string{http.MethodGet, http.MethodPut})
+
+	assert.Equal(t, "/users/{id}", route.Path())
+	assert.Equal(t, route, route.Name("user"))
+	assert.Equal(t, "/users/{id}", k.namedRoutes["user"])
+
+	hm, err := k.router.search("/users/1", http.MethodGet, make(Params))
+	assert.NoError(t, err)
+	assert.Equal(t, "user", hm.alias)
+
+	hm, err = k.router.search("/users/1", http.MethodPut, make(Params))
+	assert.NoError(t, err)
+	assert.Equal(t, "user", hm.alias)
+
+	assert.PanicsWithValue(t, "route name cannot be empty", func() {
+		route.Name("")
+	})
+
+	assert.PanicsWithValue(t, "route name \"user\" is already registered for path /users/{id}", func() {
+		k.Get("/users", testHandlerFunc).Name("user")
+	})
+
+	g := k.Group("/v1")
+	g.Get("/posts/{id}", testHandlerFunc).Name("post")
+	assert.Equal(t, "/v1/posts/{id}", k.namedRoutes["post"])
+}
+
+func TestKid_URL(t *testing.T) {
+	k := New()
+
+	k.Get("/", testHandlerFunc).Name("index")
+	k.Get("/users/{id}/posts/{postID}", testHandlerFunc).Name("post")
+	k.Get("/files/{*filePath}", testHandlerFunc).Name("files")
+	k.Get("/articles/{id:int}", testHandlerFunc).Name("article")
+	k.Get("/downloads/{name}.{ext:[a-z]+}", testHandlerFunc).Name("download")
+	k.Get("/reports/{year?}/{page=1}", testHandlerFunc).Name("reports")
+
+	testCases := []struct {
+		name        string
+		route       string
+		params      []string
+		expectedURL string
+		expectedErr error
+	}{
+		{name: "static", route: "index", expectedURL: "/"},
+		{name: "params", route: "post", params: []string{"id", "1", "postID", "2"}, expectedURL: "/users/1/posts/2"},
+		{name: "escaping", route: "post", params: []string{"id", "a b/c", "postID", "?"}, expectedURL: "/users/a%20b%2Fc/posts/%3F"},
+		{name: "star", route: "files", params: []string{"filePath", "static/a b.css"}, expectedURL: "/files/static/a%20b.css"},
+		{name: "empty_star", route: "files", params: []string{"filePath", ""}, expectedURL: "/files/"},
+		{name: "missing_param", route: "post", params: []string{"id", "1"}, expectedErr: ErrMissingParam},
+		{name: "empty_param", route: "post", params: []string{"id", "1", "postID", ""}, expectedErr: ErrMissingParam},
+		{name: "constraint", route: "article", params: []string{"id", "12"}, expectedURL: "/articles/12"},
+		{name: "invalid_constraint", route: "article", params: []string{"id", "abc"}, expectedErr: ErrInvalidParam},
+		{name: "mixed_segment", route: "download", params: []string{"name", "a b", "ext", "pdf"}, expectedURL: "/downloads/a%20b.pdf"},
+		{name: "mixed_segment_constraint", route: "download", params: []string{"name", "a", "ext", "PDF"}, expectedErr: ErrInvalidParam},
+		{name: "optional", route: "reports", params: []string{"year", "2024", "page", "2"}, expectedURL: "/reports/2024/2"},
+		{name: "omitted_optional", route: "reports", params: []string{"year", "2024"}, expectedURL: "/reports/2024"},
+		{name: "omitted_optionals", route: "reports", expectedURL: "/reports"},
+		{name: "omitted_earlier_optional", route: "reports", params: []string{"page", "3"}, expectedErr: ErrMissingParam},
+		{name: "empty_earlier_optional", route: "reports", params: []string{"year", "", "page", "3"}, expectedErr: ErrMissingParam},
+		{name: "missing_star", route: "files", expectedErr: ErrMissingParam},
+		{name: "odd_params", route: "post", params: []string{"id"}, expectedErr: ErrOddParams},
+		{name: "not_found", route: "unknown", expectedErr: ErrRouteNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			url, err := k.URL(tc.route, tc.params...)
+
+			assert.ErrorIs(t, err, tc.expectedErr)
+			assert.Equal(t, tc.expectedURL, url)
+		})
+	}
+}
+
+func TestContext_URLFor(t *testing.T) {
+	k := New()
+	k.Get("/users/{id}", testHandlerFunc).Name("user")
+
+	ctx := k.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+
+	url, err := ctx.URLFor("user", "id", "10")
+	assert.NoError(t, err)
+	assert.Equal(t, "/users/10", url)
+}
+
+func TestKid_urlFor_Template(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "link.html"), []byte(`{{urlfor "user" "id" .}}`), 0o644)
+	assert.NoError(t, err)
+
+	htmlrenderer.DefaultRootDir = dir + string(filepath.Separator)
+	defer func() {
+		htmlrenderer.DefaultRootDir = filepath.FromSlash("templates/")
+	}()
+
+	k := New()
+	k.Get("/users/{id}", testHandlerFunc).Name("user")
+
+	res := httptest.NewRecorder()
+	ctx := k.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), res)
+
+	ctx.HTML(http.StatusOK, "link.html", 12)
+
+	assert.Equal(t, "/users/12", res.Body.String())
+}
+
+func TestKid_Routes(t *testing.T) {
+	k := New()
+
+	assert.Empty(t, k.Routes())
+
+	k.Get("/users/{id}", testHandlerFunc, testMiddlewareFunc).Name("user")
+	k.Add("/users", testHandlerFunc, []string{http.MethodPost, http.MethodGet})
+	k.Get("/", routesTestHandler)
+	k.Get("/reports/{year?}", testHandlerFunc)
+
+	expected := []RouteInfo{
+		{Method: http.MethodGet, Path: "/", Handler: "github.com/mojixcoder/kid.routesTestHandler"},
+		{Method: http.MethodGet, Path: "/reports/{year?}", Handler: funcName(testHandlerFunc)},
+		{Method: http.MethodGet, Path: "/users", Handler: funcName(testHandlerFunc)},
+		{Method: http.MethodPost, Path: "/users", Handler: funcName(testHandlerFunc)},
+		{Method: http.MethodGet, Path: "/users/{id}", Name: "user", Handler: funcName(testHandlerFunc), Middlewares: 1},
+	}
+
+	assert.Equal(t, expected, k.Routes())
+}
+
+// routesTestHandler is a named handler used for testing route introspection.
+func routesTestHandler(c *Context) {}
+
+func TestKid_printRoutes(t *testing.T) {
+	k := New()
+
+	k.Get("/users/{id}", routesTestHandler, testMiddlewareFunc).Name("user")
+	k.Post("/users", routesTestHandler)
+
+	var w bytes.Buffer
+
+	k.printRoutes(&w)
+
+	expected := "[DEBUG] POST    /users      --> github.com/mojixcoder/kid.routesTestHandler, 0 middlewares\n" +
+		"[DEBUG] GET     /users/{id} --> github.com/mojixcoder/kid.routesTestHandler (user), 1 middlewares\n"
+
+	assert.Equal(t, expected, w.String())
+
+	w.Reset()
+	k.debug = false
+
+	k.printRoutes(&w)
+	assert.Empty(t, w.String())
+}
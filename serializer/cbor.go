@@ -0,0 +1,70 @@
+package serializer
+
+import (
+	"net/http"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// defaultCBORSerializer is the default CBOR serializer used in Kid.
+type defaultCBORSerializer struct {
+}
+
+// Verifying interface compliance.
+var _ Serializer = defaultCBORSerializer{}
+
+var (
+	// cborEncMode uses the preferred serialization of RFC 8949, so the output is deterministic,
+	// and writes time.Time values as RFC 3339 strings with tag 0.
+	cborEncMode = mustCBORMode(cbor.EncOptions{
+		Sort:          cbor.SortBytewiseLexical,
+		ShortestFloat: cbor.ShortestFloat16,
+		Time:          cbor.TimeRFC3339Nano,
+		TimeTag:       cbor.EncTagRequired,
+	}.EncMode())
+
+	// cborDecMode limits the nesting depth like the MessagePack serializer.
+	cborDecMode = mustCBORMode(cbor.DecOptions{
+		MaxNestedLevels: maxDepth,
+	}.DecMode())
+)
+
+// NewCBORSerializer returns a new CBOR serializer, which is implemented with github.com/fxamacker/cbor.
+//
+// Struct fields are named by their cbor tags, falling back to their json tags and names. Map keys and struct fields
+// are sorted by their encoded bytes. The indent is ignored, since CBOR is a binary format.
+// time.Time values are written as RFC 3339 strings with tag 0, and both tag 0 and tag 1 epoch times are read.
+//
+// Interface values are read as the cbor package does, e.g. unsigned integers as uint64, maps as map[any]any
+// and unknown tags as cbor.Tag. Reading is limited to 131072 elements per array or map, and errors are
+// the ones of the cbor package.
+func NewCBORSerializer() Serializer {
+	return defaultCBORSerializer{}
+}
+
+// Write writes the given object as CBOR to response.
+func (s defaultCBORSerializer) Write(w http.ResponseWriter, in any, indent string) {
+	b, err := cborEncMode.Marshal(in)
+	if err != nil {
+		panic(err)
+	}
+
+	if _, err := w.Write(b); err != nil {
+		panic(err)
+	}
+}
+
+// Read reads request's body as CBOR and puts it in the given obj.
+func (s defaultCBORSerializer) Read(req *http.Request, out any) error {
+	mustBePointer(out)
+
+	return cborDecMode.NewDecoder(req.Body).Decode(out)
+}
+
+// mustCBORMode panics if the mode can't be created, which only happens if its options are invalid.
+func mustCBORMode[T any](mode T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return mode
+}
@@ -0,0 +1,220 @@
+package serializer
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCBORSerializer(t *testing.T) {
+	serializer := NewCBORSerializer()
+
+	assert.NotNil(t, serializer)
+	assert.IsType(t, defaultCBORSerializer{}, serializer)
+}
+
+func TestDefaultCBORSerializer_Write(t *testing.T) {
+	serializer := defaultCBORSerializer{}
+
+	// Examples of RFC 8949, appendix A.
+	testCases := []struct {
+		name     string
+		in       any
+		expected string
+	}{
+		{name: "person", in: person{Name: "Mojix", Age: 22}, expected: "a2 63616765 16 646e616d65 654d6f6a6978"},
+		{name: "uints", in: []uint64{0, 23, 24, 100, 1000, 1000000, 1000000000000}, expected: "87 00 17 1818 1864 1903e8 1a000f4240 1b000000e8d4a51000"},
+		{name: "negative_ints", in: []int{-1, -10, -100, -1000}, expected: "84 20 29 3863 3903e7"},
+		{name: "min_int64", in: math.MinInt64, expected: "3b7fffffffffffffff"},
+		{name: "floats", in: []any{1.1, 1.5, float32(100000), 65504.0}, expected: "84 fb3ff199999999999a f93e00 fa47c35000 f97bff"},
+		{name: "simple", in: []any{false, true, nil}, expected: "83 f4 f5 f6"},
+		{name: "string", in: "IETF", expected: "6449455446"},
+		{name: "bytes", in: []byte{1, 2, 3, 4}, expected: "4401020304"},
+		{name: "nested", in: []any{1, []int{2, 3}, []int{4, 5}}, expected: "83 01 820203 820405"},
+		{name: "map", in: map[string]any{"b": []int{2, 3}, "a": 1}, expected: "a2 6161 01 6162 820203"},
+		{name: "time", in: time.Date(2013, 3, 21, 20, 4, 0, 0, time.UTC), expected: "c0 74 323031332d30332d32315432303a30343a30305a"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := httptest.NewRecorder()
+
+			serializer.Write(res, tc.in, "")
+			assert.Equal(t, hexBytes(t, tc.expected), res.Body.Bytes())
+		})
+	}
+
+	// Channel type cannot be converted to CBOR.
+	assert.Panics(t, func() {
+		serializer.Write(httptest.NewRecorder(), []any{make(chan bool)}, "")
+	})
+}
+
+func TestDefaultCBORSerializer_Read(t *testing.T) {
+	serializer := defaultCBORSerializer{}
+
+	res := httptest.NewRecorder()
+	in := newPayload()
+	serializer.Write(res, in, "")
+
+	req := httptest.NewRequest(http.MethodPost, "/", res.Body)
+
+	var out payload
+	err := serializer.Read(req, &out)
+	assert.NoError(t, err)
+
+	// Interface values are read with the types of the cbor package.
+	in.Extra = map[any]any{"list": []any{uint64(1), "two", 3.5, nil, true}}
+
+	in.Skipped, in.private = "", ""
+	assert.Equal(t, in, out)
+
+	var p person
+
+	// Invalid argument passed to unmarshal.
+	assert.Panics(t, func() {
+		serializer.Read(httptest.NewRequest(http.MethodPost, "/", nil), p)
+	})
+
+	err = serializer.Read(httptest.NewRequest(http.MethodPost, "/", nil), &p)
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestDefaultCBORSerializer_Read_Items(t *testing.T) {
+	serializer := defaultCBORSerializer{}
+
+	// Examples of RFC 8949, appendix A.
+	testCases := []struct {
+		name     string
+		input    string
+		expected any
+	}{
+		{name: "half_floats", input: "86 f93c00 f97bff f90001 f9c400 f97c00 f9fc00", expected: []any{1.0, 65504.0, 5.960464477539063e-8, -4.0, math.Inf(1), math.Inf(-1)}},
+		{name: "undefined", input: "f7", expected: nil},
+		{name: "indefinite_array", input: "9f 01 820203 9f0405ff ff", expected: []any{uint64(1), []any{uint64(2), uint64(3)}, []any{uint64(4), uint64(5)}}},
+		{name: "indefinite_map", input: "bf 6161 01 6162 9f0203ff ff", expected: map[any]any{"a": uint64(1), "b": []any{uint64(2), uint64(3)}}},
+		{name: "indefinite_bytes", input: "5f 420102 43030405 ff", expected: []byte{1, 2, 3, 4, 5}},
+		{name: "indefinite_string", input: "7f 657374726561 646d696e67 ff", expected: "streaming"},
+		{name: "time", input: "c0 74 323031332d30332d32315432303a30343a30305a", expected: time.Date(2013, 3, 21, 20, 4, 0, 0, time.UTC)},
+		{name: "epoch_time", input: "c1 1a514b67b0", expected: time.Unix(1363896240, 0)},
+		{name: "epoch_time_float", input: "c1 fb41d452d9ec200000", expected: time.Unix(1363896240, 5e8)},
+		{name: "negative_epoch_time", input: "c1 20", expected: time.Unix(-1, 0)},
+		{name: "unknown_tag", input: "d8 20 6161", expected: cbor.Tag{Number: 32, Content: "a"}},
+		{name: "large_negative", input: "3b 7fffffffffffffff", expected: int64(math.MinInt64)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(hexBytes(t, tc.input)))
+
+			var out any
+			err := serializer.Read(req, &out)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, out)
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(hexBytes(t, "f9 7e00")))
+
+	var f float64
+	assert.NoError(t, serializer.Read(req, &f))
+	assert.True(t, math.IsNaN(f))
+
+	// Indefinite length items are read into structs, slices and arrays too.
+	req = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(hexBytes(t, "bf 646e616d65 7f626d6f 636a6978ff 63616765 16 ff")))
+
+	var p person
+	assert.NoError(t, serializer.Read(req, &p))
+	assert.Equal(t, person{Name: "mojix", Age: 22}, p)
+
+	req = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(hexBytes(t, "9f 01 02 03 ff")))
+
+	var a [2]int
+	assert.NoError(t, serializer.Read(req, &a))
+	assert.Equal(t, [2]int{1, 2}, a)
+}
+
+func TestDefaultCBORSerializer_Read_Errors(t *testing.T) {
+	serializer := defaultCBORSerializer{}
+
+	testCases := []struct {
+		name  string
+		input string
+		out   any
+		err   error
+	}{
+		{name: "truncated", input: "a2 646e616d65", out: &person{}, err: io.ErrUnexpectedEOF},
+		{name: "truncated_indefinite", input: "9f 01", out: new([]int), err: io.ErrUnexpectedEOF},
+		{name: "huge_length", input: "5a ffffffff", out: new([]byte), err: io.ErrUnexpectedEOF},
+		{name: "too_large", input: "9b 0000000100000000", out: new([]int)},
+		{name: "reserved_info", input: "1c", out: new(any)},
+		{name: "negative_overflow", input: "3b ffffffffffffffff", out: new(int64)},
+		{name: "invalid_indefinite", input: "1f", out: new(any)},
+		{name: "invalid_chunk", input: "5f 6161 ff", out: new([]byte)},
+		{name: "unexpected_break", input: "ff", out: new(any)},
+		{name: "unexpected_break_value", input: "bf 6161 ff", out: new(map[string]int)},
+		{name: "invalid_time_string", input: "c0 01", out: new(time.Time)},
+		{name: "invalid_time", input: "c0 6161", out: new(time.Time)},
+		{name: "invalid_epoch", input: "c1 6161", out: new(time.Time)},
+		{name: "type_mismatch", input: "f5", out: new(string), err: &cbor.UnmarshalTypeError{}},
+		{name: "time_mismatch", input: "f5", out: new(time.Time), err: &cbor.UnmarshalTypeError{}},
+		{name: "depth", input: strings.Repeat("81", maxDepth+1) + "01", out: new(any), err: &cbor.MaxNestedLevelError{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(hexBytes(t, tc.input)))
+
+			err := serializer.Read(req, tc.out)
+			assert.Error(t, err)
+
+			switch target := tc.err.(type) {
+			case nil:
+			case *cbor.UnmarshalTypeError:
+				assert.ErrorAs(t, err, &target)
+			case *cbor.MaxNestedLevelError:
+				assert.ErrorAs(t, err, &target)
+			default:
+				assert.ErrorIs(t, err, target)
+			}
+		})
+	}
+}
+
+func FuzzCBORRead(f *testing.F) {
+	serializer := defaultCBORSerializer{}
+
+	res := httptest.NewRecorder()
+	serializer.Write(res, newPayload(), "")
+	f.Add(res.Body.Bytes())
+
+	for _, seed := range []string{
+		"bf 646e616d65 7f626d6f 636a6978ff 63616765 16 ff",
+		"86 f93c00 f97bff f90001 f9c400 f97c00 f9fc00",
+		"c1 fb41d452d9ec200000",
+		"d8 20 6161",
+		"9b 0000000100000000",
+	} {
+		f.Add(hexBytes(f, seed))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var out any
+		if err := serializer.Read(httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(data)), &out); err == nil {
+			// Values which are read can be written back.
+			serializer.Write(httptest.NewRecorder(), out, "")
+		}
+
+		var p payload
+		_ = serializer.Read(httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(data)), &p)
+	})
+}
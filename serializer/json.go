@@ -2,21 +2,118 @@ package serializer
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
-// defaultJSONSerializer is the default Kid's JSON serializer.
-type defaultJSONSerializer struct {
-}
+// unknownFieldErrorPrefix is the prefix of the messages of encoding/json's unknown field errors.
+const unknownFieldErrorPrefix = "json: unknown field "
+
+type (
+	// defaultJSONSerializer is the default Kid's JSON serializer.
+	defaultJSONSerializer struct {
+	}
+
+	// configuredJSONSerializer is the JSON serializer which is created with NewJSONSerializerWithConfig.
+	configuredJSONSerializer struct {
+		defaultJSONSerializer
+
+		config JSONConfig
+	}
+
+	// JSONConfig is the config of JSON serializers which are created with NewJSONSerializerWithConfig.
+	JSONConfig struct {
+		// DisallowUnknownFields makes reading fail with an UnknownFieldError if the body has a field
+		// which doesn't match any non-ignored, exported field of the destination struct.
+		DisallowUnknownFields bool
+
+		// UseNumber makes numbers be read into interface values as json.Number instead of float64.
+		UseNumber bool
+
+		// MaxBodySize is the maximum size of the request body in bytes, which is enforced with http.MaxBytesReader.
+		// Reading larger bodies fails with a BodyTooLargeError. Zero means no limit.
+		MaxBodySize int64
+
+		// DisallowTrailingData makes reading fail with a SyntaxError if the body has data after the JSON value.
+		DisallowTrailingData bool
+	}
+
+	// SyntaxError is returned when the request body is not valid.
+	SyntaxError struct {
+		// Offset is the number of bytes read before the error happened.
+		Offset int64
+
+		// Msg describes the error.
+		Msg string
+	}
+
+	// UnknownFieldError is returned when the request body has a field which is not in the destination struct.
+	UnknownFieldError struct {
+		Field string
+	}
+
+	// BodyTooLargeError is returned when the request body is larger than the maximum size.
+	BodyTooLargeError struct {
+		Limit int64
+	}
+
+	// countingReader counts the number of read bytes.
+	countingReader struct {
+		r io.Reader
+		n int64
+	}
+)
 
 // Verifying interface compliance.
-var _ Serializer = defaultJSONSerializer{}
+var (
+	_ Serializer = defaultJSONSerializer{}
+	_ Serializer = configuredJSONSerializer{}
+)
 
 // NewJSONSerializer returns a new JSON serializer.
 func NewJSONSerializer() Serializer {
 	return defaultJSONSerializer{}
 }
 
+// NewJSONSerializerWithConfig returns a new JSON serializer with the given config.
+//
+// Unlike the default JSON serializer, reading errors are returned as a *SyntaxError, *UnknownFieldError,
+// *BodyTooLargeError or *TypeMismatchError when possible, so they can be responded with precise status codes.
+// An empty body returns io.EOF. Panics if the max body size is negative.
+func NewJSONSerializerWithConfig(config JSONConfig) Serializer {
+	if config.MaxBodySize < 0 {
+		panic("max body size cannot be negative")
+	}
+
+	return configuredJSONSerializer{config: config}
+}
+
+// Error implements the error interface.
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("serializer: syntax error at offset %d: %s", e.Offset, e.Msg)
+}
+
+// Error implements the error interface.
+func (e *UnknownFieldError) Error() string {
+	return fmt.Sprintf("serializer: unknown field %q", e.Field)
+}
+
+// Error implements the error interface.
+func (e *BodyTooLargeError) Error() string {
+	return fmt.Sprintf("serializer: request body is larger than %d bytes", e.Limit)
+}
+
+// Read implements the io.Reader interface.
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
 // Marshal writes the given object as JSON to response.
 func (s defaultJSONSerializer) Write(w http.ResponseWriter, in any, indent string) {
 	encoder := json.NewEncoder(w)
@@ -37,3 +134,71 @@ func (s defaultJSONSerializer) Read(req *http.Request, out any) error {
 	}
 	return nil
 }
+
+// Read reads request's body as JSON with the serializer's config and puts it in the given obj.
+func (s configuredJSONSerializer) Read(req *http.Request, out any) error {
+	body := req.Body
+	if s.config.MaxBodySize > 0 {
+		body = http.MaxBytesReader(nil, body, s.config.MaxBodySize)
+	}
+
+	reader := &countingReader{r: body}
+
+	decoder := json.NewDecoder(reader)
+	if s.config.DisallowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+	if s.config.UseNumber {
+		decoder.UseNumber()
+	}
+
+	if err := decoder.Decode(out); err != nil {
+		if _, ok := err.(*json.InvalidUnmarshalError); ok {
+			panic(err)
+		}
+		return jsonError(err, reader.n)
+	}
+
+	if s.config.DisallowTrailingData {
+		offset := decoder.InputOffset()
+
+		if _, err := decoder.Token(); err != io.EOF {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				return &BodyTooLargeError{Limit: maxBytesErr.Limit}
+			}
+			return &SyntaxError{Offset: offset, Msg: "unexpected data after top-level value"}
+		}
+	}
+
+	return nil
+}
+
+// jsonError converts the errors of encoding/json to the typed errors, read is the number of bytes read from the body.
+func jsonError(err error, read int64) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var maxBytesErr *http.MaxBytesError
+
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return &BodyTooLargeError{Limit: maxBytesErr.Limit}
+	case errors.As(err, &syntaxErr):
+		return &SyntaxError{Offset: syntaxErr.Offset, Msg: syntaxErr.Error()}
+	case errors.As(err, &typeErr):
+		return &TypeMismatchError{Value: typeErr.Value, Type: typeErr.Type, Field: typeErr.Field}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return &SyntaxError{Offset: read, Msg: "unexpected end of JSON input"}
+	}
+
+	// encoding/json doesn't have a type for unknown field errors, so they are detected by their message,
+	// which is not covered by its compatibility promise. TestJSONError_UnknownFieldMessage fails if it changes.
+	if field, ok := strings.CutPrefix(err.Error(), unknownFieldErrorPrefix); ok {
+		if unquoted, err := strconv.Unquote(field); err == nil {
+			field = unquoted
+		}
+		return &UnknownFieldError{Field: field}
+	}
+
+	return err
+}
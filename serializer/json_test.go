@@ -1,12 +1,17 @@
 package serializer
 
 import (
+	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type person struct {
@@ -69,3 +74,110 @@ func TestDefaultJSONSerializer_Read(t *testing.T) {
 	err = serializer.Read(req, &p2)
 	assert.Error(t, err)
 }
+
+func TestNewJSONSerializerWithConfig(t *testing.T) {
+	config := JSONConfig{DisallowUnknownFields: true, MaxBodySize: 1024}
+	serializer := NewJSONSerializerWithConfig(config)
+
+	assert.Equal(t, configuredJSONSerializer{config: config}, serializer)
+
+	assert.PanicsWithValue(t, "max body size cannot be negative", func() {
+		NewJSONSerializerWithConfig(JSONConfig{MaxBodySize: -1})
+	})
+}
+
+func TestConfiguredJSONSerializer_Write(t *testing.T) {
+	res := httptest.NewRecorder()
+
+	serializer := NewJSONSerializerWithConfig(JSONConfig{})
+
+	serializer.Write(res, person{Name: "Mojix", Age: 22}, "")
+	assert.Equal(t, "{\"name\":\"Mojix\",\"age\":22}\n", res.Body.String())
+}
+
+func TestConfiguredJSONSerializer_Read(t *testing.T) {
+	serializer := NewJSONSerializerWithConfig(JSONConfig{UseNumber: true})
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"id": 12345678901234567890, "unknown": 1} {}`))
+
+	var out map[string]any
+	err := serializer.Read(req, &out)
+
+	// Unknown fields and trailing data are allowed by default.
+	assert.NoError(t, err)
+	assert.Equal(t, json.Number("12345678901234567890"), out["id"])
+
+	assert.Panics(t, func() {
+		serializer.Read(httptest.NewRequest(http.MethodPost, "/", strings.NewReader("{}")), out)
+	})
+
+	err = serializer.Read(httptest.NewRequest(http.MethodPost, "/", strings.NewReader("")), &out)
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestConfiguredJSONSerializer_Read_Errors(t *testing.T) {
+	config := JSONConfig{DisallowUnknownFields: true, MaxBodySize: 32, DisallowTrailingData: true}
+	serializer := NewJSONSerializerWithConfig(config)
+
+	testCases := []struct {
+		name     string
+		body     string
+		expected error
+	}{
+		{name: "syntax", body: `{"name": x}`, expected: &SyntaxError{Offset: 10, Msg: "invalid character 'x' looking for beginning of value"}},
+		{name: "truncated", body: `{"name": "Mojix"`, expected: &SyntaxError{Offset: 16, Msg: "unexpected end of JSON input"}},
+		{name: "unknown_field", body: `{"nickname": "Mojix"}`, expected: &UnknownFieldError{Field: "nickname"}},
+		{name: "too_large", body: `{"name": "` + strings.Repeat("a", 32) + `"}`, expected: &BodyTooLargeError{Limit: 32}},
+		{name: "trailing_too_large", body: `{"age": 1}` + strings.Repeat(" ", 32), expected: &BodyTooLargeError{Limit: 32}},
+		{name: "type_mismatch", body: `{"age": "22"}`, expected: &TypeMismatchError{Value: "string", Type: reflect.TypeOf(0), Field: "age"}},
+		{name: "trailing_data", body: `{"age": 22} {}`, expected: &SyntaxError{Offset: 11, Msg: "unexpected data after top-level value"}},
+		{name: "trailing_bracket", body: `{"age": 22}]`, expected: &SyntaxError{Offset: 11, Msg: "unexpected data after top-level value"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tc.body))
+
+			var p person
+			err := serializer.Read(req, &p)
+
+			assert.Equal(t, tc.expected, err)
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"age": 22} `))
+
+	var p person
+	assert.NoError(t, serializer.Read(req, &p))
+	assert.Equal(t, 22, p.Age)
+}
+
+func TestJSONErrors(t *testing.T) {
+	assert.Equal(t, "serializer: syntax error at offset 3: invalid character", (&SyntaxError{Offset: 3, Msg: "invalid character"}).Error())
+	assert.Equal(t, "serializer: unknown field \"name\"", (&UnknownFieldError{Field: "name"}).Error())
+	assert.Equal(t, "serializer: request body is larger than 10 bytes", (&BodyTooLargeError{Limit: 10}).Error())
+	assert.Equal(t, "serializer: cannot decode string into field age of type int", (&TypeMismatchError{Value: "string", Type: reflect.TypeOf(0), Field: "age"}).Error())
+	assert.Equal(t, "serializer: cannot decode string into Go value of type int", (&TypeMismatchError{Value: "string", Type: reflect.TypeOf(0)}).Error())
+
+	err := errors.New("other")
+	assert.Equal(t, err, jsonError(err, 0))
+}
+
+func TestJSONError_UnknownFieldMessage(t *testing.T) {
+	decoder := json.NewDecoder(strings.NewReader(`{"name": "Mojix", "nick\"name": "M"}`))
+	decoder.DisallowUnknownFields()
+
+	var p person
+	err := decoder.Decode(&p)
+	require.Error(t, err)
+
+	// Unknown field errors are detected by their message, since encoding/json doesn't have a type for them.
+	require.True(
+		t, strings.HasPrefix(err.Error(), unknownFieldErrorPrefix),
+		"the message of encoding/json's unknown field errors has changed: %q", err,
+	)
+
+	var unknownFieldErr *UnknownFieldError
+	require.ErrorAs(t, jsonError(err, 0), &unknownFieldErr)
+	assert.Equal(t, `nick"name`, unknownFieldErr.Field)
+}
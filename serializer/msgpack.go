@@ -0,0 +1,332 @@
+package serializer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"time"
+)
+
+// msgpackTimestampType is the extension type of timestamps, which is -1.
+const msgpackTimestampType byte = 0xff
+
+type (
+	// defaultMessagePackSerializer is the default MessagePack serializer used in Kid.
+	defaultMessagePackSerializer struct {
+	}
+
+	// msgpackWriter writes MessagePack tokens.
+	msgpackWriter struct {
+		bytes.Buffer
+	}
+
+	// msgpackReader reads MessagePack tokens.
+	msgpackReader struct {
+		r *bufio.Reader
+	}
+)
+
+// Verifying interface compliance.
+var _ Serializer = defaultMessagePackSerializer{}
+
+// NewMessagePackSerializer returns a new MessagePack serializer.
+//
+// Struct fields are named by their msgpack tags, falling back to their json tags and names.
+// The indent is ignored, since MessagePack is a binary format. time.Time values are written as timestamp extensions,
+// which is the only supported extension type. Writing and reading are limited to 1000 levels of nesting,
+// so writing self-referencing values panics with an error instead of overflowing the stack.
+func NewMessagePackSerializer() Serializer {
+	return defaultMessagePackSerializer{}
+}
+
+// Write writes the given object as MessagePack to response.
+func (s defaultMessagePackSerializer) Write(w http.ResponseWriter, in any, indent string) {
+	var buf msgpackWriter
+	e := encoder{w: &buf}
+	if err := e.encode(reflect.ValueOf(in)); err != nil {
+		panic(err)
+	}
+
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		panic(err)
+	}
+}
+
+// Read reads request's body as MessagePack and puts it in the given obj.
+func (s defaultMessagePackSerializer) Read(req *http.Request, out any) error {
+	return readBody(req.Body, out)
+}
+
+// writeNil writes nil.
+func (w *msgpackWriter) writeNil() {
+	w.WriteByte(0xc0)
+}
+
+// writeBool writes a boolean.
+func (w *msgpackWriter) writeBool(b bool) {
+	if b {
+		w.WriteByte(0xc3)
+	} else {
+		w.WriteByte(0xc2)
+	}
+}
+
+// writeInt writes a signed integer in the smallest format which can hold it.
+func (w *msgpackWriter) writeInt(i int64) {
+	switch {
+	case i >= 0:
+		w.writeUint(uint64(i))
+	case i >= -32:
+		w.WriteByte(byte(i))
+	case i >= math.MinInt8:
+		w.Write([]byte{0xd0, byte(i)})
+	case i >= math.MinInt16:
+		w.WriteByte(0xd1)
+		w.Write(binary.BigEndian.AppendUint16(nil, uint16(i)))
+	case i >= math.MinInt32:
+		w.WriteByte(0xd2)
+		w.Write(binary.BigEndian.AppendUint32(nil, uint32(i)))
+	default:
+		w.WriteByte(0xd3)
+		w.Write(binary.BigEndian.AppendUint64(nil, uint64(i)))
+	}
+}
+
+// writeUint writes an unsigned integer in the smallest format which can hold it.
+func (w *msgpackWriter) writeUint(u uint64) {
+	switch {
+	case u < 0x80:
+		w.WriteByte(byte(u))
+	case u <= math.MaxUint8:
+		w.Write([]byte{0xcc, byte(u)})
+	case u <= math.MaxUint16:
+		w.WriteByte(0xcd)
+		w.Write(binary.BigEndian.AppendUint16(nil, uint16(u)))
+	case u <= math.MaxUint32:
+		w.WriteByte(0xce)
+		w.Write(binary.BigEndian.AppendUint32(nil, uint32(u)))
+	default:
+		w.WriteByte(0xcf)
+		w.Write(binary.BigEndian.AppendUint64(nil, u))
+	}
+}
+
+// writeFloat32 writes a 32-bit float.
+func (w *msgpackWriter) writeFloat32(f float32) {
+	w.WriteByte(0xca)
+	w.Write(binary.BigEndian.AppendUint32(nil, math.Float32bits(f)))
+}
+
+// writeFloat64 writes a 64-bit float.
+func (w *msgpackWriter) writeFloat64(f float64) {
+	w.WriteByte(0xcb)
+	w.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(f)))
+}
+
+// writeString writes a string.
+func (w *msgpackWriter) writeString(s string) {
+	w.writeHeader(len(s), 0xa0, 32, 0xd9, 0xda, 0xdb)
+	w.WriteString(s)
+}
+
+// writeBytes writes binary data.
+func (w *msgpackWriter) writeBytes(b []byte) {
+	w.writeHeader(len(b), 0, 0, 0xc4, 0xc5, 0xc6)
+	w.Write(b)
+}
+
+// writeTime writes a time as a timestamp extension.
+//
+// Times are written in the smallest timestamp format which can hold them.
+func (w *msgpackWriter) writeTime(t time.Time) {
+	sec, nsec := t.Unix(), uint32(t.Nanosecond())
+
+	switch {
+	case sec>>34 == 0 && nsec == 0 && sec <= math.MaxUint32:
+		w.Write([]byte{0xd6, msgpackTimestampType})
+		w.Write(binary.BigEndian.AppendUint32(nil, uint32(sec)))
+	case sec>>34 == 0:
+		w.Write([]byte{0xd7, msgpackTimestampType})
+		w.Write(binary.BigEndian.AppendUint64(nil, uint64(nsec)<<34|uint64(sec)))
+	default:
+		w.Write([]byte{0xc7, 12, msgpackTimestampType})
+		w.Write(binary.BigEndian.AppendUint32(nil, nsec))
+		w.Write(binary.BigEndian.AppendUint64(nil, uint64(sec)))
+	}
+}
+
+// writeArrayHeader writes the header of an array with n elements.
+func (w *msgpackWriter) writeArrayHeader(n int) {
+	w.writeHeader(n, 0x90, 16, 0, 0xdc, 0xdd)
+}
+
+// writeMapHeader writes the header of a map with n entries.
+func (w *msgpackWriter) writeMapHeader(n int) {
+	w.writeHeader(n, 0x80, 16, 0, 0xde, 0xdf)
+}
+
+// writeHeader writes the header of a value with the given length.
+//
+// The length is packed in the fix format if it's less than fixMax, otherwise the 8, 16 or 32 bit format is used.
+// Formats which don't exist for the type are 0.
+func (w *msgpackWriter) writeHeader(n int, fix byte, fixMax int, format8, format16, format32 byte) {
+	switch {
+	case n < fixMax:
+		w.WriteByte(fix | byte(n))
+	case n <= math.MaxUint8 && format8 != 0:
+		w.Write([]byte{format8, byte(n)})
+	case n <= math.MaxUint16:
+		w.WriteByte(format16)
+		w.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	default:
+		w.WriteByte(format32)
+		w.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	}
+}
+
+// next reads the next token.
+func (r *msgpackReader) next() (token, error) {
+	b, err := r.r.ReadByte()
+	if err != nil {
+		return token{}, err
+	}
+
+	switch {
+	case b <= 0x7f:
+		return token{kind: tokenUint, u: uint64(b)}, nil
+	case b <= 0x8f:
+		return token{kind: tokenMap, n: int(b & 0x0f)}, nil
+	case b <= 0x9f:
+		return token{kind: tokenArray, n: int(b & 0x0f)}, nil
+	case b <= 0xbf:
+		return r.readString(uint64(b & 0x1f))
+	case b >= 0xe0:
+		return token{kind: tokenInt, i: int64(int8(b))}, nil
+	}
+
+	switch b {
+	case 0xc0:
+		return token{kind: tokenNil}, nil
+	case 0xc2, 0xc3:
+		return token{kind: tokenBool, b: b == 0xc3}, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := r.readUint(1 << (b - 0xc4))
+		if err != nil {
+			return token{}, err
+		}
+
+		data, err := readFull(r.r, n)
+		return token{kind: tokenBytes, bytes: data}, err
+	case 0xc7, 0xc8, 0xc9:
+		n, err := r.readUint(1 << (b - 0xc7))
+		if err != nil {
+			return token{}, err
+		}
+		return r.readExt(n)
+	case 0xca:
+		u, err := r.readUint(4)
+		return token{kind: tokenFloat, f: float64(math.Float32frombits(uint32(u)))}, err
+	case 0xcb:
+		u, err := r.readUint(8)
+		return token{kind: tokenFloat, f: math.Float64frombits(u)}, err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		u, err := r.readUint(1 << (b - 0xcc))
+		return token{kind: tokenUint, u: u}, err
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (b - 0xd0)
+		u, err := r.readUint(size)
+
+		// The value is sign extended from its size.
+		shift := 64 - 8*size
+		return token{kind: tokenInt, i: int64(u<<shift) >> shift}, err
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return r.readExt(1 << (b - 0xd4))
+	case 0xd9, 0xda, 0xdb:
+		n, err := r.readUint(1 << (b - 0xd9))
+		if err != nil {
+			return token{}, err
+		}
+		return r.readString(n)
+	case 0xdc, 0xdd:
+		n, err := r.readLength(2 << (b - 0xdc))
+		return token{kind: tokenArray, n: n}, err
+	case 0xde, 0xdf:
+		n, err := r.readLength(2 << (b - 0xde))
+		return token{kind: tokenMap, n: n}, err
+	default:
+		return token{}, fmt.Errorf("serializer: invalid msgpack format 0x%x", b)
+	}
+}
+
+// readUint reads a big endian unsigned integer of the given size in bytes.
+func (r *msgpackReader) readUint(size int) (uint64, error) {
+	b, err := readFull(r.r, uint64(size))
+	if err != nil {
+		return 0, err
+	}
+
+	var u uint64
+	for _, c := range b {
+		u = u<<8 | uint64(c)
+	}
+
+	return u, nil
+}
+
+// readLength reads the length of an array or a map.
+func (r *msgpackReader) readLength(size int) (int, error) {
+	n, err := r.readUint(size)
+	if err != nil {
+		return 0, err
+	}
+
+	if n > math.MaxInt32 {
+		return 0, errors.New("serializer: msgpack length is too large")
+	}
+
+	return int(n), nil
+}
+
+// readString reads a string of the given length.
+func (r *msgpackReader) readString(n uint64) (token, error) {
+	data, err := readFull(r.r, n)
+	return token{kind: tokenString, s: string(data)}, err
+}
+
+// readExt reads an extension with data of the given length, only timestamps are supported and they are read in UTC.
+func (r *msgpackReader) readExt(n uint64) (token, error) {
+	extType, err := r.r.ReadByte()
+	if err != nil {
+		return token{}, unexpectedEOF(err)
+	}
+
+	data, err := readFull(r.r, n)
+	if err != nil {
+		return token{}, err
+	}
+
+	if extType != msgpackTimestampType {
+		return token{}, fmt.Errorf("serializer: unsupported msgpack extension type %d", int8(extType))
+	}
+
+	var t time.Time
+
+	switch len(data) {
+	case 4:
+		t = time.Unix(int64(binary.BigEndian.Uint32(data)), 0).UTC()
+	case 8:
+		u := binary.BigEndian.Uint64(data)
+		t = time.Unix(int64(u&(1<<34-1)), int64(u>>34)).UTC()
+	case 12:
+		t = time.Unix(int64(binary.BigEndian.Uint64(data[4:])), int64(binary.BigEndian.Uint32(data))).UTC()
+	default:
+		return token{}, fmt.Errorf("serializer: invalid msgpack timestamp length %d", len(data))
+	}
+
+	return token{kind: tokenTime, t: t}, nil
+}
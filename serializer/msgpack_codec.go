@@ -0,0 +1,868 @@
+package serializer
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The reflection based codec of MessagePack, msgpack.go writes and reads the tokens of the format.
+//
+// It supports the subset of MessagePack which maps to Go values: nil, booleans, integers, floats, strings, binary,
+// arrays, maps and the timestamp extension. Other extension types are rejected.
+
+// maxDepth is the maximum nesting depth of encoded and decoded values,
+// so malicious input and self-referencing values can't overflow the stack.
+const maxDepth = 1000
+
+// maxPreallocation is the maximum number of bytes allocated up front for a length read from the input,
+// so malicious lengths can't exhaust the memory. Larger values grow as they are read.
+const maxPreallocation = 64 << 10
+
+// maxElementsPreallocation is the maximum number of array and map elements allocated up front.
+// It's smaller than maxPreallocation, since the elements are allocated for every nested array and map.
+const maxElementsPreallocation = 1 << 10
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// errMaxDepth is returned when the encoded or decoded value is nested deeper than maxDepth.
+var errMaxDepth = errors.New("serializer: exceeded max depth")
+
+type (
+	// tokenKind is the kind of a token.
+	tokenKind uint8
+
+	// token is a decoded token, arrays and maps are followed by their elements.
+	token struct {
+		kind  tokenKind
+		b     bool
+		i     int64
+		u     uint64
+		f     float64
+		s     string
+		bytes []byte
+		t     time.Time
+
+		// n is the number of elements of arrays and maps.
+		n int
+	}
+
+	// structField is an encoded field of a struct.
+	structField struct {
+		name      string
+		index     []int
+		omitEmpty bool
+	}
+
+	// encoder encodes Go values as the tokens of a writer.
+	encoder struct {
+		w     *msgpackWriter
+		depth int
+	}
+
+	// decoder decodes the tokens of a reader into Go values.
+	decoder struct {
+		r     *msgpackReader
+		depth int
+	}
+)
+
+const (
+	tokenNil tokenKind = iota
+	tokenBool
+	tokenInt
+	tokenUint
+	tokenFloat
+	tokenString
+	tokenBytes
+	tokenTime
+	tokenArray
+	tokenMap
+)
+
+// structFieldsCache caches the fields of struct types.
+var structFieldsCache sync.Map
+
+// String returns the name of the token's kind, which is used in error messages.
+func (k tokenKind) String() string {
+	switch k {
+	case tokenNil:
+		return "nil"
+	case tokenBool:
+		return "bool"
+	case tokenInt, tokenUint:
+		return "integer"
+	case tokenFloat:
+		return "float"
+	case tokenString:
+		return "string"
+	case tokenBytes:
+		return "bytes"
+	case tokenTime:
+		return "time"
+	case tokenArray:
+		return "array"
+	default:
+		return "map"
+	}
+}
+
+// readBody reads a value from the request's body into out.
+func readBody(body io.Reader, out any) error {
+	v := mustBePointer(out)
+
+	d := decoder{r: &msgpackReader{r: bufio.NewReader(body)}}
+	return d.decode(v.Elem())
+}
+
+// encode writes the value as tokens.
+func (e *encoder) encode(v reflect.Value) error {
+	// Pointers are counted with arrays, maps and structs, so cycles through interfaces are stopped too.
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map, reflect.Struct:
+		if err := e.enter(); err != nil {
+			return err
+		}
+		defer e.leave()
+	}
+
+	w := e.w
+
+	if !v.IsValid() {
+		w.writeNil()
+		return nil
+	}
+
+	if v.Type() == timeType {
+		w.writeTime(v.Interface().(time.Time))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			w.writeNil()
+			return nil
+		}
+		return e.encode(v.Elem())
+	case reflect.Bool:
+		w.writeBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		w.writeInt(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		w.writeUint(v.Uint())
+	case reflect.Float32:
+		w.writeFloat32(float32(v.Float()))
+	case reflect.Float64:
+		w.writeFloat64(v.Float())
+	case reflect.String:
+		w.writeString(v.String())
+	case reflect.Slice:
+		if v.IsNil() {
+			w.writeNil()
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			w.writeBytes(v.Bytes())
+			return nil
+		}
+		return e.encodeArray(v)
+	case reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(b), v)
+			w.writeBytes(b)
+			return nil
+		}
+		return e.encodeArray(v)
+	case reflect.Map:
+		if v.IsNil() {
+			w.writeNil()
+			return nil
+		}
+		return e.encodeMap(v)
+	case reflect.Struct:
+		return e.encodeStruct(v)
+	default:
+		return &UnsupportedTypeError{Type: v.Type()}
+	}
+
+	return nil
+}
+
+// encodeArray writes the elements of a slice or an array.
+func (e *encoder) encodeArray(v reflect.Value) error {
+	e.w.writeArrayHeader(v.Len())
+
+	for i := 0; i < v.Len(); i++ {
+		if err := e.encode(v.Index(i)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// encodeMap writes the entries of a map, sorted by their keys so the output is deterministic.
+func (e *encoder) encodeMap(v reflect.Value) error {
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return lessMapKey(keys[i], keys[j])
+	})
+
+	e.w.writeMapHeader(len(keys))
+
+	for _, key := range keys {
+		if err := e.encode(key); err != nil {
+			return err
+		}
+
+		if err := e.encode(v.MapIndex(key)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// lessMapKey orders map keys of the same kind, keys of other kinds are ordered by their formatted values.
+func lessMapKey(a, b reflect.Value) bool {
+	switch a.Kind() {
+	case reflect.String:
+		return a.String() < b.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() < b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return a.Uint() < b.Uint()
+	default:
+		return fmt.Sprint(a.Interface()) < fmt.Sprint(b.Interface())
+	}
+}
+
+// encodeStruct writes the exported fields of a struct as a map.
+func (e *encoder) encodeStruct(v reflect.Value) error {
+	fields := cachedStructFields(v.Type())
+
+	values := make([]reflect.Value, len(fields))
+	n := 0
+
+	for i, field := range fields {
+		value := v.FieldByIndex(field.index)
+		if field.omitEmpty && value.IsZero() {
+			continue
+		}
+
+		values[i] = value
+		n++
+	}
+
+	e.w.writeMapHeader(n)
+
+	for i, field := range fields {
+		if !values[i].IsValid() {
+			continue
+		}
+
+		e.w.writeString(field.name)
+		if err := e.encode(values[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// cachedStructFields returns the encoded fields of the struct type.
+func cachedStructFields(t reflect.Type) []structField {
+	if fields, ok := structFieldsCache.Load(t); ok {
+		return fields.([]structField)
+	}
+
+	fields, _ := structFieldsCache.LoadOrStore(t, structFields(t, nil))
+	return fields.([]structField)
+}
+
+// structFields returns the encoded fields of the struct type.
+//
+// Fields are named by the msgpack tag, then the json tag, then their names. Fields of embedded structs without
+// a name are promoted like encoding/json, fields of the outer struct win on name conflicts.
+func structFields(t reflect.Type, index []int) []structField {
+	fields := make([]structField, 0, t.NumField())
+	embedded := make([]structField, 0)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		value, ok := field.Tag.Lookup("msgpack")
+		if !ok {
+			value = field.Tag.Get("json")
+		}
+
+		if value == "-" {
+			continue
+		}
+
+		name, options, _ := strings.Cut(value, ",")
+
+		fieldIndex := append(append([]int(nil), index...), i)
+
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			embedded = append(embedded, structFields(field.Type, fieldIndex)...)
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		fields = append(fields, structField{name: name, index: fieldIndex, omitEmpty: hasOption(options, "omitempty")})
+	}
+
+	for _, field := range embedded {
+		if !hasField(fields, field.name) {
+			fields = append(fields, field)
+		}
+	}
+
+	return fields
+}
+
+// hasOption checks if the comma separated tag options contain the option.
+func hasOption(options, option string) bool {
+	for _, o := range strings.Split(options, ",") {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// hasField checks if a field with the given name exists.
+func hasField(fields []structField, name string) bool {
+	for _, field := range fields {
+		if field.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// decode decodes the next value into v.
+func (d *decoder) decode(v reflect.Value) error {
+	tok, err := d.next()
+	if err != nil {
+		return err
+	}
+
+	return d.decodeToken(tok, v)
+}
+
+// decodeToken decodes the value which starts with the token into v.
+func (d *decoder) decodeToken(tok token, v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if tok.kind == tokenNil {
+			v.SetZero()
+			return nil
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return d.decodeToken(tok, v.Elem())
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			return d.mismatch(tok, v.Type())
+		}
+
+		value, err := d.decodeAny(tok)
+		if err != nil {
+			return err
+		}
+
+		if value == nil {
+			v.SetZero()
+		} else {
+			v.Set(reflect.ValueOf(value))
+		}
+		return nil
+	}
+
+	if v.Type() == timeType {
+		return d.decodeTime(tok, v)
+	}
+
+	switch tok.kind {
+	case tokenNil:
+		switch v.Kind() {
+		case reflect.Map, reflect.Slice:
+			v.SetZero()
+		}
+		return nil
+	case tokenBool:
+		if v.Kind() != reflect.Bool {
+			return d.mismatch(tok, v.Type())
+		}
+		v.SetBool(tok.b)
+	case tokenInt, tokenUint:
+		return d.decodeInteger(tok, v)
+	case tokenFloat:
+		switch v.Kind() {
+		case reflect.Float32, reflect.Float64:
+			if v.OverflowFloat(tok.f) {
+				return d.mismatch(tok, v.Type())
+			}
+			v.SetFloat(tok.f)
+		default:
+			return d.mismatch(tok, v.Type())
+		}
+	case tokenString, tokenBytes:
+		return d.decodeBytes(tok, v)
+	case tokenArray:
+		return d.decodeArray(tok, v)
+	case tokenMap:
+		return d.decodeMap(tok, v)
+	default:
+		return d.mismatch(tok, v.Type())
+	}
+
+	return nil
+}
+
+// decodeInteger decodes an integer into an integer or a float.
+func (d *decoder) decodeInteger(tok token, v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if tok.kind == tokenUint && tok.u > 1<<63-1 {
+			return d.mismatch(tok, v.Type())
+		}
+
+		i := tok.i
+		if tok.kind == tokenUint {
+			i = int64(tok.u)
+		}
+
+		if v.OverflowInt(i) {
+			return d.mismatch(tok, v.Type())
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if tok.kind == tokenInt || v.OverflowUint(tok.u) {
+			return d.mismatch(tok, v.Type())
+		}
+		v.SetUint(tok.u)
+	case reflect.Float32, reflect.Float64:
+		if tok.kind == tokenInt {
+			v.SetFloat(float64(tok.i))
+		} else {
+			v.SetFloat(float64(tok.u))
+		}
+	default:
+		return d.mismatch(tok, v.Type())
+	}
+
+	return nil
+}
+
+// decodeBytes decodes a string or bytes into a string, a byte slice or a byte array.
+func (d *decoder) decodeBytes(tok token, v reflect.Value) error {
+	b := tok.bytes
+	if tok.kind == tokenString {
+		b = []byte(tok.s)
+	}
+
+	switch {
+	case v.Kind() == reflect.String:
+		v.SetString(string(b))
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+		v.SetBytes(b)
+	case v.Kind() == reflect.Array && v.Type().Elem().Kind() == reflect.Uint8:
+		if len(b) != v.Len() {
+			return d.mismatch(tok, v.Type())
+		}
+		reflect.Copy(v, reflect.ValueOf(b))
+	default:
+		return d.mismatch(tok, v.Type())
+	}
+
+	return nil
+}
+
+// decodeTime decodes a time or an RFC 3339 string into a time.Time.
+func (d *decoder) decodeTime(tok token, v reflect.Value) error {
+	switch tok.kind {
+	case tokenNil:
+		return nil
+	case tokenTime:
+		v.Set(reflect.ValueOf(tok.t))
+	case tokenString:
+		t, err := time.Parse(time.RFC3339Nano, tok.s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+	default:
+		return d.mismatch(tok, v.Type())
+	}
+
+	return nil
+}
+
+// decodeArray decodes an array into a slice or an array.
+func (d *decoder) decodeArray(tok token, v reflect.Value) error {
+	if err := d.enter(); err != nil {
+		return err
+	}
+	defer d.leave()
+
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return d.mismatch(tok, v.Type())
+		}
+
+		slice := reflect.MakeSlice(v.Type(), 0, preallocation(tok.n))
+		elem := reflect.New(v.Type().Elem()).Elem()
+
+		err := d.elements(tok, func(tok token) error {
+			elem.SetZero()
+			if err := d.decodeToken(tok, elem); err != nil {
+				return err
+			}
+
+			slice = reflect.Append(slice, elem)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		v.Set(slice)
+	case reflect.Array:
+		i := 0
+
+		err := d.elements(tok, func(tok token) error {
+			defer func() { i++ }()
+
+			// Extra elements are skipped.
+			if i >= v.Len() {
+				return d.skip(tok)
+			}
+			return d.decodeToken(tok, v.Index(i))
+		})
+		if err != nil {
+			return err
+		}
+
+		for ; i < v.Len(); i++ {
+			v.Index(i).SetZero()
+		}
+	default:
+		return d.mismatch(tok, v.Type())
+	}
+
+	return nil
+}
+
+// decodeMap decodes a map into a map or a struct.
+func (d *decoder) decodeMap(tok token, v reflect.Value) error {
+	if err := d.enter(); err != nil {
+		return err
+	}
+	defer d.leave()
+
+	switch v.Kind() {
+	case reflect.Map:
+		if v.IsNil() {
+			v.Set(reflect.MakeMapWithSize(v.Type(), preallocation(tok.n)))
+		}
+
+		key := reflect.New(v.Type().Key()).Elem()
+		elem := reflect.New(v.Type().Elem()).Elem()
+
+		return d.elements(tok, func(keyToken token) error {
+			key.SetZero()
+			if err := d.decodeToken(keyToken, key); err != nil {
+				return err
+			}
+
+			elem.SetZero()
+			if err := d.decode(elem); err != nil {
+				return err
+			}
+
+			v.SetMapIndex(key, elem)
+			return nil
+		})
+	case reflect.Struct:
+		fields := cachedStructFields(v.Type())
+
+		return d.elements(tok, func(keyToken token) error {
+			if keyToken.kind != tokenString {
+				return d.mismatch(keyToken, reflect.TypeOf(""))
+			}
+
+			field, ok := findField(fields, keyToken.s)
+			if !ok {
+				next, err := d.next()
+				if err != nil {
+					return err
+				}
+				return d.skip(next)
+			}
+
+			return d.decode(v.FieldByIndex(field.index))
+		})
+	default:
+		return d.mismatch(tok, v.Type())
+	}
+}
+
+// decodeAny decodes the value which starts with the token into an interface value.
+//
+// Integers are decoded as int64, or uint64 if they don't fit in an int64. Maps are decoded as map[string]any
+// if all of their keys are strings, otherwise as map[any]any.
+func (d *decoder) decodeAny(tok token) (any, error) {
+	switch tok.kind {
+	case tokenNil:
+		return nil, nil
+	case tokenBool:
+		return tok.b, nil
+	case tokenInt:
+		return tok.i, nil
+	case tokenUint:
+		if tok.u > 1<<63-1 {
+			return tok.u, nil
+		}
+		return int64(tok.u), nil
+	case tokenFloat:
+		return tok.f, nil
+	case tokenString:
+		return tok.s, nil
+	case tokenBytes:
+		return tok.bytes, nil
+	case tokenTime:
+		return tok.t, nil
+	case tokenArray:
+		if err := d.enter(); err != nil {
+			return nil, err
+		}
+		defer d.leave()
+
+		values := make([]any, 0, preallocation(tok.n))
+
+		err := d.elements(tok, func(tok token) error {
+			value, err := d.decodeAny(tok)
+			values = append(values, value)
+			return err
+		})
+
+		return values, err
+	default:
+		if err := d.enter(); err != nil {
+			return nil, err
+		}
+		defer d.leave()
+
+		keys := make([]any, 0, preallocation(tok.n))
+		values := make([]any, 0, preallocation(tok.n))
+		stringKeys := true
+
+		err := d.elements(tok, func(keyToken token) error {
+			key, err := d.decodeAny(keyToken)
+			if err != nil {
+				return err
+			}
+
+			switch k := key.(type) {
+			case string:
+			case []byte:
+				key, stringKeys = string(k), false
+			case []any, map[string]any, map[any]any:
+				return fmt.Errorf("serializer: unhashable map key of type %T", key)
+			default:
+				stringKeys = false
+			}
+
+			next, err := d.next()
+			if err != nil {
+				return err
+			}
+
+			value, err := d.decodeAny(next)
+			if err != nil {
+				return err
+			}
+
+			keys = append(keys, key)
+			values = append(values, value)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if stringKeys {
+			m := make(map[string]any, len(keys))
+			for i, key := range keys {
+				m[key.(string)] = values[i]
+			}
+			return m, nil
+		}
+
+		m := make(map[any]any, len(keys))
+		for i, key := range keys {
+			m[key] = values[i]
+		}
+		return m, nil
+	}
+}
+
+// next reads the next token, the input can't end inside of arrays and maps.
+func (d *decoder) next() (token, error) {
+	tok, err := d.r.next()
+	if err != nil && d.depth > 0 {
+		return token{}, unexpectedEOF(err)
+	}
+	return tok, err
+}
+
+// skip skips the value which starts with the token.
+func (d *decoder) skip(tok token) error {
+	switch tok.kind {
+	case tokenArray, tokenMap:
+		if err := d.enter(); err != nil {
+			return err
+		}
+		defer d.leave()
+
+		n := tok.n
+		if tok.kind == tokenMap {
+			n *= 2
+		}
+
+		for i := 0; i < n; i++ {
+			next, err := d.next()
+			if err != nil {
+				return err
+			}
+
+			if err := d.skip(next); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// elements calls f with the first token of each element of an array, or each key of a map. f must decode map values too.
+func (d *decoder) elements(tok token, f func(token) error) error {
+	for i := 0; i < tok.n; i++ {
+		next, err := d.next()
+		if err != nil {
+			return err
+		}
+
+		if err := f(next); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// enter increases the depth of the encoder.
+func (e *encoder) enter() error {
+	e.depth++
+	if e.depth > maxDepth {
+		return errMaxDepth
+	}
+	return nil
+}
+
+// leave decreases the depth of the encoder.
+func (e *encoder) leave() {
+	e.depth--
+}
+
+// enter increases the depth of the decoder.
+func (d *decoder) enter() error {
+	d.depth++
+	if d.depth > maxDepth {
+		return errMaxDepth
+	}
+	return nil
+}
+
+// leave decreases the depth of the decoder.
+func (d *decoder) leave() {
+	d.depth--
+}
+
+// mismatch returns a type mismatch error for the token and the type.
+func (d *decoder) mismatch(tok token, t reflect.Type) error {
+	return &TypeMismatchError{Value: tok.kind.String(), Type: t}
+}
+
+// findField finds the field with the given name, or case-insensitively like encoding/json.
+func findField(fields []structField, name string) (structField, bool) {
+	for _, field := range fields {
+		if field.name == name {
+			return field, true
+		}
+	}
+
+	for _, field := range fields {
+		if strings.EqualFold(field.name, name) {
+			return field, true
+		}
+	}
+
+	return structField{}, false
+}
+
+// preallocation returns the capacity which is allocated up front for the number of elements read from the input.
+func preallocation(n int) int {
+	return min(n, maxElementsPreallocation)
+}
+
+// readFull reads n bytes, the buffer grows as the bytes are read so malicious lengths can't exhaust the memory.
+func readFull(r io.Reader, n uint64) ([]byte, error) {
+	if n <= maxPreallocation {
+		b := make([]byte, n)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		return b, nil
+	}
+
+	var buf bytes.Buffer
+	if n > 1<<62 {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	if _, err := io.CopyN(&buf, r, int64(n)); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// unexpectedEOF converts io.EOF to io.ErrUnexpectedEOF, since the input ended in the middle of a value.
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
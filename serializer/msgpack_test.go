@@ -0,0 +1,287 @@
+package serializer
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// payload is a struct which has every supported kind of field.
+type payload struct {
+	embedded
+	Name     string            `json:"name"`
+	Age      int8              `msgpack:"age" cbor:"age"`
+	Score    float64           `json:"score"`
+	Ratio    float32           `json:"ratio"`
+	Active   bool              `json:"active"`
+	Count    uint16            `json:"count"`
+	Tags     []string          `json:"tags"`
+	Blob     []byte            `json:"blob"`
+	Hash     [2]byte           `json:"hash"`
+	Matrix   [2][]int          `json:"matrix"`
+	Attrs    map[string]int    `json:"attrs"`
+	IDs      map[int]string    `json:"ids"`
+	Parent   *payload          `json:"parent"`
+	Extra    any               `json:"extra"`
+	Created  time.Time         `json:"created"`
+	Optional string            `json:"optional,omitempty"`
+	Skipped  string            `json:"-"`
+	Nested   map[string][]bool `json:"nested"`
+	private  string
+}
+
+type embedded struct {
+	ID string `json:"id"`
+}
+
+func newPayload() payload {
+	return payload{
+		embedded: embedded{ID: "1"},
+		Name:     "Mojix",
+		Age:      -22,
+		Score:    math.Pi,
+		Ratio:    0.5,
+		Active:   true,
+		Count:    300,
+		Tags:     []string{"a", strings.Repeat("b", 300)},
+		Blob:     []byte{1, 2, 3},
+		Hash:     [2]byte{4, 5},
+		Matrix:   [2][]int{{1, -1000}, {70000}},
+		Attrs:    map[string]int{"x": 1, "y": math.MinInt64},
+		IDs:      map[int]string{2: "b", 1: "a"},
+		Parent:   &payload{Name: "parent"},
+		Extra:    map[string]any{"list": []any{int64(1), "two", 3.5, nil, true}},
+		Created:  time.Date(2023, 5, 1, 10, 30, 0, 123456789, time.UTC),
+		Skipped:  "skipped",
+		Nested:   map[string][]bool{"flags": {true, false}},
+		private:  "private",
+	}
+}
+
+func hexBytes(t testing.TB, s string) []byte {
+	b, err := hex.DecodeString(strings.ReplaceAll(s, " ", ""))
+	assert.NoError(t, err)
+	return b
+}
+
+func TestNewMessagePackSerializer(t *testing.T) {
+	serializer := NewMessagePackSerializer()
+
+	assert.NotNil(t, serializer)
+	assert.IsType(t, defaultMessagePackSerializer{}, serializer)
+}
+
+func TestDefaultMessagePackSerializer_Write(t *testing.T) {
+	serializer := defaultMessagePackSerializer{}
+
+	testCases := []struct {
+		name     string
+		in       any
+		expected string
+	}{
+		{name: "person", in: person{Name: "Mojix", Age: 22}, expected: "82 a4 6e616d65 a5 4d6f6a6978 a3 616765 16"},
+		{name: "nil", in: nil, expected: "c0"},
+		{name: "nil_slice", in: []int(nil), expected: "c0"},
+		{name: "bools", in: []bool{true, false}, expected: "92 c3 c2"},
+		{name: "positive_ints", in: []uint64{127, 128, 256, 65536, 1 << 32}, expected: "95 7f cc80 cd0100 ce00010000 cf0000000100000000"},
+		{name: "negative_ints", in: []int64{-1, -32, -33, -129, -32769, math.MinInt64}, expected: "96 ff e0 d0df d1ff7f d2ffff7fff d38000000000000000"},
+		{name: "floats", in: []any{float32(1.5), 1.5}, expected: "92 ca3fc00000 cb3ff8000000000000"},
+		{name: "string", in: strings.Repeat("a", 32), expected: "d9 20" + strings.Repeat("61", 32)},
+		{name: "bytes", in: []byte{1, 2, 3}, expected: "c4 03 010203"},
+		{name: "array16", in: make([]int, 16), expected: "dc 0010" + strings.Repeat("00", 16)},
+		{name: "map", in: map[string]int{"b": 2, "a": 1}, expected: "82 a161 01 a162 02"},
+		{name: "timestamp32", in: time.Unix(1, 0), expected: "d6 ff 00000001"},
+		{name: "timestamp64", in: time.Unix(1, 1), expected: "d7 ff 0000000400000001"},
+		{name: "timestamp96", in: time.Unix(-1, 0), expected: "c7 0c ff 00000000 ffffffffffffffff"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := httptest.NewRecorder()
+
+			serializer.Write(res, tc.in, "")
+			assert.Equal(t, hexBytes(t, tc.expected), res.Body.Bytes())
+		})
+	}
+
+	// Channel type cannot be converted to MessagePack.
+	assert.Panics(t, func() {
+		serializer.Write(httptest.NewRecorder(), map[string]any{"ch": make(chan bool)}, "")
+	})
+}
+
+func TestDefaultMessagePackSerializer_Write_Depth(t *testing.T) {
+	serializer := defaultMessagePackSerializer{}
+
+	// Self-referencing values fail when the max depth is reached instead of overflowing the stack.
+	cyclic := &payload{Name: "cyclic"}
+	cyclic.Parent = cyclic
+
+	assert.PanicsWithError(t, errMaxDepth.Error(), func() {
+		serializer.Write(httptest.NewRecorder(), cyclic, "")
+	})
+
+	list := []any{1}
+	list[0] = list
+
+	assert.PanicsWithError(t, errMaxDepth.Error(), func() {
+		serializer.Write(httptest.NewRecorder(), list, "")
+	})
+
+	var self any
+	self = &self
+
+	assert.PanicsWithError(t, errMaxDepth.Error(), func() {
+		serializer.Write(httptest.NewRecorder(), self, "")
+	})
+
+	// Values nested up to the max depth are written.
+	var nested any = "leaf"
+	for i := 0; i < maxDepth; i++ {
+		nested = []any{nested}
+	}
+
+	res := httptest.NewRecorder()
+	serializer.Write(res, nested, "")
+	assert.Equal(t, hexBytes(t, strings.Repeat("91", maxDepth)+"a46c656166"), res.Body.Bytes())
+
+	assert.PanicsWithError(t, errMaxDepth.Error(), func() {
+		serializer.Write(httptest.NewRecorder(), []any{nested}, "")
+	})
+}
+
+func TestDefaultMessagePackSerializer_Read(t *testing.T) {
+	serializer := defaultMessagePackSerializer{}
+
+	res := httptest.NewRecorder()
+	in := newPayload()
+	serializer.Write(res, in, "")
+
+	req := httptest.NewRequest(http.MethodPost, "/", res.Body)
+
+	var out payload
+	err := serializer.Read(req, &out)
+	assert.NoError(t, err)
+
+	in.Skipped, in.private = "", ""
+	assert.Equal(t, in, out)
+
+	var p person
+
+	// Invalid argument passed to unmarshal.
+	assert.PanicsWithValue(t, "serializer: out must be a non-nil pointer, got serializer.person", func() {
+		serializer.Read(httptest.NewRequest(http.MethodPost, "/", nil), p)
+	})
+
+	err = serializer.Read(httptest.NewRequest(http.MethodPost, "/", nil), &p)
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestDefaultMessagePackSerializer_Read_Errors(t *testing.T) {
+	serializer := defaultMessagePackSerializer{}
+
+	testCases := []struct {
+		name  string
+		input string
+		out   any
+		err   error
+	}{
+		{name: "truncated", input: "82 a4 6e616d65", out: &person{}, err: io.ErrUnexpectedEOF},
+		{name: "truncated_string", input: "a4 6e61", out: new(string), err: io.ErrUnexpectedEOF},
+		{name: "huge_length", input: "dd 7fffffff", out: new([]int), err: io.ErrUnexpectedEOF},
+		{name: "huge_string", input: "db ffffffff", out: new(string), err: io.ErrUnexpectedEOF},
+		{name: "invalid_format", input: "c1", out: new(any)},
+		{name: "unsupported_ext", input: "d4 01 00", out: new(any)},
+		{name: "invalid_timestamp", input: "d5 ff 0000", out: new(time.Time)},
+		{name: "type_mismatch", input: "82 a4 6e616d65 01 a3 616765 16", out: &person{}, err: &TypeMismatchError{}},
+		{name: "overflow", input: "cd 0100", out: new(int8), err: &TypeMismatchError{}},
+		{name: "negative_uint", input: "ff", out: new(uint), err: &TypeMismatchError{}},
+		{name: "float_int", input: "cb 3ff8000000000000", out: new(int), err: &TypeMismatchError{}},
+		{name: "byte_array_length", input: "c4 01 00", out: new([2]byte), err: &TypeMismatchError{}},
+		{name: "non_string_key", input: "81 01 01", out: &person{}, err: &TypeMismatchError{}},
+		{name: "unhashable_key", input: "81 90 01", out: new(any)},
+		{name: "depth", input: strings.Repeat("91", maxDepth+1) + "c0", out: new(any), err: errMaxDepth},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(hexBytes(t, tc.input)))
+
+			err := serializer.Read(req, tc.out)
+			assert.Error(t, err)
+
+			switch target := tc.err.(type) {
+			case nil:
+			case *TypeMismatchError:
+				assert.ErrorAs(t, err, &target)
+			default:
+				assert.ErrorIs(t, err, target)
+			}
+		})
+	}
+}
+
+func TestDefaultMessagePackSerializer_Read_Any(t *testing.T) {
+	serializer := defaultMessagePackSerializer{}
+
+	input := "84 a161 cf ffffffffffffffff a162 c4 0101 01 c0 a163 81 02 a178"
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(hexBytes(t, input)))
+
+	var out any
+	err := serializer.Read(req, &out)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[any]any{
+		"a":      uint64(math.MaxUint64),
+		"b":      []byte{1},
+		int64(1): nil,
+		"c":      map[any]any{int64(2): "x"},
+	}, out)
+
+	// Fields are matched case-insensitively, unknown fields are skipped and null leaves values untouched.
+	input = "83 a44e414d45 a3 666f6f a7756e6b6e6f776e 92 01 81 a178 c0 a3616765 c0"
+	req = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(hexBytes(t, input)))
+
+	p := person{Age: 22}
+	err = serializer.Read(req, &p)
+
+	assert.NoError(t, err)
+	assert.Equal(t, person{Name: "foo", Age: 22}, p)
+}
+
+func FuzzMessagePackRead(f *testing.F) {
+	serializer := defaultMessagePackSerializer{}
+
+	res := httptest.NewRecorder()
+	serializer.Write(res, newPayload(), "")
+	f.Add(res.Body.Bytes())
+
+	for _, seed := range []string{
+		"84 a161 cf ffffffffffffffff a162 c4 0101 01 c0 a163 81 02 a178",
+		"d6 ff 00000001",
+		"c7 0c ff 00000000 ffffffffffffffff",
+		"dd 7fffffff",
+		"91 91 91 c0",
+	} {
+		f.Add(hexBytes(f, seed))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var out any
+		if err := serializer.Read(httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(data)), &out); err == nil {
+			// Values which are read can be written back.
+			serializer.Write(httptest.NewRecorder(), out, "")
+		}
+
+		var p payload
+		_ = serializer.Read(httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(data)), &p)
+	})
+}
@@ -0,0 +1,134 @@
+package serializer
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+type (
+	// defaultProtobufSerializer is the default Protocol Buffers serializer used in Kid.
+	defaultProtobufSerializer struct {
+	}
+
+	// defaultProtoJSONSerializer is the JSON serializer which writes and reads proto messages as protobuf JSON.
+	defaultProtoJSONSerializer struct {
+		defaultJSONSerializer
+	}
+
+	// NotProtoMessageError is returned when a value which isn't a proto.Message is read or written as protobuf.
+	NotProtoMessageError struct {
+		Type reflect.Type
+	}
+)
+
+// Verifying interface compliance.
+var (
+	_ Serializer   = defaultProtobufSerializer{}
+	_ WriteChecker = defaultProtobufSerializer{}
+	_ Serializer   = defaultProtoJSONSerializer{}
+)
+
+// NewProtobufSerializer returns a new Protocol Buffers serializer.
+//
+// Only proto.Message values can be written and read, the indent is ignored since protobuf is a binary format.
+func NewProtobufSerializer() Serializer {
+	return defaultProtobufSerializer{}
+}
+
+// NewProtoJSONSerializer returns a new JSON serializer which uses the protobuf JSON mapping for proto.Message values,
+// other values are written and read like the default JSON serializer.
+//
+// It can be set as Kid's JSON serializer with WithJSONSerializer, so JSON responses of proto messages use protobuf JSON.
+// Unknown fields are ignored when reading.
+func NewProtoJSONSerializer() Serializer {
+	return defaultProtoJSONSerializer{}
+}
+
+// Error implements the error interface.
+func (e *NotProtoMessageError) Error() string {
+	return fmt.Sprintf("serializer: %v is not a proto.Message", e.Type)
+}
+
+// CheckWrite returns a NotProtoMessageError if the object is not a proto.Message.
+func (s defaultProtobufSerializer) CheckWrite(in any) error {
+	if _, ok := in.(proto.Message); !ok {
+		return &NotProtoMessageError{Type: reflect.TypeOf(in)}
+	}
+	return nil
+}
+
+// Write writes the given proto message as protobuf to response.
+//
+// Kid checks the object with CheckWrite before the response is started, so Write only panics
+// with a NotProtoMessageError if it's called directly with an object which is not a proto.Message.
+func (s defaultProtobufSerializer) Write(w http.ResponseWriter, in any, indent string) {
+	if err := s.CheckWrite(in); err != nil {
+		panic(err)
+	}
+	msg := in.(proto.Message)
+
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		panic(err)
+	}
+
+	if _, err := w.Write(data); err != nil {
+		panic(err)
+	}
+}
+
+// Read reads request's body as protobuf and puts it in the given proto message.
+//
+// Returns a NotProtoMessageError if out is not a proto.Message.
+func (s defaultProtobufSerializer) Read(req *http.Request, out any) error {
+	msg, ok := out.(proto.Message)
+	if !ok {
+		return &NotProtoMessageError{Type: reflect.TypeOf(out)}
+	}
+
+	data, err := io.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+
+	return proto.Unmarshal(data, msg)
+}
+
+// Write writes the given object as JSON to response, proto messages are written as protobuf JSON.
+func (s defaultProtoJSONSerializer) Write(w http.ResponseWriter, in any, indent string) {
+	msg, ok := in.(proto.Message)
+	if !ok {
+		s.defaultJSONSerializer.Write(w, in, indent)
+		return
+	}
+
+	data, err := protojson.MarshalOptions{Indent: indent}.Marshal(msg)
+	if err != nil {
+		panic(err)
+	}
+
+	// A trailing newline is written like the default JSON serializer.
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		panic(err)
+	}
+}
+
+// Read reads request's body as JSON and puts it in the given obj, proto messages are read as protobuf JSON.
+func (s defaultProtoJSONSerializer) Read(req *http.Request, out any) error {
+	msg, ok := out.(proto.Message)
+	if !ok {
+		return s.defaultJSONSerializer.Read(req, out)
+	}
+
+	data, err := io.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+
+	return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, msg)
+}
@@ -0,0 +1,147 @@
+package serializer
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/apipb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestNewProtobufSerializer(t *testing.T) {
+	serializer := NewProtobufSerializer()
+
+	assert.NotNil(t, serializer)
+	assert.IsType(t, defaultProtobufSerializer{}, serializer)
+}
+
+func TestNewProtoJSONSerializer(t *testing.T) {
+	serializer := NewProtoJSONSerializer()
+
+	assert.NotNil(t, serializer)
+	assert.IsType(t, defaultProtoJSONSerializer{}, serializer)
+}
+
+func TestNotProtoMessageError(t *testing.T) {
+	err := &NotProtoMessageError{Type: nil}
+	assert.Equal(t, "serializer: <nil> is not a proto.Message", err.Error())
+
+	err = &NotProtoMessageError{Type: reflect.TypeOf(person{})}
+	assert.Equal(t, "serializer: serializer.person is not a proto.Message", err.Error())
+}
+
+func TestDefaultProtobufSerializer_CheckWrite(t *testing.T) {
+	serializer := defaultProtobufSerializer{}
+
+	assert.NoError(t, serializer.CheckWrite(wrapperspb.String("Mojix")))
+
+	var notProtoErr *NotProtoMessageError
+	assert.ErrorAs(t, serializer.CheckWrite(person{Name: "Mojix"}), &notProtoErr)
+	assert.Equal(t, reflect.TypeOf(person{}), notProtoErr.Type)
+
+	assert.ErrorAs(t, serializer.CheckWrite(nil), &notProtoErr)
+	assert.Nil(t, notProtoErr.Type)
+}
+
+func TestDefaultProtobufSerializer_Write(t *testing.T) {
+	res := httptest.NewRecorder()
+
+	serializer := defaultProtobufSerializer{}
+
+	serializer.Write(res, wrapperspb.String("Mojix"), "    ")
+	assert.Equal(t, []byte("\x0a\x05Mojix"), res.Body.Bytes())
+
+	assert.PanicsWithError(t, "serializer: serializer.person is not a proto.Message", func() {
+		serializer.Write(httptest.NewRecorder(), person{Name: "Mojix"}, "")
+	})
+}
+
+func TestDefaultProtobufSerializer_Read(t *testing.T) {
+	serializer := defaultProtobufSerializer{}
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte("\x0a\x05Mojix")))
+
+	var msg wrapperspb.StringValue
+	err := serializer.Read(req, &msg)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Mojix", msg.GetValue())
+
+	req = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte("\x0a\x05Mo")))
+
+	err = serializer.Read(req, &msg)
+	assert.Error(t, err)
+
+	var p person
+	err = serializer.Read(httptest.NewRequest(http.MethodPost, "/", nil), &p)
+
+	var notProtoErr *NotProtoMessageError
+	assert.True(t, errors.As(err, &notProtoErr))
+	assert.Equal(t, "serializer: *serializer.person is not a proto.Message", err.Error())
+}
+
+func TestDefaultProtoJSONSerializer_Write(t *testing.T) {
+	serializer := defaultProtoJSONSerializer{}
+
+	msg, err := structpb.NewStruct(map[string]any{"name": "Mojix", "age": 22})
+	assert.NoError(t, err)
+
+	res := httptest.NewRecorder()
+	serializer.Write(res, msg, "")
+
+	assert.JSONEq(t, `{"name": "Mojix", "age": 22}`, res.Body.String())
+	assert.True(t, strings.HasSuffix(res.Body.String(), "\n"))
+
+	res = httptest.NewRecorder()
+	serializer.Write(res, msg, "  ")
+
+	assert.JSONEq(t, `{"name": "Mojix", "age": 22}`, res.Body.String())
+	assert.Contains(t, res.Body.String(), "\n  ")
+
+	// Other values are written as JSON.
+	res = httptest.NewRecorder()
+	serializer.Write(res, person{Name: "Mojix", Age: 22}, "")
+
+	assert.Equal(t, "{\"name\":\"Mojix\",\"age\":22}\n", res.Body.String())
+
+	// NaN can't be written as a JSON number.
+	assert.Panics(t, func() {
+		serializer.Write(httptest.NewRecorder(), structpb.NewNumberValue(math.NaN()), "")
+	})
+}
+
+func TestDefaultProtoJSONSerializer_Read(t *testing.T) {
+	serializer := defaultProtoJSONSerializer{}
+
+	// Unknown fields are ignored.
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name": "Mojix", "requestStreaming": true, "unknown": 1}`))
+
+	var msg apipb.Method
+	err := serializer.Read(req, &msg)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Mojix", msg.GetName())
+	assert.True(t, msg.GetRequestStreaming())
+
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name": 1}`))
+
+	err = serializer.Read(req, &msg)
+	assert.Error(t, err)
+
+	// Other values are read as JSON.
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name": "Mojix", "age": 22}`))
+
+	var p person
+	err = serializer.Read(req, &p)
+
+	assert.NoError(t, err)
+	assert.Equal(t, person{Name: "Mojix", Age: 22}, p)
+}
@@ -1,20 +1,69 @@
 // Package serializer provides an interface to read from request body or write to response body.
 // Can be used for reading/writing JSON, XML, MessagePack, etc.
 //
-// Currently the supported ones are JSON and XML.
+// The supported ones are JSON, XML, MessagePack, CBOR, YAML and Protocol Buffers.
 package serializer
 
 import (
+	"fmt"
 	"net/http"
+	"reflect"
 )
 
-// Serializer is the interface for reading from request body or writing to response body.
-//
-// It can be implemented to read/write custom JSON/XML serializers.
-type Serializer interface {
-	// Write writes object with the given indent to response body.
-	Write(w http.ResponseWriter, in any, indent string)
+type (
+	// Serializer is the interface for reading from request body or writing to response body.
+	//
+	// It can be implemented to read/write custom JSON/XML serializers.
+	Serializer interface {
+		// Write writes object with the given indent to response body.
+		Write(w http.ResponseWriter, in any, indent string)
+
+		// Read reads request body and store it in the given object.
+		Read(req *http.Request, out any) error
+	}
+
+	// WriteChecker is implemented by serializers which can't write every value, e.g. the protobuf serializer.
+	//
+	// Kid checks the value before the response is started, so the error is handled by the error handler instead of Write panicking.
+	WriteChecker interface {
+		// CheckWrite returns an error if the given object can't be written.
+		CheckWrite(in any) error
+	}
+
+	// UnsupportedTypeError is returned when a type can't be encoded or decoded.
+	UnsupportedTypeError struct {
+		Type reflect.Type
+	}
+
+	// TypeMismatchError is returned when a decoded value can't be stored in a Go value of the given type.
+	TypeMismatchError struct {
+		Value string
+		Type  reflect.Type
+
+		// Field is the path of the struct field, nested fields are separated by dots. It's empty if it's unknown.
+		Field string
+	}
+)
+
+// Error implements the error interface.
+func (e *UnsupportedTypeError) Error() string {
+	return "serializer: unsupported type " + e.Type.String()
+}
+
+// Error implements the error interface.
+func (e *TypeMismatchError) Error() string {
+	if e.Field != "" {
+		return "serializer: cannot decode " + e.Value + " into field " + e.Field + " of type " + e.Type.String()
+	}
+
+	return "serializer: cannot decode " + e.Value + " into Go value of type " + e.Type.String()
+}
 
-	// Read reads request body and store it in the given object.
-	Read(req *http.Request, out any) error
+// mustBePointer panics if out is not a non-nil pointer, since it's a programmer error.
+func mustBePointer(out any) reflect.Value {
+	v := reflect.ValueOf(out)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		panic(fmt.Sprintf("serializer: out must be a non-nil pointer, got %T", out))
+	}
+	return v
 }
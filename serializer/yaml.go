@@ -0,0 +1,48 @@
+package serializer
+
+import (
+	"net/http"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultYAMLSerializer is the default YAML serializer used in Kid.
+type defaultYAMLSerializer struct {
+}
+
+// Verifying interface compliance.
+var _ Serializer = defaultYAMLSerializer{}
+
+// NewYAMLSerializer returns a new YAML serializer.
+//
+// Struct fields are named by their yaml tags, or their lowercased names. Indents are written as spaces,
+// with the same width as the given indent but at least 2 spaces, and default to 4 spaces.
+// Indents which are not made of spaces, e.g. tabs, are ignored since YAML doesn't allow them.
+func NewYAMLSerializer() Serializer {
+	return defaultYAMLSerializer{}
+}
+
+// Write writes the given object as YAML to response.
+func (s defaultYAMLSerializer) Write(w http.ResponseWriter, in any, indent string) {
+	encoder := yaml.NewEncoder(w)
+	if indent != "" && strings.Trim(indent, " ") == "" {
+		// yaml.v3 panics for indents smaller than 2.
+		encoder.SetIndent(max(len(indent), 2))
+	}
+
+	if err := encoder.Encode(in); err != nil {
+		panic(err)
+	}
+
+	if err := encoder.Close(); err != nil {
+		panic(err)
+	}
+}
+
+// Read reads request's body as YAML and puts it in the given obj.
+func (s defaultYAMLSerializer) Read(req *http.Request, out any) error {
+	mustBePointer(out)
+
+	return yaml.NewDecoder(req.Body).Decode(out)
+}
@@ -0,0 +1,77 @@
+package serializer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewYAMLSerializer(t *testing.T) {
+	serializer := NewYAMLSerializer()
+
+	assert.NotNil(t, serializer)
+	assert.IsType(t, defaultYAMLSerializer{}, serializer)
+}
+
+func TestDefaultYAMLSerializer_Write(t *testing.T) {
+	res := httptest.NewRecorder()
+
+	serializer := defaultYAMLSerializer{}
+
+	in := map[string]any{"person": person{Name: "Mojix", Age: 22}}
+
+	serializer.Write(res, in, "")
+	assert.Equal(t, "person:\n    name: Mojix\n    age: 22\n", res.Body.String())
+
+	res = httptest.NewRecorder()
+
+	serializer.Write(res, in, "  ")
+	assert.Equal(t, "person:\n  name: Mojix\n  age: 22\n", res.Body.String())
+
+	// Indents are at least 2 spaces.
+	res = httptest.NewRecorder()
+
+	serializer.Write(res, in, " ")
+	assert.Equal(t, "person:\n  name: Mojix\n  age: 22\n", res.Body.String())
+
+	// Tabs are ignored.
+	res = httptest.NewRecorder()
+
+	serializer.Write(res, in, "\t")
+	assert.Equal(t, "person:\n    name: Mojix\n    age: 22\n", res.Body.String())
+
+	// Unsupported type.
+	assert.Panics(t, func() {
+		serializer.Write(res, make(chan bool), "")
+	})
+}
+
+func TestDefaultYAMLSerializer_Read(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", strings.NewReader("name: Mojix\nage: 22\n"))
+
+	serializer := defaultYAMLSerializer{}
+
+	var p person
+	err := serializer.Read(req, &p)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Mojix", p.Name)
+	assert.Equal(t, 22, p.Age)
+
+	req = httptest.NewRequest(http.MethodGet, "/", strings.NewReader("name: Mojix\nage: 22\n"))
+
+	var p2 person
+
+	// Invalid argument passed to unmarshal.
+	assert.Panics(t, func() {
+		serializer.Read(req, p2)
+	})
+
+	req = httptest.NewRequest(http.MethodGet, "/", strings.NewReader("name: Mojix\nage: old\n"))
+
+	err = serializer.Read(req, &p2)
+	assert.Error(t, err)
+}
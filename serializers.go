@@ -0,0 +1,134 @@
+package kid
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/mojixcoder/kid/serializer"
+)
+
+// Media types of the built-in responses and serializers.
+const (
+	MIMEApplicationJSON      string = "application/json"
+	MIMEApplicationXML       string = "application/xml"
+	MIMETextXML              string = "text/xml"
+	MIMETextHTML             string = "text/html"
+	MIMETextPlain            string = "text/plain"
+	MIMEApplicationMsgPack   string = "application/msgpack"
+	MIMEApplicationXMsgPack  string = "application/x-msgpack"
+	MIMEApplicationCBOR      string = "application/cbor"
+	MIMEApplicationYAML      string = "application/yaml"
+	MIMEApplicationXYAML     string = "application/x-yaml"
+	MIMETextYAML             string = "text/yaml"
+	MIMEApplicationProtobuf  string = "application/protobuf"
+	MIMEApplicationXProtobuf string = "application/x-protobuf"
+	MIMEApplicationNDJSON    string = "application/x-ndjson"
+	MIMETextEventStream      string = "text/event-stream"
+)
+
+// ProtoMessage is implemented by protobuf messages, e.g. the ones generated by protoc-gen-go and dynamicpb messages.
+//
+// It's used instead of proto.Message, so Kid's core package doesn't import the protobuf module.
+type ProtoMessage interface {
+	ProtoMessage()
+}
+
+// Render sends the object with the given status code, written by the serializer registered for the media type.
+//
+// The media type is used as the response's Content-Type and it can have parameters, e.g. "application/yaml; charset=utf-8".
+// Serializers are registered with WithSerializer, the built-in serializers are registered for their media types by default.
+//
+// If the serializer can't write the object, e.g. the protobuf serializer with a value which is not a proto.Message,
+// the serializer's error is handled with HandleError before the response is started.
+// Panics if the media type is invalid or no serializer is registered for it.
+func (c *Context) Render(code int, mediaType string, obj any) {
+	base, ok := parseMediaType(mediaType)
+	if !ok {
+		panic(fmt.Sprintf("invalid media type %q", mediaType))
+	}
+
+	s, ok := c.kid.serializerFor(base)
+	if !ok {
+		panic(fmt.Sprintf("no serializer is registered for media type %q", base))
+	}
+
+	if checker, ok := s.(serializer.WriteChecker); ok {
+		if err := checker.CheckWrite(obj); err != nil {
+			c.HandleError(err)
+			return
+		}
+	}
+
+	c.writeContentType(mediaType)
+	c.response.WriteHeader(code)
+	s.Write(c.Response(), obj, "")
+}
+
+// Read reads request's body with the serializer registered for its Content-Type and stores it in the given object.
+// The object must be a pointer.
+//
+// Structured syntax suffixes fall back to their base serializers, e.g. "application/problem+json" is read as JSON.
+// Returns an *HTTPError with 415 status code if the Content-Type is missing, invalid or has no serializer.
+func (c *Context) Read(out any) error {
+	s, err := c.requestSerializer()
+	if err != nil {
+		return err
+	}
+
+	return s.Read(c.Request(), out)
+}
+
+// requestSerializer returns the serializer registered for the request's Content-Type.
+func (c *Context) requestSerializer() (serializer.Serializer, error) {
+	mediaType, _, err := mime.ParseMediaType(c.GetRequestHeader(contentTypeHeader))
+	if err != nil {
+		return nil, NewHTTPError(http.StatusUnsupportedMediaType, "").WithCause(err)
+	}
+
+	s, ok := c.kid.serializerFor(mediaType)
+	if !ok {
+		return nil, unsupportedMediaTypeError(mediaType)
+	}
+
+	return s, nil
+}
+
+// serializerFor returns the serializer registered for the media type.
+//
+// Media types with a structured syntax suffix fall back to the serializer of the suffix, e.g. "application/vnd.api+json".
+func (k *Kid) serializerFor(mediaType string) (serializer.Serializer, bool) {
+	mediaType = strings.ToLower(mediaType)
+
+	if s, ok := k.serializers[mediaType]; ok {
+		return s, true
+	}
+
+	if i := strings.LastIndexByte(mediaType, '+'); i != -1 {
+		s, ok := k.serializers["application/"+mediaType[i+1:]]
+		return s, ok
+	}
+
+	return nil, false
+}
+
+// parseMediaType returns the media type without its parameters, it reports false if it's not a valid type/subtype.
+func parseMediaType(mediaType string) (string, bool) {
+	base, _, err := mime.ParseMediaType(mediaType)
+	if err != nil {
+		return "", false
+	}
+
+	mainType, subtype, ok := strings.Cut(base, "/")
+	if !ok || mainType == "" || subtype == "" || strings.Contains(base, "*") {
+		return "", false
+	}
+
+	return base, true
+}
+
+// unsupportedMediaTypeError returns an HTTP error with 415 status code for the media type.
+func unsupportedMediaTypeError(mediaType string) *HTTPError {
+	return NewHTTPError(http.StatusUnsupportedMediaType, "").WithCause(fmt.Errorf("unsupported media type %s", mediaType))
+}
@@ -0,0 +1,192 @@
+package kid
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mojixcoder/kid/serializer"
+	"github.com/stretchr/testify/assert"
+)
+
+// csvSerializer is a serializer which writes and reads a single line of comma separated values.
+type csvSerializer struct{}
+
+func (csvSerializer) Write(w http.ResponseWriter, in any, indent string) {
+	if _, err := io.WriteString(w, strings.Join(in.([]string), ",")); err != nil {
+		panic(err)
+	}
+}
+
+func (csvSerializer) Read(req *http.Request, out any) error {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+
+	if len(body) == 0 {
+		return errors.New("empty body")
+	}
+
+	*out.(*[]string) = strings.Split(string(body), ",")
+	return nil
+}
+
+func newCSVKid() *Kid {
+	k := New()
+	k.ApplyOptions(WithSerializer("text/csv", csvSerializer{}))
+	return k
+}
+
+func TestContext_Render(t *testing.T) {
+	k := newCSVKid()
+
+	res := httptest.NewRecorder()
+	k.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), res).Render(http.StatusCreated, "text/csv; charset=utf-8", []string{"a", "b"})
+
+	assert.Equal(t, http.StatusCreated, res.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", res.Header().Get("Content-Type"))
+	assert.Equal(t, "a,b", res.Body.String())
+
+	res = httptest.NewRecorder()
+	k.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), res).Render(http.StatusOK, "application/vnd.api+json", Map{"key": "value"})
+
+	assert.Equal(t, "application/vnd.api+json", res.Header().Get("Content-Type"))
+	assert.Equal(t, "{\"key\":\"value\"}\n", res.Body.String())
+
+	c := k.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+
+	assert.PanicsWithValue(t, "no serializer is registered for media type \"application/toml\"", func() {
+		c.Render(http.StatusOK, "application/toml", nil)
+	})
+
+	assert.PanicsWithValue(t, "invalid media type \"csv\"", func() {
+		c.Render(http.StatusOK, "csv", nil)
+	})
+}
+
+func TestContext_Render_NotProtoMessage(t *testing.T) {
+	k := New()
+
+	res := httptest.NewRecorder()
+	c := k.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), res)
+
+	assert.NotPanics(t, func() {
+		c.Render(http.StatusOK, MIMEApplicationProtobuf, Map{"key": "value"})
+	})
+
+	var notProtoErr *serializer.NotProtoMessageError
+	assert.ErrorAs(t, c.Err(), &notProtoErr)
+
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+	assert.Equal(t, MIMEApplicationJSON, res.Header().Get("Content-Type"))
+	assert.Equal(t, "{\"message\":\"Internal Server Error\"}\n", res.Body.String())
+}
+
+func TestContext_Read(t *testing.T) {
+	k := newCSVKid()
+
+	testCases := []struct {
+		name        string
+		contentType string
+		body        string
+		expected    []string
+		status      int
+		err         bool
+	}{
+		{name: "registered", contentType: "text/csv", body: "a,b", expected: []string{"a", "b"}},
+		{name: "parameters", contentType: "Text/CSV; charset=utf-8", body: "a", expected: []string{"a"}},
+		{name: "suffix", contentType: "application/vnd.api+json", body: `["a"]`, expected: []string{"a"}},
+		{name: "text_xml", contentType: "text/xml", body: "<x>a</x>", expected: []string{"a"}},
+		{name: "decode_error", contentType: "text/csv", err: true},
+		{name: "missing", status: http.StatusUnsupportedMediaType},
+		{name: "unknown", contentType: "application/toml", status: http.StatusUnsupportedMediaType},
+		{name: "unknown_suffix", contentType: "application/vnd.api+toml", status: http.StatusUnsupportedMediaType},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tc.body))
+			if tc.contentType != "" {
+				req.Header.Set(contentTypeHeader, tc.contentType)
+			}
+
+			var out []string
+			err := k.NewContext(req, httptest.NewRecorder()).Read(&out)
+
+			if tc.status != 0 {
+				var httpErr *HTTPError
+				assert.ErrorAs(t, err, &httpErr)
+				assert.Equal(t, tc.status, httpErr.Status)
+				return
+			}
+
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, out)
+		})
+	}
+}
+
+func TestContext_Bind_Serializer(t *testing.T) {
+	k := New()
+	k.ApplyOptions(WithSerializer("application/x-person", mockPersonSerializer{}))
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("foo"))
+	req.Header.Set(contentTypeHeader, "application/x-person")
+
+	var p person
+	err := k.NewContext(req, httptest.NewRecorder()).Bind(&p)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", p.Name)
+}
+
+func TestContext_Negotiate_Serializer(t *testing.T) {
+	k := newCSVKid()
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "text/csv, application/json;q=0.5")
+
+	res := httptest.NewRecorder()
+	k.NewContext(req, res).Negotiate(http.StatusOK, []string{"a", "b"}, MIMEApplicationJSON, "text/csv")
+
+	assert.Equal(t, "text/csv", res.Header().Get("Content-Type"))
+	assert.Equal(t, "a,b", res.Body.String())
+}
+
+func TestWithSerializer(t *testing.T) {
+	k := New()
+
+	assert.PanicsWithValue(t, "serializer cannot be nil", func() {
+		WithSerializer("text/csv", nil)
+	})
+
+	for _, mediaType := range []string{"", "csv", "text/*"} {
+		assert.Panics(t, func() {
+			WithSerializer(mediaType, csvSerializer{})
+		})
+	}
+
+	k.ApplyOptions(WithSerializer("Text/CSV; charset=utf-8", csvSerializer{}))
+
+	assert.Equal(t, csvSerializer{}, k.serializers["text/csv"])
+}
+
+// mockPersonSerializer reads the whole body as a person's name.
+type mockPersonSerializer struct{}
+
+func (mockPersonSerializer) Write(w http.ResponseWriter, in any, indent string) {}
+
+func (mockPersonSerializer) Read(req *http.Request, out any) error {
+	body, err := io.ReadAll(req.Body)
+	out.(*person).Name = string(body)
+	return err
+}
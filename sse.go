@@ -0,0 +1,265 @@
+package kid
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrSSEClosed is returned when an event is sent with a closed SSEWriter.
+	ErrSSEClosed = errors.New("sse writer is closed")
+
+	// ErrInvalidSSEField is returned when an event's name or ID contains a line break.
+	ErrInvalidSSEField = errors.New("sse event name and id cannot contain line breaks")
+)
+
+type (
+	// SSEWriter writes server-sent events to the response.
+	//
+	// It's returned by Context.SSE and it's safe for concurrent use.
+	SSEWriter struct {
+		c         *Context
+		ctx       context.Context
+		mutex     sync.Mutex
+		closed    bool
+		heartbeat bool
+		stop      chan struct{}
+		wg        sync.WaitGroup
+	}
+
+	// SSEEvent is a server-sent event.
+	SSEEvent struct {
+		// Event is the event's name, the client dispatches unnamed events as message events.
+		Event string
+
+		// ID is the event's ID, which the client sends back in the Last-Event-ID header when it reconnects.
+		ID string
+
+		// Data is the event's data, it can have multiple lines.
+		Data string
+	}
+)
+
+// SSE starts a server-sent events response and returns its writer.
+//
+// The response is sent with 200 status code and text/event-stream Content-Type, and it's not cached.
+// The writer is closed when the handler returns, which also stops its heartbeat, and sending events after that returns ErrSSEClosed.
+// Sending stops with the request context's error when the client disconnects.
+// Subsequent calls in the same request return the same writer.
+func (c *Context) SSE() *SSEWriter {
+	if c.sse != nil {
+		return c.sse
+	}
+
+	header := c.response.Header()
+	header.Set(contentTypeHeader, MIMETextEventStream)
+	header.Set("Cache-Control", "no-cache")
+
+	// Disables buffering of reverse proxies such as nginx.
+	header.Set("X-Accel-Buffering", "no")
+
+	c.response.WriteHeader(http.StatusOK)
+	c.response.Flush()
+
+	c.sse = &SSEWriter{
+		c:    c,
+		ctx:  c.Request().Context(),
+		stop: make(chan struct{}),
+	}
+
+	return c.sse
+}
+
+// LastEventID returns the ID of the last event which the client received, sent in the Last-Event-ID header when it reconnects.
+//
+// It's empty for the first connection.
+func (w *SSEWriter) LastEventID() string {
+	return w.c.GetRequestHeader("Last-Event-ID")
+}
+
+// Done returns a channel which is closed when the request's context is done, e.g. when the client disconnects.
+func (w *SSEWriter) Done() <-chan struct{} {
+	return w.ctx.Done()
+}
+
+// Send sends an event with the given name, ID and data. The name and the ID are optional.
+//
+// Data with multiple lines is sent as multiple data fields, which the client joins with line feeds.
+// Returns ErrInvalidSSEField if the name or the ID contains a line break.
+func (w *SSEWriter) Send(event, id, data string) error {
+	if strings.ContainsAny(event, "\r\n") || strings.ContainsAny(id, "\r\n\x00") {
+		return ErrInvalidSSEField
+	}
+
+	var b strings.Builder
+
+	if id != "" {
+		b.WriteString("id: " + id + "\n")
+	}
+
+	if event != "" {
+		b.WriteString("event: " + event + "\n")
+	}
+
+	for _, line := range splitLines(data) {
+		b.WriteString("data: " + line + "\n")
+	}
+
+	b.WriteString("\n")
+
+	return w.write(b.String())
+}
+
+// SendJSON sends an event with the given name and ID, and the data encoded as JSON.
+func (w *SSEWriter) SendJSON(event, id string, data any) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	return w.Send(event, id, string(encoded))
+}
+
+// Retry tells the client to wait for the given duration before reconnecting, when the connection is lost.
+func (w *SSEWriter) Retry(d time.Duration) error {
+	return w.write(fmt.Sprintf("retry: %d\n\n", d.Milliseconds()))
+}
+
+// Comment sends a comment, which the client ignores. It can be used to keep the connection alive.
+func (w *SSEWriter) Comment(text string) error {
+	var b strings.Builder
+
+	for _, line := range splitLines(text) {
+		if line == "" {
+			b.WriteString(":\n")
+		} else {
+			b.WriteString(": " + line + "\n")
+		}
+	}
+
+	b.WriteString("\n")
+
+	return w.write(b.String())
+}
+
+// Heartbeat sends empty comments on the given interval, so proxies and clients don't close idle connections.
+//
+// The heartbeat stops when the writer is closed or the request's context is done.
+// Panics if the interval is not positive or the heartbeat is already started.
+func (w *SSEWriter) Heartbeat(interval time.Duration) {
+	if interval <= 0 {
+		panic("heartbeat interval must be positive")
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.heartbeat {
+		panic("heartbeat is already started")
+	}
+	w.heartbeat = true
+
+	if w.closed {
+		return
+	}
+
+	w.wg.Add(1)
+
+	go func() {
+		defer w.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-w.ctx.Done():
+				return
+			case <-w.stop:
+				return
+			case <-ticker.C:
+				if err := w.Comment(""); err != nil {
+					return
+				}
+			}
+		}
+	}()
+}
+
+// Stream sends the events of the subscription until the subscription is closed or the request's context is done.
+//
+// It returns nil when the client disconnects, and the error of sending events otherwise.
+func (w *SSEWriter) Stream(sub *SSESubscription) error {
+	for {
+		select {
+		case <-w.ctx.Done():
+			return nil
+		case event, ok := <-sub.Events():
+			if !ok {
+				return nil
+			}
+
+			if err := w.Send(event.Event, event.ID, event.Data); err != nil {
+				if w.ctx.Err() != nil {
+					return nil
+				}
+				return err
+			}
+		}
+	}
+}
+
+// Close closes the writer and waits for its heartbeat to stop. Sending events after closing returns ErrSSEClosed.
+func (w *SSEWriter) Close() {
+	w.mutex.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.stop)
+	}
+	w.mutex.Unlock()
+
+	w.wg.Wait()
+}
+
+// closeSSE closes the request's SSE writer, so it doesn't write to the response after the context is put back in the pool.
+func (c *Context) closeSSE() {
+	if c.sse != nil {
+		c.sse.Close()
+	}
+}
+
+// write writes the given fields to the response and flushes it.
+func (w *SSEWriter) write(s string) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.closed {
+		return ErrSSEClosed
+	}
+
+	if err := w.ctx.Err(); err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w.c.response, s); err != nil {
+		return err
+	}
+
+	w.c.response.Flush()
+
+	return nil
+}
+
+// splitLines splits the given text by CRLF, CR and LF line breaks.
+func splitLines(s string) []string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+	return strings.Split(s, "\n")
+}
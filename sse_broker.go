@@ -0,0 +1,154 @@
+package kid
+
+import (
+	"sync"
+)
+
+type (
+	// SSEBroker fans out server-sent events to the subscribers of topics, in the current process.
+	//
+	// Events are delivered without blocking the publisher, so events are dropped for subscribers whose buffer is full.
+	// The last events of each topic are kept, so reconnecting clients can get the events they missed.
+	SSEBroker struct {
+		mutex       sync.RWMutex
+		topics      map[string]map[*SSESubscription]struct{}
+		history     map[string][]SSEEvent
+		bufferSize  int
+		historySize int
+	}
+
+	// SSESubscription is a subscription to the topics of an SSEBroker.
+	SSESubscription struct {
+		broker *SSEBroker
+		topics []string
+		events chan SSEEvent
+		once   sync.Once
+	}
+)
+
+// NewSSEBroker returns a new SSEBroker.
+//
+// bufferSize is the number of events which are buffered for each subscriber,
+// and historySize is the number of the last events which are kept for each topic.
+// Panics if any of the sizes is negative.
+func NewSSEBroker(bufferSize, historySize int) *SSEBroker {
+	if bufferSize < 0 || historySize < 0 {
+		panic("sse broker sizes cannot be negative")
+	}
+
+	return &SSEBroker{
+		topics:      make(map[string]map[*SSESubscription]struct{}),
+		history:     make(map[string][]SSEEvent),
+		bufferSize:  bufferSize,
+		historySize: historySize,
+	}
+}
+
+// Subscribe subscribes to the given topics.
+//
+// If lastEventID is the ID of an event in a topic's history, the events which are published after it are delivered first,
+// it's usually SSEWriter.LastEventID. The subscription must be closed when it's not used anymore.
+// Panics if no topic is given.
+func (b *SSEBroker) Subscribe(lastEventID string, topics ...string) *SSESubscription {
+	if len(topics) == 0 {
+		panic("at least one topic is required")
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	var missed []SSEEvent
+	if lastEventID != "" {
+		for _, topic := range topics {
+			missed = append(missed, eventsAfter(b.history[topic], lastEventID)...)
+		}
+	}
+
+	sub := &SSESubscription{
+		broker: b,
+		topics: topics,
+		events: make(chan SSEEvent, b.bufferSize+len(missed)),
+	}
+
+	for _, event := range missed {
+		sub.events <- event
+	}
+
+	for _, topic := range topics {
+		if b.topics[topic] == nil {
+			b.topics[topic] = make(map[*SSESubscription]struct{})
+		}
+		b.topics[topic][sub] = struct{}{}
+	}
+
+	return sub
+}
+
+// Publish publishes the event to the subscribers of the topic and returns the number of subscribers which received it.
+func (b *SSEBroker) Publish(topic string, event SSEEvent) int {
+	// The history is updated with the delivery, so subscribers don't get the event both from the history and the topic.
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.historySize > 0 {
+		history := append(b.history[topic], event)
+		if len(history) > b.historySize {
+			history = history[len(history)-b.historySize:]
+		}
+		b.history[topic] = history
+	}
+
+	var delivered int
+	for sub := range b.topics[topic] {
+		select {
+		case sub.events <- event:
+			delivered++
+		default:
+		}
+	}
+
+	return delivered
+}
+
+// Subscribers returns the number of subscribers of the topic.
+func (b *SSEBroker) Subscribers(topic string) int {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	return len(b.topics[topic])
+}
+
+// Events returns the channel of the subscription's events, which is closed when the subscription is closed.
+func (s *SSESubscription) Events() <-chan SSEEvent {
+	return s.events
+}
+
+// Close unsubscribes from the topics and closes the events channel. It's safe to call it multiple times.
+func (s *SSESubscription) Close() {
+	s.once.Do(func() {
+		b := s.broker
+
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+
+		for _, topic := range s.topics {
+			delete(b.topics[topic], s)
+			if len(b.topics[topic]) == 0 {
+				delete(b.topics, topic)
+			}
+		}
+
+		close(s.events)
+	})
+}
+
+// eventsAfter returns the events which are after the event with the given ID, or nil if it's not found.
+func eventsAfter(events []SSEEvent, id string) []SSEEvent {
+	for i := len(events) - 1; i >= 0; i-- {
+		if events[i].ID == id {
+			return events[i+1:]
+		}
+	}
+
+	return nil
+}
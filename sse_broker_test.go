@@ -0,0 +1,131 @@
+package kid
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// receive returns the events which are buffered in the subscription.
+func receive(sub *SSESubscription) []SSEEvent {
+	var events []SSEEvent
+	for {
+		select {
+		case event := <-sub.Events():
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func TestNewSSEBroker(t *testing.T) {
+	broker := NewSSEBroker(10, 5)
+
+	assert.Equal(t, 10, broker.bufferSize)
+	assert.Equal(t, 5, broker.historySize)
+	assert.NotNil(t, broker.topics)
+	assert.NotNil(t, broker.history)
+
+	assert.PanicsWithValue(t, "sse broker sizes cannot be negative", func() {
+		NewSSEBroker(-1, 0)
+	})
+
+	assert.PanicsWithValue(t, "sse broker sizes cannot be negative", func() {
+		NewSSEBroker(0, -1)
+	})
+}
+
+func TestSSEBroker_Publish(t *testing.T) {
+	broker := NewSSEBroker(2, 0)
+
+	assert.PanicsWithValue(t, "at least one topic is required", func() {
+		broker.Subscribe("")
+	})
+
+	news := broker.Subscribe("", "news")
+	both := broker.Subscribe("", "news", "sports")
+
+	assert.Equal(t, 2, broker.Subscribers("news"))
+	assert.Equal(t, 1, broker.Subscribers("sports"))
+	assert.Equal(t, 0, broker.Subscribers("weather"))
+
+	assert.Equal(t, 2, broker.Publish("news", SSEEvent{ID: "1"}))
+	assert.Equal(t, 1, broker.Publish("sports", SSEEvent{ID: "2"}))
+	assert.Equal(t, 0, broker.Publish("weather", SSEEvent{ID: "3"}))
+
+	// The buffer of both is full, so the event is dropped for it.
+	assert.Equal(t, 1, broker.Publish("news", SSEEvent{ID: "4"}))
+
+	assert.Equal(t, []SSEEvent{{ID: "1"}, {ID: "4"}}, receive(news))
+	assert.Equal(t, []SSEEvent{{ID: "1"}, {ID: "2"}}, receive(both))
+
+	both.Close()
+	both.Close()
+
+	_, ok := <-both.Events()
+	assert.False(t, ok)
+
+	assert.Equal(t, 1, broker.Subscribers("news"))
+	assert.Equal(t, 0, broker.Subscribers("sports"))
+	assert.NotContains(t, broker.topics, "sports")
+
+	news.Close()
+	assert.Empty(t, broker.topics)
+}
+
+func TestSSEBroker_Subscribe_LastEventID(t *testing.T) {
+	broker := NewSSEBroker(1, 3)
+
+	for i := 1; i <= 5; i++ {
+		broker.Publish("news", SSEEvent{ID: strconv.Itoa(i)})
+	}
+	broker.Publish("sports", SSEEvent{ID: "s1"})
+
+	assert.Len(t, broker.history["news"], 3)
+
+	// Missed events are delivered even if they're more than the buffer size.
+	sub := broker.Subscribe("3", "news", "sports")
+	defer sub.Close()
+
+	assert.Equal(t, []SSEEvent{{ID: "4"}, {ID: "5"}}, receive(sub))
+
+	// Events which are not in the history can't be replayed.
+	for _, id := range []string{"", "1", "5", "unknown"} {
+		sub := broker.Subscribe(id, "news")
+		assert.Empty(t, receive(sub))
+		sub.Close()
+	}
+
+	// History is not kept if its size is zero.
+	broker = NewSSEBroker(1, 0)
+	broker.Publish("news", SSEEvent{ID: "1"})
+
+	assert.Empty(t, broker.history)
+}
+
+func TestSSEBroker_DataRace(t *testing.T) {
+	broker := NewSSEBroker(10, 10)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+			sub := broker.Subscribe("1", "news")
+			receive(sub)
+			sub.Close()
+		}()
+
+		go func(i int) {
+			defer wg.Done()
+			broker.Publish("news", SSEEvent{ID: strconv.Itoa(i)})
+			broker.Subscribers("news")
+		}(i)
+	}
+
+	wg.Wait()
+}
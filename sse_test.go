@@ -0,0 +1,235 @@
+package kid
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newSSEContext(req *http.Request) (*Context, *httptest.ResponseRecorder) {
+	res := httptest.NewRecorder()
+	return New().NewContext(req, res), res
+}
+
+// isDone reports whether the done channel of the writer is closed.
+func isDone(sse *SSEWriter) bool {
+	select {
+	case <-sse.Done():
+		return true
+	default:
+		return false
+	}
+}
+
+func TestContext_SSE(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Last-Event-ID", "41")
+
+	c, res := newSSEContext(req)
+
+	sse := c.SSE()
+	defer sse.Close()
+
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "text/event-stream", res.Header().Get("Content-Type"))
+	assert.Equal(t, "no-cache", res.Header().Get("Cache-Control"))
+	assert.Equal(t, "no", res.Header().Get("X-Accel-Buffering"))
+	assert.True(t, res.Flushed)
+	assert.Equal(t, "41", sse.LastEventID())
+	assert.False(t, isDone(sse))
+}
+
+func TestContext_SSE_Cached(t *testing.T) {
+	k := New()
+
+	var first, second *SSEWriter
+	k.Get("/events", func(c *Context) {
+		first = c.SSE()
+		first.Heartbeat(time.Millisecond)
+
+		// The second call returns the same writer, instead of starting a writer which isn't closed with the first one.
+		second = c.SSE()
+		assert.NoError(t, second.Send("", "1", "hello"))
+	})
+
+	res := httptest.NewRecorder()
+	k.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+	assert.Same(t, first, second)
+
+	// The heartbeat is stopped when the handler returns.
+	body := res.Body.String()
+	time.Sleep(10 * time.Millisecond)
+
+	assert.Equal(t, body, res.Body.String())
+	assert.ErrorIs(t, first.Comment(""), ErrSSEClosed)
+}
+
+func TestSSEWriter_Send(t *testing.T) {
+	c, res := newSSEContext(httptest.NewRequest(http.MethodGet, "/", nil))
+
+	sse := c.SSE()
+	defer sse.Close()
+
+	assert.NoError(t, sse.Send("", "", "hello"))
+	assert.NoError(t, sse.Send("update", "42", "line 1\nline 2\r\nline 3\rline 4"))
+	assert.NoError(t, sse.Send("empty", "", ""))
+	assert.NoError(t, sse.SendJSON("user", "43", Map{"name": "foo"}))
+	assert.NoError(t, sse.Retry(3*time.Second))
+	assert.NoError(t, sse.Comment("first\nsecond"))
+
+	expected := "data: hello\n\n" +
+		"id: 42\nevent: update\ndata: line 1\ndata: line 2\ndata: line 3\ndata: line 4\n\n" +
+		"event: empty\ndata: \n\n" +
+		"id: 43\nevent: user\ndata: {\"name\":\"foo\"}\n\n" +
+		"retry: 3000\n\n" +
+		": first\n: second\n\n"
+	assert.Equal(t, expected, res.Body.String())
+
+	assert.ErrorIs(t, sse.Send("a\nb", "", "data"), ErrInvalidSSEField)
+	assert.ErrorIs(t, sse.Send("", "1\r", "data"), ErrInvalidSSEField)
+	assert.ErrorIs(t, sse.Send("", "1\x00", "data"), ErrInvalidSSEField)
+	assert.Error(t, sse.SendJSON("", "", make(chan int)))
+	assert.Equal(t, expected, res.Body.String())
+
+	sse.Close()
+	sse.Close()
+
+	assert.ErrorIs(t, sse.Send("", "", "data"), ErrSSEClosed)
+}
+
+func TestSSEWriter_Send_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c, res := newSSEContext(httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+
+	sse := c.SSE()
+	defer sse.Close()
+
+	cancel()
+
+	assert.ErrorIs(t, sse.Send("", "", "data"), context.Canceled)
+	assert.Empty(t, res.Body.String())
+	assert.True(t, isDone(sse))
+
+	c, _ = newSSEContext(httptest.NewRequest(http.MethodGet, "/", nil))
+	c.response = newResponse(errWriter{httptest.NewRecorder()})
+
+	sse = c.SSE()
+	defer sse.Close()
+
+	assert.Error(t, sse.Send("", "", "data"))
+}
+
+func TestSSEWriter_Heartbeat(t *testing.T) {
+	c, res := newSSEContext(httptest.NewRequest(http.MethodGet, "/", nil))
+
+	sse := c.SSE()
+
+	assert.PanicsWithValue(t, "heartbeat interval must be positive", func() {
+		sse.Heartbeat(0)
+	})
+
+	sse.Heartbeat(time.Millisecond)
+
+	assert.PanicsWithValue(t, "heartbeat is already started", func() {
+		sse.Heartbeat(time.Millisecond)
+	})
+
+	time.Sleep(20 * time.Millisecond)
+	sse.Close()
+
+	body := res.Body.String()
+	assert.NotEmpty(t, body)
+	assert.Equal(t, "", strings.ReplaceAll(body, ":\n\n", ""))
+
+	// Heartbeat is not started after closing.
+	c, _ = newSSEContext(httptest.NewRequest(http.MethodGet, "/", nil))
+	sse = c.SSE()
+	sse.Close()
+	sse.Heartbeat(time.Millisecond)
+	sse.Close()
+
+	// Heartbeat stops when the request's context is done.
+	ctx, cancel := context.WithCancel(context.Background())
+	c, _ = newSSEContext(httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+
+	sse = c.SSE()
+	sse.Heartbeat(time.Hour)
+	cancel()
+	sse.Close()
+}
+
+func TestKid_ServeHTTP_SSE(t *testing.T) {
+	k := New()
+
+	var sse *SSEWriter
+	k.Get("/events", func(c *Context) {
+		sse = c.SSE()
+		sse.Heartbeat(time.Millisecond)
+
+		assert.NoError(t, sse.Send("", "1", "hello"))
+	})
+
+	res := httptest.NewRecorder()
+	k.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+	// The writer is closed after the handler returns, so its heartbeat doesn't write to the response anymore.
+	body := res.Body.String()
+	time.Sleep(10 * time.Millisecond)
+
+	assert.Equal(t, body, res.Body.String())
+	assert.Contains(t, body, "id: 1\ndata: hello\n\n")
+	assert.ErrorIs(t, sse.Send("", "2", "hello"), ErrSSEClosed)
+	assert.ErrorIs(t, sse.Comment(""), ErrSSEClosed)
+}
+
+func TestSSEWriter_Stream(t *testing.T) {
+	broker := NewSSEBroker(10, 0)
+
+	c, res := newSSEContext(httptest.NewRequest(http.MethodGet, "/", nil))
+
+	sse := c.SSE()
+	defer sse.Close()
+
+	sub := broker.Subscribe("", "news")
+
+	broker.Publish("news", SSEEvent{Event: "news", ID: "1", Data: "first"})
+	broker.Publish("news", SSEEvent{Data: "second"})
+	sub.Close()
+
+	assert.NoError(t, sse.Stream(sub))
+	assert.Equal(t, "id: 1\nevent: news\ndata: first\n\ndata: second\n\n", res.Body.String())
+
+	// Streaming ends cleanly when the client disconnects.
+	ctx, cancel := context.WithCancel(context.Background())
+	c, _ = newSSEContext(httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+
+	sse = c.SSE()
+	defer sse.Close()
+
+	sub = broker.Subscribe("", "news")
+	defer sub.Close()
+
+	broker.Publish("news", SSEEvent{Data: "data"})
+	cancel()
+
+	assert.NoError(t, sse.Stream(sub))
+
+	// Sending errors are returned.
+	c, _ = newSSEContext(httptest.NewRequest(http.MethodGet, "/", nil))
+
+	sse = c.SSE()
+	sse.Close()
+
+	sub = broker.Subscribe("", "news")
+	defer sub.Close()
+
+	broker.Publish("news", SSEEvent{Data: "data"})
+
+	assert.ErrorIs(t, sse.Stream(sub), ErrSSEClosed)
+}
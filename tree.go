@@ -4,6 +4,10 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"maps"
+	"net/http"
+	"path"
+	"regexp"
 	"strings"
 )
 
@@ -18,8 +22,29 @@ const (
 	paramPrefix     = "{"
 	paramSuffix     = "}"
 	starParamPrefix = paramPrefix + "*"
+
+	// constraintSeparator separates a path parameter's name from its constraint, e.g. {id:int}.
+	// The optional marker and the default value are part of the name, e.g. {year?:int} or {page=1:int}.
+	constraintSeparator = ":"
+
+	// optionalSuffix makes a path parameter optional, e.g. {year?}.
+	optionalSuffix = "?"
+
+	// defaultSeparator separates an optional path parameter from its default value, e.g. {page=1}.
+	defaultSeparator = "="
 )
 
+// paramConstraints are the built-in path parameter constraints.
+//
+// Any other constraint is compiled as a regular expression which must match the whole path segment.
+var paramConstraints = map[string]*regexp.Regexp{
+	"int":   regexp.MustCompile(`^-?[0-9]+$`),
+	"uint":  regexp.MustCompile(`^[0-9]+$`),
+	"alpha": regexp.MustCompile(`^[a-zA-Z]+$`),
+	"alnum": regexp.MustCompile(`^[a-zA-Z0-9]+$`),
+	"uuid":  regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`),
+}
+
 type (
 	// handlerMiddleware zips a handler and its middlewares to each other.
 	handlerMiddleware struct {
@@ -31,30 +56,67 @@ type (
 
 		// name is route name.
 		name string
+
+		// alias is the user-chosen name of the route, used for reverse routing.
+		alias string
+
+		// mount is the mounted handler if the route is a mount point.
+		mount http.Handler
+
+		// alternate is true if the route is an expansion of a route which omits its optional path parameters.
+		alternate bool
+
+		// defaults holds the default values of the omitted optional path parameters.
+		defaults Params
+
+		// options holds the route options, shared between all of the route's methods and expansions.
+		options *routeOptions
 	}
 
-	// Tree is a tree used for routing.
+	// Tree is a compressed radix tree used for routing.
 	Tree struct {
-		// size is the number of nodes in the tree.
-		size uint32
-
 		// root of the tree.
 		root *Node
+
+		// patterns maps methods and paths without parameter names to the registered paths.
+		patterns map[string]string
 	}
 
 	// Node is a tree node.
 	Node struct {
-		// id of each node. It separates nodes from each other.
-		id uint32
-
 		// label of the node.
+		//
+		// It's the static path prefix for static nodes and the parameter name for parameter nodes.
 		label string
 
+		// indices holds the first byte of each static child's label, in the same order as children.
+		indices []byte
+
+		// children is the node's static children.
 		children []*Node
 
+		// paramChildren is the node's path parameter children.
+		// Parameters with constraints come before the ones without constraints.
+		paramChildren []*Node
+
+		// starChild is the node's star path parameter child.
+		starChild *Node
+
 		isParam bool
 		isStar  bool
 
+		// constraint is the path parameter's constraint, e.g. int in {id:int}.
+		constraint string
+
+		// matcher validates path parameter values against the constraint.
+		matcher *regexp.Regexp
+
+		// optional is true if the path parameter can be omitted, e.g. {year?} or {page=1}.
+		optional bool
+
+		// defaultValue is the value of the optional path parameter when it's omitted.
+		defaultValue string
+
 		// handlerMap maps HTTP methods to their handlers.
 		handlerMap map[string]handlerMiddleware
 	}
@@ -74,16 +136,14 @@ func newNode() Node {
 // newTree returns a new Tree.
 func newTree() Tree {
 	node := newNode()
-	node.id = 1
 
-	return Tree{
-		size: 1,
-		root: &node,
-	}
+	return Tree{root: &node}
 }
 
-// insert inserts a new node into the tree.
-func (t *Tree) insertNode(path string, methods []string, middlewares []MiddlewareFunc, handler HandlerFunc) {
+// insert inserts a new node into the tree and returns the node which holds the handler.
+//
+// Routes with optional path parameters are expanded, e.g. /reports/{year?} is inserted as /reports and /reports/{year}.
+func (t *Tree) insertNode(path string, methods []string, middlewares []MiddlewareFunc, handler HandlerFunc) *Node {
 	if len(methods) == 0 {
 		panic("providing at least one method is required")
 	}
@@ -94,80 +154,217 @@ func (t *Tree) insertNode(path string, methods []string, middlewares []Middlewar
 
 	segments := strings.Split(path, "/")[1:]
 
+	hm := handlerMiddleware{handler: handler, middlewares: middlewares, name: path, options: &routeOptions{}}
+
+	required, defaults := optionalSegments(segments)
+
+	// Routes without the optional segments are inserted from the shortest one.
+	for i := required; i < len(segments); i++ {
+		alternate := hm
+		alternate.alternate = true
+		alternate.defaults = defaults[i-required]
+
+		if i == 0 {
+			t.insertSegments([]string{""}, methods, alternate)
+		} else {
+			t.insertSegments(segments[:i], methods, alternate)
+		}
+	}
+
+	return t.insertSegments(segments, methods, hm)
+}
+
+// optionalSegments returns the number of segments which are required and the default values of the optional segments.
+//
+// Default values of each optional segment are returned together with the ones of the optional segments after it.
+//
+// Panics if an optional path parameter is not a whole segment at the end of the path,
+// otherwise the expanded routes would be ambiguous.
+func optionalSegments(segments []string) (int, []Params) {
+	required := len(segments)
+	defaults := make([]Params, 0)
+
+	for i := len(segments) - 1; i >= 0; i-- {
+		for _, part := range splitSegment(segments[i]) {
+			if !isParam(part) {
+				continue
+			}
+
+			node := Node{isParam: true, isStar: isStar(part)}
+			node.setLabel(part)
+
+			if !node.optional {
+				continue
+			}
+
+			switch {
+			case node.isStar:
+				panic("star path parameters cannot be optional")
+			case part != segments[i]:
+				panic(fmt.Sprintf("optional path parameter %s must be a whole path segment", node.label))
+			case i != required-1:
+				panic(fmt.Sprintf("optional path parameter %s can only be followed by optional path parameters", node.label))
+			case node.defaultValue != "" && !node.matchesConstraint(node.defaultValue):
+				panic(fmt.Sprintf("default value %q of path parameter %s must match %s", node.defaultValue, node.label, node.constraint))
+			}
+
+			values := make(Params)
+			if len(defaults) > 0 {
+				maps.Copy(values, defaults[0])
+			}
+			if node.defaultValue != "" {
+				values[node.label] = node.defaultValue
+			}
+
+			defaults = append([]Params{values}, defaults...)
+			required--
+		}
+	}
+
+	return required, defaults
+}
+
+// insertSegments inserts the path segments into the tree and returns the node which holds the handler.
+func (t *Tree) insertSegments(segments []string, methods []string, hm handlerMiddleware) *Node {
 	currNode := t.root
 
+	// prefix is the static part of the path which hasn't been inserted yet.
+	var prefix string
+
+	// pattern is the path with parameter names removed, used for detecting conflicting routes.
+	var pattern strings.Builder
+
 	for i, segment := range segments {
-		node := newNode()
+		prefix += "/"
+		pattern.WriteByte('/')
 
-		node.isParam = isParam(segment)
-		node.isStar = isStar(segment)
-		node.setLabel(segment)
-		node.id = t.size + 1
-		t.size++
+		parts := splitSegment(segment)
 
-		if i != len(segments)-1 {
-			if node.isStar {
-				panic("star path parameters can only be the last part of a path")
+		for j, part := range parts {
+			if !isParam(part) {
+				prefix += part
+				pattern.WriteString(part)
+				continue
 			}
 
-			if child := currNode.getChild(node.label, node.isParam, node.isStar); child == nil {
-				currNode.addChild(&node)
-				currNode = &node
-			} else {
-				currNode = child
+			if j > 0 && isParam(parts[j-1]) {
+				panic(fmt.Sprintf("path parameters in segment %s must be separated by static text", segment))
 			}
-		} else { // Only for the last iteration of the for loop.
-			hm := handlerMiddleware{handler: handler, middlewares: middlewares, name: path}
-			if child := currNode.getChild(node.label, node.isParam, node.isStar); child == nil {
-				node.addHanlder(methods, hm)
-				currNode.addChild(&node)
+
+			node := newNode()
+
+			node.isParam = true
+			node.isStar = isStar(part)
+			node.setLabel(part)
+
+			if node.isStar {
+				if i != len(segments)-1 || j != len(parts)-1 {
+					panic("star path parameters can only be the last part of a path")
+				}
+
+				if node.constraint != "" {
+					panic("star path parameters cannot have constraints")
+				}
+
+				pattern.WriteString(starParamPrefix + paramSuffix)
 			} else {
-				child.addHanlder(methods, hm)
+				pattern.WriteString(paramPrefix + constraintSeparator + node.constraint + paramSuffix)
 			}
+
+			currNode = currNode.insertStatic(prefix)
+			currNode = currNode.insertParam(&node)
+			prefix = ""
 		}
 	}
+
+	currNode = currNode.insertStatic(prefix)
+	t.checkConflicts(pattern.String(), hm.name, methods)
+	currNode.addHanlder(methods, hm)
+
+	return currNode
 }
 
-// doesMatch deterines if the path matches the node's label.
-func (n Node) doesMatch(path []string, pos int) bool {
-	if n.isStar {
-		return true
-	}
+// insertStatic inserts the static path under the node and returns the node which the path ends at.
+//
+// Nodes are split when the path ends in the middle of their labels.
+func (n *Node) insertStatic(path string) *Node {
+	for path != "" {
+		child := n.getStaticChild(path[0])
+		if child == nil {
+			node := newNode()
+			node.label = path
+			n.addChild(&node)
+			return &node
+		}
 
-	if pos >= len(path) {
-		return false
-	}
+		i := commonPrefixLen(path, child.label)
+		if i < len(child.label) {
+			child = n.splitChild(child, i)
+		}
 
-	// Param matching.
-	if n.isParam {
-		return path[pos] != ""
+		n = child
+		path = path[i:]
 	}
 
-	// Exact matching.
-	return path[pos] == n.label
+	return n
 }
 
-// searchFinished returns true if the search has to be finished.
-func (n Node) searchFinished(path []string, pos int) bool {
-	if pos+1 == len(path) && len(n.handlerMap) > 0 {
-		return true
+// splitChild splits the child's label at the given position and returns the new child which holds the common prefix.
+//
+// The old child keeps its handlers and children, it will only be moved one level down.
+func (n *Node) splitChild(child *Node, pos int) *Node {
+	parent := newNode()
+	parent.label = child.label[:pos]
+
+	child.label = child.label[pos:]
+	parent.addChild(child)
+
+	for i := range n.children {
+		if n.children[i] == child {
+			n.children[i] = &parent
+		}
 	}
-	return n.isStar
+
+	return &parent
 }
 
-// getPathParam returns the path parameter.
-func (n *Node) getPathParam(path []string, pos int) string {
-	if n.isStar {
-		return strings.Join(path[pos:], "/")
+// insertParam inserts the given path parameter node under the node and returns the inserted node.
+//
+// If the same parameter already exists, the existing node will be returned.
+func (n *Node) insertParam(node *Node) *Node {
+	if node.isStar {
+		if n.starChild == nil {
+			n.starChild = node
+		} else if n.starChild.label != node.label {
+			panic(fmt.Sprintf("star path parameter %s conflicts with %s", node.label, n.starChild.label))
+		}
+		return n.starChild
+	}
+
+	if child := n.getParamChild(node.label, node.constraint); child != nil {
+		return child
+	}
+
+	// Parameters with constraints have a higher priority.
+	pos := len(n.paramChildren)
+	if node.constraint != "" {
+		pos = 0
+		for pos < len(n.paramChildren) && n.paramChildren[pos].constraint != "" {
+			pos++
+		}
 	}
 
-	return path[pos]
+	n.paramChildren = append(n.paramChildren, nil)
+	copy(n.paramChildren[pos+1:], n.paramChildren[pos:])
+	n.paramChildren[pos] = node
+
+	return node
 }
 
-// getChild returns the specified child of the node.
-func (n Node) getChild(label string, isParam, isStar bool) *Node {
-	for i := 0; i < len(n.children); i++ {
-		if n.children[i].label == label && n.children[i].isParam == isParam && n.children[i].isStar == isStar {
+// getStaticChild returns the static child whose label starts with the given byte.
+func (n *Node) getStaticChild(b byte) *Node {
+	for i := 0; i < len(n.indices); i++ {
+		if n.indices[i] == b {
 			return n.children[i]
 		}
 	}
@@ -175,16 +372,167 @@ func (n Node) getChild(label string, isParam, isStar bool) *Node {
 	return nil
 }
 
-// addChild adds the given node to the node's children.
+// getParamChild returns the path parameter child with the given name and constraint.
+func (n *Node) getParamChild(label, constraint string) *Node {
+	for _, child := range n.paramChildren {
+		if child.label == label && child.constraint == constraint {
+			return child
+		}
+	}
+
+	return nil
+}
+
+// addChild adds the given node to the node's static children.
 func (n *Node) addChild(node *Node) {
+	n.indices = append(n.indices, node.label[0])
 	n.children = append(n.children, node)
 }
 
+// walk calls fn for the node and all of its descendants.
+func (n *Node) walk(fn func(*Node)) {
+	fn(n)
+
+	for _, child := range n.children {
+		child.walk(fn)
+	}
+
+	for _, child := range n.paramChildren {
+		child.walk(fn)
+	}
+
+	if n.starChild != nil {
+		n.starChild.walk(fn)
+	}
+}
+
+// search searches the node's subtree for the given path, which is the rest of the path after the node's label.
+//
+// Static children have the highest priority, then path parameters and finally the star parameter.
+// Path parameters are stored in the given params while searching.
+func (n *Node) search(path string, params Params) *Node {
+	if path == "" && len(n.handlerMap) > 0 {
+		return n
+	}
+
+	// Static matching. An empty path can only match a star parameter preceded by a slash.
+	first := byte('/')
+	if path != "" {
+		first = path[0]
+	}
+
+	if child := n.getStaticChild(first); child != nil {
+		if strings.HasPrefix(path, child.label) {
+			if node := child.search(path[len(child.label):], params); node != nil {
+				return node
+			}
+		} else if child.starChild != nil && child.label == path+"/" {
+			// Star parameters also match the path without the trailing slash.
+			params[child.starChild.label] = ""
+			return child.starChild
+		}
+	}
+
+	// Param matching.
+	if len(n.paramChildren) > 0 {
+		end := strings.IndexByte(path, '/')
+		if end == -1 {
+			end = len(path)
+		}
+
+		for _, child := range n.paramChildren {
+			// Static text after the parameter in the same segment is tried first, with the longest value possible.
+			if child.hasSegmentChildren() {
+				for i := end - 1; i > 0; i-- {
+					if child.getStaticChild(path[i]) == nil {
+						continue
+					}
+
+					if node := child.searchParam(path, i, params); node != nil {
+						return node
+					}
+				}
+			}
+
+			if end > 0 {
+				if node := child.searchParam(path, end, params); node != nil {
+					return node
+				}
+			}
+		}
+	}
+
+	// Star matching.
+	if n.starChild != nil {
+		params[n.starChild.label] = path
+		return n.starChild
+	}
+
+	return nil
+}
+
+// searchParam searches the path parameter node's subtree with the first end bytes of the path as the parameter's value.
+func (n *Node) searchParam(path string, end int, params Params) *Node {
+	value := path[:end]
+	if !n.matchesConstraint(value) {
+		return nil
+	}
+
+	params[n.label] = value
+
+	if node := n.search(path[end:], params); node != nil {
+		return node
+	}
+
+	delete(params, n.label)
+
+	return nil
+}
+
+// hasSegmentChildren reports whether the path parameter node has static children in the same path segment,
+// e.g. the . in {name}.{ext}.
+func (n *Node) hasSegmentChildren() bool {
+	for _, b := range n.indices {
+		if b != '/' {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchesConstraint checks if the path parameter's value satisfies the node's constraint.
+func (n Node) matchesConstraint(value string) bool {
+	return n.matcher == nil || n.matcher.MatchString(value)
+}
+
+// checkConflicts panics if a route which only differs from the path in parameter names is already registered
+// for any of the methods, since only one of them could ever be matched.
+//
+// Registering the same path twice is reported by addHanlder.
+func (t *Tree) checkConflicts(pattern, path string, methods []string) {
+	if t.patterns == nil {
+		t.patterns = make(map[string]string)
+	}
+
+	for _, method := range methods {
+		key := method + " " + pattern
+
+		if registered, ok := t.patterns[key]; ok && registered != path {
+			panic(fmt.Sprintf("route %s conflicts with %s for method %s.", path, registered, method))
+		}
+	}
+
+	for _, method := range methods {
+		t.patterns[method+" "+pattern] = path
+	}
+}
+
 // addHanlders add handlers to their methods.
 func (n *Node) addHanlder(methods []string, hm handlerMiddleware) {
 	for _, v := range methods {
 		if _, ok := n.handlerMap[v]; ok {
-			panic(fmt.Sprintf("handler is already registered for method %s and node %+v.", v, n))
+			panic(fmt.Sprintf("handler is already registered for method %s and route %s.", v, hm.name))
 		}
 
 		n.handlerMap[v] = hm
@@ -200,17 +548,101 @@ func (n *Node) setLabel(label string) {
 		} else {
 			n.label = label[1 : len(label)-1]
 		}
+
+		// The constraint is split off first, so the optional marker and the default value are only read from the name,
+		// and constraints can end with them, e.g. {name:[a-z]+s?}.
+		name, constraint, _ := strings.Cut(n.label, constraintSeparator)
+
+		if label, value, ok := strings.Cut(name, defaultSeparator); ok {
+			name = label
+			n.optional = true
+			n.defaultValue = value
+		} else if label, ok := strings.CutSuffix(name, optionalSuffix); ok {
+			name = label
+			n.optional = true
+		}
+
+		n.label = name
+		if constraint != "" {
+			n.constraint = constraint
+			n.matcher = compileConstraint(constraint)
+		}
 	}
 }
 
+// compileConstraint returns the matcher of a path parameter constraint.
+//
+// Panics if the constraint is neither a built-in one nor a valid regular expression.
+func compileConstraint(constraint string) *regexp.Regexp {
+	if matcher, ok := paramConstraints[constraint]; ok {
+		return matcher
+	}
+
+	matcher, err := regexp.Compile("^(?:" + constraint + ")$")
+	if err != nil {
+		panic(fmt.Sprintf("invalid path parameter constraint %q: %s", constraint, err))
+	}
+
+	return matcher
+}
+
 // isParam determines if a label is a parameter.
 func isParam(label string) bool {
 	if strings.HasPrefix(label, paramPrefix) && strings.HasSuffix(label, paramSuffix) {
-		return true
+		return paramEnd(label) == len(label)-1
 	}
 	return false
 }
 
+// paramEnd returns the index of the brace which closes the path parameter at the start of s, or -1 if it's not closed.
+//
+// Braces are counted so constraints like {id:[0-9]{3}} are supported.
+func paramEnd(s string) int {
+	var depth int
+
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case paramPrefix[0]:
+			depth++
+		case paramSuffix[0]:
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+// splitSegment splits a path segment into its static and path parameter parts,
+// e.g. {name}.{ext} is split into {name}, . and {ext}.
+func splitSegment(segment string) []string {
+	var parts []string
+
+	for rest := segment; rest != ""; {
+		start := strings.Index(rest, paramPrefix)
+		if start == -1 {
+			return append(parts, rest)
+		}
+
+		if start > 0 {
+			parts = append(parts, rest[:start])
+			rest = rest[start:]
+		}
+
+		end := paramEnd(rest)
+		if end == -1 {
+			panic(fmt.Sprintf("path parameter in segment %s is not closed", segment))
+		}
+
+		parts = append(parts, rest[:end+1])
+		rest = rest[end+1:]
+	}
+
+	return parts
+}
+
 // isStar checks if a parameter is a star parameter.
 func isStar(label string) bool {
 	if isParam(label) && label[1] == '*' {
@@ -219,67 +651,96 @@ func isStar(label string) bool {
 	return false
 }
 
-// searchDFS searches the tree with the DFS search algorithm.
-func (t Tree) searchDFS(path []string) (map[string]handlerMiddleware, Params, bool) {
-	stack := []*Node{t.root}
-	visitedMap := map[uint32]bool{}
-	params := make(Params)
-	var pos int
-
-	// Search while stack is not empty.
-SearchLoop:
-	for len(stack) != 0 {
-		// Accessing last element.
-		node := stack[len(stack)-1]
+// searchCaseInsensitive is the case-insensitive version of search.
+//
+// Path with the case of the matched route is appended to buf and returned.
+func (n *Node) searchCaseInsensitive(path string, buf []byte) ([]byte, bool) {
+	if path == "" && len(n.handlerMap) > 0 {
+		return buf, true
+	}
 
-		if !visitedMap[node.id] {
-			visitedMap[node.id] = true
+	// Static matching.
+	for _, child := range n.children {
+		size := len(child.label)
 
-			if node.isParam {
-				params[node.label] = node.getPathParam(path, pos)
+		if len(path) >= size && strings.EqualFold(path[:size], child.label) {
+			if fixedPath, ok := child.searchCaseInsensitive(path[size:], append(buf, child.label...)); ok {
+				return fixedPath, true
 			}
+		} else if child.starChild != nil && strings.EqualFold(child.label, path+"/") {
+			return append(buf, child.label[:size-1]...), true
+		}
+	}
 
-			if node.searchFinished(path, pos) {
-				return node.handlerMap, params, true
-			}
+	// Param matching.
+	if len(n.paramChildren) > 0 {
+		end := strings.IndexByte(path, '/')
+		if end == -1 {
+			end = len(path)
 		}
 
-		for _, child := range node.children {
-			if !visitedMap[child.id] && child.doesMatch(path, pos+1) {
-				// Push child to the stack.
-				stack = append(stack, child)
-				pos++
-				continue SearchLoop
+		for _, child := range n.paramChildren {
+			if child.hasSegmentChildren() {
+				for i := end - 1; i > 0; i-- {
+					if fixedPath, ok := child.searchParamCaseInsensitive(path, i, buf); ok {
+						return fixedPath, true
+					}
+				}
 			}
-		}
 
-		if node.isParam {
-			delete(params, node.label)
+			if end > 0 {
+				if fixedPath, ok := child.searchParamCaseInsensitive(path, end, buf); ok {
+					return fixedPath, true
+				}
+			}
 		}
+	}
 
-		// Pop from stack.
-		stack = stack[:len(stack)-1]
-		pos--
+	// Star matching.
+	if n.starChild != nil {
+		return append(buf, path...), true
 	}
 
-	return nil, params, false
+	return nil, false
 }
 
-// search searches the Tree and tries to match the path to a handler if possible.
-func (t Tree) search(path, method string) (handlerMiddleware, Params, error) {
-	segments := strings.Split(path, "/")
+// searchParamCaseInsensitive is the case-insensitive version of searchParam.
+func (n *Node) searchParamCaseInsensitive(path string, end int, buf []byte) ([]byte, bool) {
+	value := path[:end]
+	if !n.matchesConstraint(value) {
+		return nil, false
+	}
 
-	hmMap, params, found := t.searchDFS(segments)
+	return n.searchCaseInsensitive(path[end:], append(buf, value...))
+}
+
+// match returns the node which matches the path or nil if there is no match.
+//
+// Path parameters are stored in the given params.
+func (t Tree) match(path string, params Params) *Node {
+	return t.root.search(path, params)
+}
 
-	if !found {
-		return handlerMiddleware{}, params, errNotFound
+// matchCaseInsensitive matches the path case-insensitively and returns the path with the case of the matched route.
+func (t Tree) matchCaseInsensitive(path string) (string, bool) {
+	fixedPath, ok := t.root.searchCaseInsensitive(path, make([]byte, 0, len(path)))
+	return string(fixedPath), ok
+}
+
+// search searches the Tree and tries to match the path to a handler if possible.
+//
+// Path parameters are stored in the given params.
+func (t Tree) search(path, method string, params Params) (handlerMiddleware, error) {
+	node := t.match(path, params)
+	if node == nil {
+		return handlerMiddleware{}, errNotFound
 	}
 
-	if hm, ok := hmMap[method]; ok {
-		return hm, params, nil
+	if hm, ok := node.handlerMap[method]; ok {
+		return hm, nil
 	}
 
-	return handlerMiddleware{}, params, errMethodNotAllowed
+	return handlerMiddleware{}, errMethodNotAllowed
 }
 
 // cleanPath normalizes the path.
@@ -309,3 +770,38 @@ func cleanPath(s string, soft bool) string {
 
 	return buff.String()
 }
+
+// cleanRequestPath returns the shortest equivalent of the request path.
+//
+// Unlike path.Clean, the trailing slash is kept.
+func cleanRequestPath(p string) string {
+	cleaned := path.Clean(cleanPath(p, true))
+
+	if strings.HasSuffix(p, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+
+	return cleaned
+}
+
+// toggleTrailingSlash removes the trailing slash from the path if it has one, otherwise adds it.
+func toggleTrailingSlash(path string) string {
+	if path == "/" {
+		return path
+	}
+
+	if strings.HasSuffix(path, "/") {
+		return path[:len(path)-1]
+	}
+
+	return path + "/"
+}
+
+// commonPrefixLen returns the length of the common prefix of the given strings.
+func commonPrefixLen(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
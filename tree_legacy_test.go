@@ -0,0 +1,169 @@
+package kid
+
+import (
+	"regexp"
+	"strings"
+)
+
+// dfsTree is the previous segment-based router implementation which was searched with DFS.
+//
+// It's only kept for benchmarking and verifying the radix tree against it.
+type dfsTree struct {
+	size uint32
+	root *dfsNode
+}
+
+// dfsNode is a node of dfsTree.
+type dfsNode struct {
+	id         uint32
+	label      string
+	children   []*dfsNode
+	isParam    bool
+	isStar     bool
+	constraint string
+	matcher    *regexp.Regexp
+	handlerMap map[string]handlerMiddleware
+}
+
+// newDFSTree returns a new dfsTree.
+func newDFSTree() dfsTree {
+	return dfsTree{size: 1, root: &dfsNode{id: 1, handlerMap: make(map[string]handlerMiddleware)}}
+}
+
+// insertNode inserts a new route into the tree.
+func (t *dfsTree) insertNode(path string, methods []string, handler HandlerFunc) {
+	path = cleanPath(path, false)
+
+	segments := strings.Split(path, "/")[1:]
+
+	currNode := t.root
+
+	for i, segment := range segments {
+		label := Node{isParam: isParam(segment), isStar: isStar(segment)}
+		label.setLabel(segment)
+
+		t.size++
+		node := &dfsNode{
+			id:         t.size,
+			label:      label.label,
+			isParam:    label.isParam,
+			isStar:     label.isStar,
+			constraint: label.constraint,
+			matcher:    label.matcher,
+			handlerMap: make(map[string]handlerMiddleware),
+		}
+
+		child := currNode.getChild(node)
+		if child == nil {
+			currNode.children = append(currNode.children, node)
+			child = node
+		}
+
+		if i == len(segments)-1 {
+			for _, method := range methods {
+				child.handlerMap[method] = handlerMiddleware{handler: handler, name: path}
+			}
+		}
+
+		currNode = child
+	}
+}
+
+// getChild returns the child which is the same as the given node.
+func (n *dfsNode) getChild(node *dfsNode) *dfsNode {
+	for _, child := range n.children {
+		if child.label == node.label && child.constraint == node.constraint && child.isParam == node.isParam && child.isStar == node.isStar {
+			return child
+		}
+	}
+	return nil
+}
+
+// doesMatch deterines if the path matches the node's label.
+func (n *dfsNode) doesMatch(path []string, pos int) bool {
+	if n.isStar {
+		return true
+	}
+
+	if pos >= len(path) {
+		return false
+	}
+
+	if n.isParam {
+		return path[pos] != "" && (n.matcher == nil || n.matcher.MatchString(path[pos]))
+	}
+
+	return path[pos] == n.label
+}
+
+// searchFinished returns true if the search has to be finished.
+func (n *dfsNode) searchFinished(path []string, pos int) bool {
+	if pos+1 == len(path) && len(n.handlerMap) > 0 {
+		return true
+	}
+	return n.isStar
+}
+
+// getPathParam returns the path parameter.
+func (n *dfsNode) getPathParam(path []string, pos int) string {
+	if n.isStar {
+		return strings.Join(path[pos:], "/")
+	}
+	return path[pos]
+}
+
+// searchDFS searches the tree with the DFS search algorithm.
+func (t dfsTree) searchDFS(path []string) (map[string]handlerMiddleware, Params, bool) {
+	stack := []*dfsNode{t.root}
+	visitedMap := map[uint32]bool{}
+	params := make(Params)
+	var pos int
+
+SearchLoop:
+	for len(stack) != 0 {
+		node := stack[len(stack)-1]
+
+		if !visitedMap[node.id] {
+			visitedMap[node.id] = true
+
+			if node.isParam {
+				params[node.label] = node.getPathParam(path, pos)
+			}
+
+			if node.searchFinished(path, pos) {
+				return node.handlerMap, params, true
+			}
+		}
+
+		for _, child := range node.children {
+			if !visitedMap[child.id] && child.doesMatch(path, pos+1) {
+				stack = append(stack, child)
+				pos++
+				continue SearchLoop
+			}
+		}
+
+		if node.isParam {
+			delete(params, node.label)
+		}
+
+		stack = stack[:len(stack)-1]
+		pos--
+	}
+
+	return nil, params, false
+}
+
+// search searches the tree and tries to match the path to a handler if possible.
+func (t dfsTree) search(path, method string) (handlerMiddleware, Params, error) {
+	hmMap, params, found := t.searchDFS(strings.Split(path, "/"))
+	if !found {
+		return handlerMiddleware{}, params, errNotFound
+	}
+
+	if hm, ok := hmMap[method]; ok {
+		return hm, params, nil
+	}
+
+	return handlerMiddleware{}, params, errMethodNotAllowed
+}
@@ -1,8 +1,8 @@
 package kid
 
 import (
-	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"testing"
 
@@ -29,16 +29,18 @@ func TestNewNode(t *testing.T) {
 
 	assert.Empty(t, node.handlerMap)
 	assert.Empty(t, node.children)
+	assert.Empty(t, node.paramChildren)
+	assert.Nil(t, node.starChild)
 }
 
 func TestNewTree(t *testing.T) {
 	tree := newTree()
 
 	assert.NotNil(t, tree.root)
-	assert.EqualValues(t, 1, tree.size)
+	assert.Equal(t, "", tree.root.label)
 }
 
-func TestNode_getChild(t *testing.T) {
+func TestNode_getStaticChild(t *testing.T) {
 	node := newNode()
 
 	childNode := newNode()
@@ -46,10 +48,22 @@ func TestNode_getChild(t *testing.T) {
 
 	node.addChild(&childNode)
 
-	assert.Equal(t, &childNode, node.getChild("test", childNode.isParam, childNode.isStar))
-	assert.Nil(t, node.getChild("test", !childNode.isParam, childNode.isStar))
-	assert.Nil(t, node.getChild("test", childNode.isParam, !childNode.isStar))
-	assert.Nil(t, node.getChild("test2", childNode.isParam, childNode.isStar))
+	assert.Equal(t, &childNode, node.getStaticChild('t'))
+	assert.Nil(t, node.getStaticChild('x'))
+}
+
+func TestNode_getParamChild(t *testing.T) {
+	node := newNode()
+
+	childNode := newNode()
+	childNode.isParam = true
+	childNode.setLabel("{id:int}")
+
+	node.insertParam(&childNode)
+
+	assert.Equal(t, &childNode, node.getParamChild("id", "int"))
+	assert.Nil(t, node.getParamChild("id", ""))
+	assert.Nil(t, node.getParamChild("name", "int"))
 }
 
 func TestNode_addChild(t *testing.T) {
@@ -60,20 +74,76 @@ func TestNode_addChild(t *testing.T) {
 
 	node.addChild(&childNode)
 	assert.Len(t, node.children, 1)
+	assert.Equal(t, []byte{'t'}, node.indices)
+}
+
+func TestNode_insertStatic(t *testing.T) {
+	node := newNode()
+
+	test := node.insertStatic("/test")
+	assert.Equal(t, "/test", test.label)
+	assert.Equal(t, test, node.insertStatic("/test"))
+
+	// Splitting the node.
+	team := node.insertStatic("/team")
+	assert.Equal(t, "am", team.label)
+	assert.Equal(t, "st", test.label)
+
+	te := node.getStaticChild('/')
+	assert.Equal(t, "/te", te.label)
+	assert.Equal(t, []*Node{test, team}, te.children)
+	assert.Equal(t, []byte{'s', 'a'}, te.indices)
+
+	// Path ends in the middle of a label.
+	tes := node.insertStatic("/tes")
+	assert.Equal(t, "s", tes.label)
+	assert.Equal(t, "t", test.label)
+	assert.Equal(t, []*Node{test}, tes.children)
+	assert.Equal(t, tes, te.getStaticChild('s'))
+
+	assert.Equal(t, &node, node.insertStatic(""))
+}
+
+func TestNode_insertParam(t *testing.T) {
+	node := newNode()
+
+	newParam := func(label string) *Node {
+		n := newNode()
+		n.isParam = true
+		n.isStar = isStar(label)
+		n.setLabel(label)
+		return &n
+	}
+
+	name := node.insertParam(newParam("{name}"))
+	id := node.insertParam(newParam("{id:int}"))
+	slug := node.insertParam(newParam("{slug:[a-z]+}"))
+
+	assert.Equal(t, []*Node{id, slug, name}, node.paramChildren)
+	assert.Equal(t, id, node.insertParam(newParam("{id:int}")))
+	assert.Len(t, node.paramChildren, 3)
+
+	star := node.insertParam(newParam("{*path}"))
+	assert.Equal(t, star, node.starChild)
+	assert.Equal(t, star, node.insertParam(newParam("{*path}")))
+
+	assert.PanicsWithValue(t, "star path parameter other conflicts with path", func() {
+		node.insertParam(newParam("{*other}"))
+	})
 }
 
 func TestNode_addHanlder(t *testing.T) {
 	node := newNode()
 
-	node.addHanlder([]string{http.MethodGet, http.MethodPost}, handlerMiddleware{})
+	node.addHanlder([]string{http.MethodGet, http.MethodPost}, handlerMiddleware{name: "/path"})
 
 	assert.Len(t, node.handlerMap, 2)
 
 	assert.PanicsWithValue(
 		t,
-		"handler is already registered for method GET and node &{id:0 label: children:[] isParam:false isStar:false handlerMap:map[GET:{handler:<nil> middlewares:[] name:} POST:{handler:<nil> middlewares:[] name:}]}.",
+		"handler is already registered for method GET and route /path.",
 		func() {
-			node.addHanlder([]string{http.MethodGet, http.MethodPost}, handlerMiddleware{})
+			node.addHanlder([]string{http.MethodGet, http.MethodPost}, handlerMiddleware{name: "/path"})
 		},
 	)
 }
@@ -86,6 +156,24 @@ func TestIsParam(t *testing.T) {
 	assert.False(t, isParam("param}"))
 
 	assert.False(t, isParam("{param"))
+
+	assert.False(t, isParam("{name}.{ext}"))
+
+	assert.True(t, isParam("{id:[0-9]{3}}"))
+}
+
+func TestSplitSegment(t *testing.T) {
+	assert.Nil(t, splitSegment(""))
+	assert.Equal(t, []string{"users"}, splitSegment("users"))
+	assert.Equal(t, []string{"{id}"}, splitSegment("{id}"))
+	assert.Equal(t, []string{"{name}", ".", "{ext}"}, splitSegment("{name}.{ext}"))
+	assert.Equal(t, []string{"v", "{version:int}"}, splitSegment("v{version:int}"))
+	assert.Equal(t, []string{"@", "{username}", "-profile"}, splitSegment("@{username}-profile"))
+	assert.Equal(t, []string{"{id:[0-9]{3}}", ".json"}, splitSegment("{id:[0-9]{3}}.json"))
+
+	assert.PanicsWithValue(t, "path parameter in segment a{b is not closed", func() {
+		splitSegment("a{b")
+	})
 }
 
 func TestIsStar(t *testing.T) {
@@ -97,51 +185,52 @@ func TestIsStar(t *testing.T) {
 func TestTree_insertNode(t *testing.T) {
 	tree := newTree()
 
-	tree.insertNode("/test/path", []string{http.MethodGet}, nil, testHandlerFunc)
+	node := tree.insertNode("/test/path", []string{http.MethodGet}, nil, testHandlerFunc)
 
-	assert.False(t, tree.root.isParam)
-	assert.False(t, tree.root.isStar)
 	assert.Equal(t, "", tree.root.label)
-	assert.EqualValues(t, 1, tree.root.id)
 
-	child := tree.root.getChild("test", false, false)
+	child := tree.root.getStaticChild('/')
+	assert.Equal(t, child, node)
 	assert.False(t, child.isParam)
 	assert.False(t, child.isStar)
-	assert.Equal(t, "test", child.label)
-	assert.EqualValues(t, 2, child.id)
-
-	_, ok := child.handlerMap[http.MethodGet]
-	assert.False(t, ok)
-
-	child2 := child.getChild("path", false, false)
-	assert.False(t, child2.isParam)
-	assert.False(t, child2.isStar)
-	assert.Equal(t, "path", child2.label)
-	assert.EqualValues(t, 3, child2.id)
+	assert.Equal(t, "/test/path", child.label)
 
-	hm, ok := child2.handlerMap[http.MethodGet]
+	hm, ok := child.handlerMap[http.MethodGet]
 	assert.True(t, ok)
 	assert.True(t, funcsAreEqual(hm.handler, testHandlerFunc))
 	assert.Nil(t, hm.middlewares)
 	assert.Equal(t, "/test/path", hm.name)
 
-	tree.insertNode("/test", []string{http.MethodPost}, []MiddlewareFunc{testMiddlewareFunc}, testHandlerFunc)
+	node = tree.insertNode("/test", []string{http.MethodPost}, []MiddlewareFunc{testMiddlewareFunc}, testHandlerFunc)
 
-	assert.False(t, tree.root.isParam)
-	assert.False(t, tree.root.isStar)
-	assert.Equal(t, "", tree.root.label)
-
-	child = tree.root.getChild("test", false, false)
-	assert.False(t, child.isParam)
-	assert.False(t, child.isStar)
-	assert.Equal(t, "test", child.label)
-	assert.EqualValues(t, 2, child.id)
+	child = tree.root.getStaticChild('/')
+	assert.Equal(t, child, node)
+	assert.Equal(t, "/test", child.label)
 
 	hm, ok = child.handlerMap[http.MethodPost]
 	assert.True(t, ok)
 	assert.True(t, funcsAreEqual(hm.handler, testHandlerFunc))
 	assert.Len(t, hm.middlewares, 1)
 	assert.Equal(t, "/test", hm.name)
+
+	child2 := child.getStaticChild('/')
+	assert.Equal(t, "/path", child2.label)
+
+	_, ok = child2.handlerMap[http.MethodGet]
+	assert.True(t, ok)
+
+	node = tree.insertNode("/test/{id:int}/{*path}", []string{http.MethodGet}, nil, testHandlerFunc)
+
+	param := child.getStaticChild('/').getParamChild("id", "int")
+	assert.True(t, param.isParam)
+	assert.False(t, param.isStar)
+
+	star := param.getStaticChild('/').starChild
+	assert.Equal(t, star, node)
+	assert.True(t, star.isParam)
+	assert.True(t, star.isStar)
+	assert.Equal(t, "path", star.label)
+	assert.Equal(t, "/test/{id:int}/{*path}", star.handlerMap[http.MethodGet].name)
 }
 
 func TestNode_insert_Panics(t *testing.T) {
@@ -158,6 +247,27 @@ func TestNode_insert_Panics(t *testing.T) {
 	assert.PanicsWithValue(t, "star path parameters can only be the last part of a path", func() {
 		tree.insertNode("/{*starParam}/test", []string{http.MethodGet}, nil, testHandlerFunc)
 	})
+
+	assert.PanicsWithValue(t, "star path parameter other conflicts with path", func() {
+		tree.insertNode("/files/{*path}", []string{http.MethodGet}, nil, testHandlerFunc)
+		tree.insertNode("/files/{*other}", []string{http.MethodPost}, nil, testHandlerFunc)
+	})
+
+	assert.PanicsWithValue(t, "star path parameters cannot have constraints", func() {
+		tree.insertNode("/{*starParam:int}", []string{http.MethodGet}, nil, testHandlerFunc)
+	})
+
+	assert.PanicsWithValue(t, "path parameters in segment {name}{ext} must be separated by static text", func() {
+		tree.insertNode("/files/{name}{ext}", []string{http.MethodGet}, nil, testHandlerFunc)
+	})
+
+	assert.PanicsWithValue(t, "star path parameters can only be the last part of a path", func() {
+		tree.insertNode("/static/{*path}.css", []string{http.MethodGet}, nil, testHandlerFunc)
+	})
+
+	assert.PanicsWithValue(t, "invalid path parameter constraint \"[a-z\": error parsing regexp: missing closing ]: `[a-z)$`", func() {
+		tree.insertNode("/{param:[a-z}", []string{http.MethodGet}, nil, testHandlerFunc)
+	})
 }
 
 func TestNode_setLabel(t *testing.T) {
@@ -171,117 +281,499 @@ func TestNode_setLabel(t *testing.T) {
 	n.setLabel("{param}")
 	assert.Equal(t, "param", n.label)
 
+	n.setLabel("{id:int}")
+	assert.Equal(t, "id", n.label)
+	assert.Equal(t, "int", n.constraint)
+	assert.Equal(t, paramConstraints["int"], n.matcher)
+
+	n.setLabel("{slug:[a-z0-9-]+}")
+	assert.Equal(t, "slug", n.label)
+	assert.Equal(t, "[a-z0-9-]+", n.constraint)
+	assert.Equal(t, "^(?:[a-z0-9-]+)$", n.matcher.String())
+
 	n.isStar = true
 	n.setLabel("{*starParam}")
 	assert.Equal(t, "starParam", n.label)
+
+	n = Node{isParam: true}
+	n.setLabel("{year?}")
+	assert.Equal(t, "year", n.label)
+	assert.True(t, n.optional)
+	assert.Empty(t, n.defaultValue)
+
+	n = Node{isParam: true}
+	n.setLabel("{page=1:int}")
+	assert.Equal(t, "page", n.label)
+	assert.Equal(t, "int", n.constraint)
+	assert.True(t, n.optional)
+	assert.Equal(t, "1", n.defaultValue)
+
+	n = Node{isParam: true}
+	n.setLabel("{year?:int}")
+	assert.Equal(t, "year", n.label)
+	assert.Equal(t, "int", n.constraint)
+	assert.True(t, n.optional)
+	assert.Empty(t, n.defaultValue)
+
+	// Markers at the end of constraints are part of the regular expressions.
+	n = Node{isParam: true}
+	n.setLabel("{name:[a-z]+s?}")
+	assert.Equal(t, "name", n.label)
+	assert.Equal(t, "[a-z]+s?", n.constraint)
+	assert.False(t, n.optional)
+
+	n = Node{isParam: true}
+	n.setLabel("{key:[a-z]+=[0-9]+}")
+	assert.Equal(t, "key", n.label)
+	assert.Equal(t, "[a-z]+=[0-9]+", n.constraint)
+	assert.False(t, n.optional)
+	assert.Empty(t, n.defaultValue)
 }
 
-func TestCleanPath(t *testing.T) {
-	slash := cleanPath("", true)
+func TestTree_insertNode_Optional(t *testing.T) {
+	tree := newTree()
 
-	assert.Equal(t, "/", slash)
+	node := tree.insertNode("/archive/{year?:int}/{month=1}", []string{http.MethodGet}, nil, testHandlerFunc)
 
-	prefixSlash := cleanPath("test", true)
+	testCases := []struct {
+		path           string
+		expectedParams Params
+		expectedAlt    bool
+		expectedDefs   Params
+	}{
+		{path: "/archive", expectedParams: Params{}, expectedAlt: true, expectedDefs: Params{"month": "1"}},
+		{path: "/archive/2024", expectedParams: Params{"year": "2024"}, expectedAlt: true, expectedDefs: Params{"month": "1"}},
+		{path: "/archive/2024/5", expectedParams: Params{"year": "2024", "month": "5"}, expectedDefs: nil},
+	}
 
-	assert.Equal(t, "/test", prefixSlash)
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			params := make(Params)
+			hm, err := tree.search(tc.path, http.MethodGet, params)
 
-	cleanedPath := cleanPath("//api///v1////books/offer", false)
+			assert.NoError(t, err)
+			assert.Equal(t, "/archive/{year?:int}/{month=1}", hm.name)
+			assert.Equal(t, tc.expectedParams, params)
+			assert.Equal(t, tc.expectedAlt, hm.alternate)
+			assert.Equal(t, tc.expectedDefs, hm.defaults)
+		})
+	}
 
-	assert.Equal(t, "/api/v1/books/offer", cleanedPath)
+	params := make(Params)
+	assert.Equal(t, node, tree.match("/archive/2024/5", params))
+
+	_, err := tree.search("/archive/abc", http.MethodGet, make(Params))
+	assert.ErrorIs(t, err, errNotFound)
+
+	tree.insertNode("/{page?}", []string{http.MethodGet}, nil, testHandlerFunc)
+
+	hm, err := tree.search("/", http.MethodGet, make(Params))
+	assert.NoError(t, err)
+	assert.Equal(t, "/{page?}", hm.name)
+}
+
+func TestTree_insertNode_ConstraintEndsWithOptionalMarker(t *testing.T) {
+	tree := newTree()
+
+	tree.insertNode("/items/{name:[a-z]+s?}", []string{http.MethodGet}, nil, testHandlerFunc)
+
+	_, err := tree.search("/items", http.MethodGet, make(Params))
+	assert.ErrorIs(t, err, errNotFound)
+
+	for _, name := range []string{"cat", "cats"} {
+		params := make(Params)
+		hm, err := tree.search("/items/"+name, http.MethodGet, params)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "/items/{name:[a-z]+s?}", hm.name)
+		assert.Equal(t, Params{"name": name}, params)
+	}
 }
 
-func TestDFS(t *testing.T) {
+func TestTree_insertNode_Optional_Panics(t *testing.T) {
 	tree := newTree()
 
+	tree.insertNode("/reports", []string{http.MethodGet}, nil, testHandlerFunc)
+	tree.insertNode("/users/{id}", []string{http.MethodGet}, nil, testHandlerFunc)
+
+	assert.PanicsWithValue(t, "optional path parameter year can only be followed by optional path parameters", func() {
+		tree.insertNode("/archive/{year?}/summary", []string{http.MethodGet}, nil, testHandlerFunc)
+	})
+
+	assert.PanicsWithValue(t, "optional path parameter ext must be a whole path segment", func() {
+		tree.insertNode("/files/{name}.{ext?}", []string{http.MethodGet}, nil, testHandlerFunc)
+	})
+
+	assert.PanicsWithValue(t, "star path parameters cannot be optional", func() {
+		tree.insertNode("/static/{*path?}", []string{http.MethodGet}, nil, testHandlerFunc)
+	})
+
+	assert.PanicsWithValue(t, "default value \"first\" of path parameter page must match int", func() {
+		tree.insertNode("/posts/{page=first:int}", []string{http.MethodGet}, nil, testHandlerFunc)
+	})
+
+	assert.PanicsWithValue(t, "route /reports/{year?} conflicts with /reports for method GET.", func() {
+		tree.insertNode("/reports/{year?}", []string{http.MethodGet}, nil, testHandlerFunc)
+	})
+
+	assert.PanicsWithValue(t, "route /users/{userID?} conflicts with /users/{id} for method GET.", func() {
+		tree.insertNode("/users/{userID?}", []string{http.MethodGet}, nil, testHandlerFunc)
+	})
+}
+
+func TestNode_matchesConstraint(t *testing.T) {
+	testCases := []struct {
+		constraint, value string
+		expected          bool
+	}{
+		{constraint: "", value: "anything", expected: true},
+		{constraint: "int", value: "-12", expected: true},
+		{constraint: "int", value: "12a", expected: false},
+		{constraint: "uint", value: "12", expected: true},
+		{constraint: "uint", value: "-12", expected: false},
+		{constraint: "alpha", value: "abc", expected: true},
+		{constraint: "alpha", value: "abc1", expected: false},
+		{constraint: "alnum", value: "abc1", expected: true},
+		{constraint: "alnum", value: "abc-1", expected: false},
+		{constraint: "uuid", value: "123e4567-e89b-12d3-a456-426614174000", expected: true},
+		{constraint: "uuid", value: "123e4567-e89b-12d3-a456", expected: false},
+		{constraint: "[a-z]+", value: "abc", expected: true},
+		{constraint: "[a-z]+", value: "abc1", expected: false},
+		{constraint: "a|b", value: "ab", expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.constraint+"_"+tc.value, func(t *testing.T) {
+			node := newNode()
+			node.isParam = true
+			node.setLabel("{param:" + tc.constraint + "}")
+
+			assert.Equal(t, tc.expected, node.matchesConstraint(tc.value))
+		})
+	}
+}
+
+func TestTree_search_Constraints(t *testing.T) {
+	tree := newTree()
+
+	tree.insertNode("/users/{id:int}", []string{http.MethodGet}, nil, testHandlerFunc)
+	tree.insertNode("/users/{id:uuid}/posts", []string{http.MethodGet}, nil, testHandlerFunc)
+	tree.insertNode("/users/{slug:[a-z-]+}", []string{http.MethodGet}, nil, testHandlerFunc)
+
+	testCases := []struct {
+		path          string
+		expectedRoute string
+		expectedParam Params
+		expectedErr   error
+	}{
+		{path: "/users/12", expectedRoute: "/users/{id:int}", expectedParam: Params{"id": "12"}},
+		{path: "/users/john-doe", expectedRoute: "/users/{slug:[a-z-]+}", expectedParam: Params{"slug": "john-doe"}},
+		{
+			path:          "/users/123e4567-e89b-12d3-a456-426614174000/posts",
+			expectedRoute: "/users/{id:uuid}/posts",
+			expectedParam: Params{"id": "123e4567-e89b-12d3-a456-426614174000"},
+		},
+		{path: "/users/123e4567-e89b-12d3-a456-426614174000", expectedErr: errNotFound, expectedParam: Params{}},
+		{path: "/users/John_Doe", expectedErr: errNotFound, expectedParam: Params{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			params := make(Params)
+			hm, err := tree.search(tc.path, http.MethodGet, params)
+
+			assert.ErrorIs(t, err, tc.expectedErr)
+			assert.Equal(t, tc.expectedRoute, hm.name)
+			assert.Equal(t, tc.expectedParam, params)
+		})
+	}
+}
+
+func TestTree_checkConflicts(t *testing.T) {
+	tree := newTree()
+
+	tree.insertNode("/files/{name}.{ext}", []string{http.MethodGet}, nil, testHandlerFunc)
+	tree.insertNode("/users/{id:int}", []string{http.MethodGet}, nil, testHandlerFunc)
+
+	assert.PanicsWithValue(t, "route /files/{base}.{ext} conflicts with /files/{name}.{ext} for method GET.", func() {
+		tree.insertNode("/files/{base}.{ext}", []string{http.MethodPost, http.MethodGet}, nil, testHandlerFunc)
+	})
+
+	assert.PanicsWithValue(t, "route /users/{userID:int} conflicts with /users/{id:int} for method GET.", func() {
+		tree.insertNode("/users/{userID:int}", []string{http.MethodGet}, nil, testHandlerFunc)
+	})
+
+	assert.PanicsWithValue(t, "handler is already registered for method GET and route /files/{name}.{ext}.", func() {
+		tree.insertNode("/files/{name}.{ext}", []string{http.MethodGet}, nil, testHandlerFunc)
+	})
+
+	assert.NotPanics(t, func() {
+		tree.insertNode("/files/{base}.{ext}", []string{http.MethodPut}, nil, testHandlerFunc)
+		tree.insertNode("/users/{userID:uuid}", []string{http.MethodGet}, nil, testHandlerFunc)
+		tree.insertNode("/files/{name}.{ext}/", []string{http.MethodGet}, nil, testHandlerFunc)
+	})
+}
+
+func TestTree_search_MixedSegments(t *testing.T) {
+	tree := newTree()
+
+	tree.insertNode("/files/{name}.{ext}", []string{http.MethodGet}, nil, testHandlerFunc)
+	tree.insertNode("/files/{name}.json", []string{http.MethodGet}, nil, testHandlerFunc)
+	tree.insertNode("/files/{name}", []string{http.MethodGet}, nil, testHandlerFunc)
+	tree.insertNode("/v{version:int}/items", []string{http.MethodGet}, nil, testHandlerFunc)
+	tree.insertNode("/v{version}-beta/items", []string{http.MethodGet}, nil, testHandlerFunc)
+	tree.insertNode("/@{username}", []string{http.MethodGet}, nil, testHandlerFunc)
+	tree.insertNode("/{page}", []string{http.MethodGet}, nil, testHandlerFunc)
+	tree.insertNode("/static-{*path}", []string{http.MethodGet}, nil, testHandlerFunc)
+
+	testCases := []struct {
+		path           string
+		expectedRoute  string
+		expectedParams Params
+	}{
+		{path: "/files/report.pdf", expectedRoute: "/files/{name}.{ext}", expectedParams: Params{"name": "report", "ext": "pdf"}},
+		{path: "/files/archive.tar.gz", expectedRoute: "/files/{name}.{ext}", expectedParams: Params{"name": "archive.tar", "ext": "gz"}},
+		{path: "/files/data.json", expectedRoute: "/files/{name}.json", expectedParams: Params{"name": "data"}},
+		{path: "/files/README", expectedRoute: "/files/{name}", expectedParams: Params{"name": "README"}},
+		{path: "/files/.env", expectedRoute: "/files/{name}", expectedParams: Params{"name": ".env"}},
+		{path: "/files/README.", expectedRoute: "/files/{name}", expectedParams: Params{"name": "README."}},
+		{path: "/v2/items", expectedRoute: "/v{version:int}/items", expectedParams: Params{"version": "2"}},
+		{path: "/v2-beta/items", expectedRoute: "/v{version}-beta/items", expectedParams: Params{"version": "2"}},
+		{path: "/vx/items", expectedParams: Params{}},
+		{path: "/@john", expectedRoute: "/@{username}", expectedParams: Params{"username": "john"}},
+		{path: "/@", expectedRoute: "/{page}", expectedParams: Params{"page": "@"}},
+		{path: "/about", expectedRoute: "/{page}", expectedParams: Params{"page": "about"}},
+		{path: "/static-css/app.css", expectedRoute: "/static-{*path}", expectedParams: Params{"path": "css/app.css"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			params := make(Params)
+			hm, err := tree.search(tc.path, http.MethodGet, params)
+
+			assert.Equal(t, tc.expectedParams, params)
+
+			if tc.expectedRoute == "" {
+				assert.ErrorIs(t, err, errNotFound)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedRoute, hm.name)
+		})
+	}
+}
+
+func TestNode_search(t *testing.T) {
+	tree := newTree()
+
+	tree.insertNode("/", []string{http.MethodGet}, nil, testHandlerFunc)
 	tree.insertNode("/{path}/path1", []string{http.MethodGet}, nil, testHandlerFunc)
 	tree.insertNode("/{path}/path2", []string{http.MethodPost}, nil, testHandlerFunc)
 	tree.insertNode("/{path}/path2/", []string{http.MethodDelete}, nil, testHandlerFunc)
 	tree.insertNode("/path/test", []string{http.MethodPut}, nil, testHandlerFunc)
 	tree.insertNode("/path1/{*starParam}", []string{http.MethodPatch}, nil, testHandlerFunc)
+	tree.insertNode("/path1/static/{param}", []string{http.MethodPatch}, nil, testHandlerFunc)
+	tree.insertNode("/users/{id}/posts/{postID}", []string{http.MethodGet}, nil, testHandlerFunc)
+	tree.insertNode("/users/me/posts/{postID}", []string{http.MethodGet}, nil, testHandlerFunc)
+	tree.insertNode("/users/{*path}", []string{http.MethodGet}, nil, testHandlerFunc)
 
 	testCases := []struct {
-		method         string
-		path           []string
+		path           string
+		expectedRoute  string
 		expectedParams Params
-		found          bool
 	}{
-		{method: http.MethodGet, path: []string{"", "param1", "path1"}, expectedParams: Params{"path": "param1"}, found: true},
-		{method: http.MethodPost, path: []string{"", "param2", "path2"}, expectedParams: Params{"path": "param2"}, found: true},
-		{method: http.MethodDelete, path: []string{"", "param", "path2", ""}, expectedParams: Params{"path": "param"}, found: true},
-		{method: http.MethodPut, path: []string{"", "path", "test"}, expectedParams: Params{}, found: true},
-		{method: http.MethodGet, path: []string{"", "path"}, expectedParams: Params{}, found: false},
-		{method: http.MethodPatch, path: []string{"", "path1", "param1"}, expectedParams: Params{"starParam": "param1"}, found: true},
-		{method: http.MethodPatch, path: []string{"", "path1", "param1", "param2"}, expectedParams: Params{"starParam": "param1/param2"}, found: true},
-		{method: http.MethodPatch, path: []string{"", "path1"}, expectedParams: Params{"starParam": ""}, found: true},
+		{path: "/", expectedRoute: "/", expectedParams: Params{}},
+		{path: "/param1/path1", expectedRoute: "/{path}/path1", expectedParams: Params{"path": "param1"}},
+		{path: "/param2/path2", expectedRoute: "/{path}/path2", expectedParams: Params{"path": "param2"}},
+		{path: "/param/path2/", expectedRoute: "/{path}/path2/", expectedParams: Params{"path": "param"}},
+		{path: "/path/test", expectedRoute: "/path/test", expectedParams: Params{}},
+		{path: "/path/path1", expectedRoute: "/{path}/path1", expectedParams: Params{"path": "path"}},
+		{path: "/path", expectedParams: Params{}},
+		{path: "//path1", expectedParams: Params{}},
+		{path: "/path1/param1", expectedRoute: "/path1/{*starParam}", expectedParams: Params{"starParam": "param1"}},
+		{path: "/path1/param1/param2", expectedRoute: "/path1/{*starParam}", expectedParams: Params{"starParam": "param1/param2"}},
+		{path: "/path1/", expectedRoute: "/path1/{*starParam}", expectedParams: Params{"starParam": ""}},
+		{path: "/path1", expectedRoute: "/path1/{*starParam}", expectedParams: Params{"starParam": ""}},
+		{path: "/path1/static/x", expectedRoute: "/path1/static/{param}", expectedParams: Params{"param": "x"}},
+		{path: "/path1/static", expectedRoute: "/path1/{*starParam}", expectedParams: Params{"starParam": "static"}},
+		{path: "/path1/static/", expectedRoute: "/path1/{*starParam}", expectedParams: Params{"starParam": "static/"}},
+		{path: "/users/me/posts/1", expectedRoute: "/users/me/posts/{postID}", expectedParams: Params{"postID": "1"}},
+		{path: "/users/12/posts/1", expectedRoute: "/users/{id}/posts/{postID}", expectedParams: Params{"id": "12", "postID": "1"}},
+		{path: "/users/me/comments", expectedRoute: "/users/{*path}", expectedParams: Params{"path": "me/comments"}},
+		{path: "/users/12/posts", expectedRoute: "/users/{*path}", expectedParams: Params{"path": "12/posts"}},
 	}
 
-	for i, testCase := range testCases {
-		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
-			hmMap, params, found := tree.searchDFS(testCase.path)
-			hm, ok := hmMap[testCase.method]
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			params := make(Params)
+			node := tree.root.search(tc.path, params)
 
-			assert.Equal(t, testCase.found, found)
-			assert.Equal(t, testCase.found, ok)
-			assert.Equal(t, testCase.expectedParams, params)
+			assert.Equal(t, tc.expectedParams, params)
 
-			if testCase.found {
-				assert.NotEmpty(t, hm)
-			} else {
-				assert.Empty(t, hm)
+			if tc.expectedRoute == "" {
+				assert.Nil(t, node)
+				return
+			}
+
+			for _, hm := range node.handlerMap {
+				assert.Equal(t, tc.expectedRoute, hm.name)
 			}
 		})
 	}
 }
 
-func TestNode_getPathParam(t *testing.T) {
-	node := newNode()
-	path := []string{"", "param", "path"}
+func TestTree_search_Legacy(t *testing.T) {
+	tree := newTree()
+	legacyTree := newDFSTree()
+
+	for _, route := range benchmarkRoutes {
+		tree.insertNode(route, []string{http.MethodGet}, nil, testHandlerFunc)
+		legacyTree.insertNode(route, []string{http.MethodGet}, testHandlerFunc)
+	}
+
+	paths := append([]string{"/", "/unknown", "/users", "/users/1/unknown", "/static/"}, benchmarkPaths...)
 
-	assert.Equal(t, "param", node.getPathParam(path, 1))
+	for _, path := range paths {
+		t.Run(path, func(t *testing.T) {
+			params := make(Params)
+			hm, err := tree.search(path, http.MethodGet, params)
 
-	node.isStar = true
+			expectedHm, expectedParams, expectedErr := legacyTree.search(path, http.MethodGet)
 
-	assert.Equal(t, "param/path", node.getPathParam(path, 1))
+			assert.Equal(t, expectedErr, err)
+			assert.Equal(t, expectedHm.name, hm.name)
+			assert.Equal(t, expectedParams, params)
+		})
+	}
 }
 
-func TestNode_searchFinished(t *testing.T) {
-	node := newNode()
-	path := []string{"", "param", "path"}
+func TestTree_search_Allocations(t *testing.T) {
+	tree := newTree()
+
+	for _, route := range benchmarkRoutes {
+		tree.insertNode(route, []string{http.MethodGet}, nil, testHandlerFunc)
+	}
 
-	assert.False(t, node.searchFinished(path, 2))
-	assert.False(t, node.searchFinished(path, 1))
+	params := make(Params)
 
-	node.handlerMap[http.MethodGet] = handlerMiddleware{}
+	allocs := testing.AllocsPerRun(100, func() {
+		for _, path := range benchmarkPaths {
+			clear(params)
+			tree.search(path, http.MethodGet, params)
+		}
+	})
 
-	assert.True(t, node.searchFinished(path, 2))
-	assert.False(t, node.searchFinished(path, 1))
+	assert.Zero(t, allocs)
+}
 
-	node.isStar = true
+// benchmarkRoutes are the routes used in benchmarks.
+//
+// Static routes are inserted before the parameterized ones to
+// have the same priority in both the radix tree and the legacy tree.
+var benchmarkRoutes = []string{
+	"/",
+	"/health",
+	"/users/me",
+	"/users/me/settings",
+	"/users/{id}",
+	"/users/{id}/posts",
+	"/users/{id}/posts/{postID}",
+	"/users/{id}/posts/{postID}/comments/{commentID}",
+	"/articles/{id:int}",
+	"/articles/{slug:[a-z-]+}",
+	"/api/v1/orders/{orderID}/items/{itemID}",
+	"/api/v1/products/{productID}/reviews",
+	"/static/{*filePath}",
+}
 
-	assert.True(t, node.searchFinished(path, 2))
-	assert.True(t, node.searchFinished(path, 1))
+// benchmarkPaths are the request paths used in benchmarks.
+var benchmarkPaths = []string{
+	"/health",
+	"/users/me/settings",
+	"/users/12",
+	"/users/12/posts/4",
+	"/users/12/posts/4/comments/9",
+	"/articles/42",
+	"/articles/hello-world",
+	"/api/v1/orders/1/items/2",
+	"/api/v1/products/7/reviews",
+	"/static/css/main.css",
 }
 
-func TestNode_doesMatch(t *testing.T) {
-	node := newNode()
-	node.isStar = true
-	node.label = "lbl"
+func BenchmarkTree_search(b *testing.B) {
+	tree := newTree()
 
-	assert.True(t, node.doesMatch([]string{"0", "1", "2"}, 0))
+	for _, route := range benchmarkRoutes {
+		tree.insertNode(route, []string{http.MethodGet}, nil, testHandlerFunc)
+	}
 
-	node.isStar = false
+	params := make(Params)
 
-	assert.False(t, node.doesMatch([]string{"0", "1", "2"}, 3))
+	b.ReportAllocs()
+	b.ResetTimer()
 
-	node.isParam = true
+	for i := 0; i < b.N; i++ {
+		for _, path := range benchmarkPaths {
+			clear(params)
+			tree.search(path, http.MethodGet, params)
+		}
+	}
+}
 
-	assert.True(t, node.doesMatch([]string{"0", "1", "2"}, 2))
-	assert.False(t, node.doesMatch([]string{"0", "1", ""}, 2))
+func BenchmarkTree_search_Legacy(b *testing.B) {
+	tree := newDFSTree()
 
-	node.isParam = false
+	for _, route := range benchmarkRoutes {
+		tree.insertNode(route, []string{http.MethodGet}, testHandlerFunc)
+	}
 
-	assert.True(t, node.doesMatch([]string{"0", "1", "lbl"}, 2))
-	assert.False(t, node.doesMatch([]string{"0", "1", "invalid"}, 2))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for _, path := range benchmarkPaths {
+			tree.search(path, http.MethodGet)
+		}
+	}
+}
+
+func BenchmarkKid_ServeHTTP(b *testing.B) {
+	k := New()
+
+	for _, route := range benchmarkRoutes {
+		k.Get(route, testHandlerFunc)
+	}
+
+	requests := make([]*http.Request, len(benchmarkPaths))
+	for i, path := range benchmarkPaths {
+		requests[i] = httptest.NewRequest(http.MethodGet, path, nil)
+	}
+
+	res := httptest.NewRecorder()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for _, req := range requests {
+			k.ServeHTTP(res, req)
+		}
+	}
+}
+
+func TestCleanPath(t *testing.T) {
+	slash := cleanPath("", true)
+
+	assert.Equal(t, "/", slash)
+
+	prefixSlash := cleanPath("test", true)
+
+	assert.Equal(t, "/test", prefixSlash)
+
+	cleanedPath := cleanPath("//api///v1////books/offer", false)
+
+	assert.Equal(t, "/api/v1/books/offer", cleanedPath)
 }
 
 func TestTree_search(t *testing.T) {
@@ -301,7 +793,8 @@ func TestTree_search(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			hm, params, err := tree.search(tc.path, tc.method)
+			params := make(Params)
+			hm, err := tree.search(tc.path, tc.method, params)
 
 			assert.ErrorIs(t, err, tc.expectedErr)
 			assert.Equal(t, tc.expectedParams, params)
@@ -314,3 +807,49 @@ func TestTree_search(t *testing.T) {
 		})
 	}
 }
+
+func TestTree_matchCaseInsensitive(t *testing.T) {
+	tree := newTree()
+
+	tree.insertNode("/users/{id:int}/Posts", []string{http.MethodGet}, nil, testHandlerFunc)
+	tree.insertNode("/users/me", []string{http.MethodGet}, nil, testHandlerFunc)
+	tree.insertNode("/Files/{*path}", []string{http.MethodGet}, nil, testHandlerFunc)
+	tree.insertNode("/Docs/{name}.PDF", []string{http.MethodGet}, nil, testHandlerFunc)
+
+	testCases := []struct {
+		path, expectedPath string
+		found              bool
+	}{
+		{path: "/USERS/12/posts", expectedPath: "/users/12/Posts", found: true},
+		{path: "/Users/ME", expectedPath: "/users/me", found: true},
+		{path: "/files/A/b", expectedPath: "/Files/A/b", found: true},
+		{path: "/files", expectedPath: "/Files", found: true},
+		{path: "/docs/Report.pdf", expectedPath: "/Docs/Report.PDF", found: true},
+		{path: "/users/abc/posts", found: false},
+		{path: "/unknown", found: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			fixedPath, found := tree.matchCaseInsensitive(tc.path)
+
+			assert.Equal(t, tc.found, found)
+			assert.Equal(t, tc.expectedPath, fixedPath)
+		})
+	}
+}
+
+func TestCleanRequestPath(t *testing.T) {
+	assert.Equal(t, "/", cleanRequestPath(""))
+	assert.Equal(t, "/", cleanRequestPath("//"))
+	assert.Equal(t, "/users", cleanRequestPath("//users"))
+	assert.Equal(t, "/users/", cleanRequestPath("/users//"))
+	assert.Equal(t, "/b", cleanRequestPath("/a/../b"))
+	assert.Equal(t, "/a/b", cleanRequestPath("users/../a/./b"))
+}
+
+func TestToggleTrailingSlash(t *testing.T) {
+	assert.Equal(t, "/", toggleTrailingSlash("/"))
+	assert.Equal(t, "/users", toggleTrailingSlash("/users/"))
+	assert.Equal(t, "/users/", toggleTrailingSlash("/users"))
+}
@@ -11,6 +11,17 @@ func WrapHandlerFunc(f http.HandlerFunc) HandlerFunc {
 	}
 }
 
+// WrapErrHandlerFunc wraps a kid.ErrHandlerFunc and returns a kid.HandlerFunc.
+//
+// The returned error is reported with Context.Error.
+func WrapErrHandlerFunc(f ErrHandlerFunc) HandlerFunc {
+	return func(c *Context) {
+		if err := f(c); err != nil {
+			c.Error(err)
+		}
+	}
+}
+
 // WrapHandler wraps a http.Handler and returns a kid.HandlerFunc.
 func WrapHandler(h http.Handler) HandlerFunc {
 	return func(c *Context) {
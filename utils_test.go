@@ -1,6 +1,7 @@
 package kid
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -44,3 +45,32 @@ func TestWrapHandlerFunc(t *testing.T) {
 	assert.Equal(t, http.StatusOK, res.Code)
 	assert.Equal(t, `{"status": "ok"}`, res.Body.String())
 }
+
+func TestWrapErrHandlerFunc(t *testing.T) {
+	k := New()
+
+	k.Get("/ok", WrapErrHandlerFunc(func(c *Context) error {
+		c.String(http.StatusOK, "ok")
+		return nil
+	}))
+
+	k.Get("/error", WrapErrHandlerFunc(func(c *Context) error {
+		return errors.New("error")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/ok", nil)
+	res := httptest.NewRecorder()
+
+	k.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "ok", res.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/error", nil)
+	res = httptest.NewRecorder()
+
+	k.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+	assert.Equal(t, "{\"message\":\"Internal Server Error\"}\n", res.Body.String())
+}
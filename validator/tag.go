@@ -0,0 +1,338 @@
+package validator
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// tagName is the struct tag which holds the validation rules.
+const tagName = "validate"
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// regexCache caches the compiled patterns of regex rules.
+var regexCache sync.Map
+
+type (
+	// defaultValidator is Kid's default validator which validates structs by their `validate` tags.
+	defaultValidator struct {
+	}
+
+	// rule is a parsed validation rule.
+	rule struct {
+		name  string
+		param string
+		num   float64
+		regex *regexp.Regexp
+	}
+)
+
+// Verifying interface compliance.
+var _ Validator = defaultValidator{}
+
+// New returns a new tag based validator.
+//
+// Rules are separated by commas in the `validate` tag, e.g. `validate:"required,min=3,max=32"`.
+// Supported rules are:
+//   - required: the value must not be zero. Slices and maps must not be empty.
+//   - omitempty: skips the rest of the rules if the value is zero.
+//   - min=n, max=n: minimum and maximum of numbers, or length of strings, slices and maps.
+//   - len=n: exact length of strings, slices and maps.
+//   - oneof=a b c: the value must be one of the space separated values.
+//   - email, url: the string must be a valid email address or absolute URL.
+//   - regex=pattern: the string must match the pattern. It must be the last rule since the pattern can contain commas.
+//   - dive: applies the rest of the rules to the elements of slices, arrays and maps.
+//
+// Nested structs, pointers to structs and structs inside dived collections are validated recursively.
+// Fields are reported by their JSON names if they have any.
+//
+// Validate panics if the value is not a struct or a rule is invalid, e.g. min on a bool field.
+func New() Validator {
+	return defaultValidator{}
+}
+
+// Validate validates the given struct or pointer to a struct.
+func (defaultValidator) Validate(v any) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		panic("validation target must be a struct or a pointer to a struct")
+	}
+
+	var errs ValidationErrors
+	validateStruct(rv, "", &errs)
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// validateStruct validates the fields of the struct.
+func validateStruct(v reflect.Value, path string, errs *ValidationErrors) {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+
+		tag := field.Tag.Get(tagName)
+		if tag == "-" {
+			continue
+		}
+
+		// Fields of embedded structs are reported as if they were the parent's own fields.
+		fieldPath := path
+		if !field.Anonymous || tag != "" {
+			fieldPath = joinPath(path, fieldName(field))
+		}
+
+		validateValue(v.Field(i), fieldPath, parseRules(tag), errs)
+	}
+}
+
+// validateValue applies the rules to the value and validates it recursively if it's a struct.
+//
+// Only the first failing rule of a value is reported.
+func validateValue(v reflect.Value, path string, rules []rule, errs *ValidationErrors) {
+	for i, r := range rules {
+		switch r.name {
+		case "omitempty":
+			if isEmpty(v) {
+				return
+			}
+		case "dive":
+			dive(indirect(v), path, rules[i+1:], errs)
+			return
+		default:
+			if message, ok := check(r, v); !ok {
+				*errs = append(*errs, FieldError{Field: path, Rule: r.name, Param: r.param, Message: message})
+				return
+			}
+		}
+	}
+
+	if elem := indirect(v); elem.Kind() == reflect.Struct && elem.Type() != timeType {
+		validateStruct(elem, path, errs)
+	}
+}
+
+// dive validates the elements of the collection with the given rules.
+func dive(v reflect.Value, path string, rules []rule, errs *ValidationErrors) {
+	switch v.Kind() {
+	case reflect.Invalid:
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			validateValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i), rules, errs)
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+		})
+
+		for _, key := range keys {
+			validateValue(v.MapIndex(key), fmt.Sprintf("%s[%v]", path, key), rules, errs)
+		}
+	default:
+		panic(fmt.Sprintf("validation rule dive cannot be used on %s", v.Type()))
+	}
+}
+
+// check checks the rule against the value and returns the error message if it fails.
+//
+// Rules other than required pass for nil pointers.
+func check(r rule, v reflect.Value) (string, bool) {
+	if r.name == "required" {
+		return "is required", !isEmpty(v)
+	}
+
+	v = indirect(v)
+	if !v.IsValid() {
+		return "", true
+	}
+
+	switch r.name {
+	case "min":
+		return checkSize(r, v, "at least", func(n float64) bool { return n >= r.num })
+	case "max":
+		return checkSize(r, v, "at most", func(n float64) bool { return n <= r.num })
+	case "len":
+		if isNumber(v) {
+			panic(fmt.Sprintf("validation rule len cannot be used on %s", v.Type()))
+		}
+		return checkSize(r, v, "exactly", func(n float64) bool { return n == r.num })
+	case "oneof":
+		value := fmt.Sprint(v.Interface())
+		for _, option := range strings.Fields(r.param) {
+			if value == option {
+				return "", true
+			}
+		}
+		return fmt.Sprintf("must be one of %s", strings.Join(strings.Fields(r.param), ", ")), false
+	case "email":
+		s := stringValue(r, v)
+		addr, err := mail.ParseAddress(s)
+		return "must be a valid email address", err == nil && addr.Address == s
+	case "url":
+		u, err := url.Parse(stringValue(r, v))
+		return "must be a valid URL", err == nil && u.Scheme != "" && u.Host != ""
+	case "regex":
+		return fmt.Sprintf("must match %s", r.param), r.regex.MatchString(stringValue(r, v))
+	}
+
+	return "", true
+}
+
+// checkSize checks the size of the value, which is the value itself for numbers and the length for the others.
+func checkSize(r rule, v reflect.Value, qualifier string, ok func(float64) bool) (string, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return fmt.Sprintf("must be %s %s characters long", qualifier, r.param), ok(float64(utf8.RuneCountInString(v.String())))
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return fmt.Sprintf("must contain %s %s items", qualifier, r.param), ok(float64(v.Len()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return fmt.Sprintf("must be %s %s", qualifier, r.param), ok(float64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return fmt.Sprintf("must be %s %s", qualifier, r.param), ok(float64(v.Uint()))
+	case reflect.Float32, reflect.Float64:
+		return fmt.Sprintf("must be %s %s", qualifier, r.param), ok(v.Float())
+	default:
+		panic(fmt.Sprintf("validation rule %s cannot be used on %s", r.name, v.Type()))
+	}
+}
+
+// parseRules parses the rules of a validate tag.
+//
+// Panics if a rule is unknown or its parameter is invalid.
+func parseRules(tag string) []rule {
+	if tag == "" {
+		return nil
+	}
+
+	var rules []rule
+
+	for tag != "" {
+		var part string
+		if strings.HasPrefix(tag, "regex=") {
+			// The pattern can contain commas, so it takes the rest of the tag.
+			part, tag = tag, ""
+		} else {
+			part, tag, _ = strings.Cut(tag, ",")
+		}
+
+		name, param, _ := strings.Cut(part, "=")
+		r := rule{name: name, param: param}
+
+		switch name {
+		case "required", "omitempty", "dive", "email", "url":
+		case "min", "max", "len":
+			num, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				panic(fmt.Sprintf("invalid parameter %q of validation rule %s", param, name))
+			}
+			r.num = num
+		case "oneof":
+			if strings.TrimSpace(param) == "" {
+				panic("validation rule oneof requires at least one value")
+			}
+		case "regex":
+			r.regex = compileRegex(param)
+		default:
+			panic(fmt.Sprintf("unknown validation rule %q", name))
+		}
+
+		rules = append(rules, r)
+	}
+
+	return rules
+}
+
+// compileRegex compiles the pattern of a regex rule, or returns it from the cache.
+func compileRegex(pattern string) *regexp.Regexp {
+	if regex, ok := regexCache.Load(pattern); ok {
+		return regex.(*regexp.Regexp)
+	}
+
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		panic(fmt.Sprintf("invalid pattern of validation rule regex: %s", err))
+	}
+
+	regexCache.Store(pattern, regex)
+
+	return regex
+}
+
+// fieldName returns the JSON name of the field, or its Go name if it doesn't have any.
+func fieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
+
+// joinPath joins the field name to the path of its parent.
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// indirect dereferences pointers and interfaces, it returns an invalid value for nil ones.
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// isEmpty checks if the value is zero. Empty slices and maps are also considered as empty.
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
+
+// isNumber checks if the value is a number.
+func isNumber(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// stringValue returns the string value of v, panics if it's not a string.
+func stringValue(r rule, v reflect.Value) string {
+	if v.Kind() != reflect.String {
+		panic(fmt.Sprintf("validation rule %s cannot be used on %s", r.name, v.Type()))
+	}
+	return v.String()
+}
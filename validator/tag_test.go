@@ -0,0 +1,194 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type address struct {
+	City    string `json:"city" validate:"required"`
+	ZipCode string `json:"zip_code" validate:"omitempty,regex=^[0-9]{5}(-[0-9]{4})?$"`
+}
+
+type Audit struct {
+	CreatedBy string `validate:"required"`
+}
+
+type user struct {
+	Audit
+
+	Name     string            `json:"name" validate:"required,min=3,max=8"`
+	Email    string            `json:"email,omitempty" validate:"required,email"`
+	Website  string            `json:"website" validate:"omitempty,url"`
+	Age      int               `json:"age" validate:"min=18,max=130"`
+	Score    *float64          `json:"score" validate:"omitempty,max=10"`
+	Role     string            `json:"role" validate:"oneof=admin user"`
+	Code     string            `json:"code" validate:"len=4"`
+	Tags     []string          `json:"tags" validate:"max=3,dive,required,max=5"`
+	Address  address           `json:"address"`
+	Billing  *address          `json:"billing"`
+	Contacts []address         `json:"contacts" validate:"dive"`
+	Labels   map[string]string `json:"labels" validate:"dive,oneof=a b"`
+	Ignored  string            `validate:"-"`
+	private  string            `validate:"required"`
+}
+
+func validUser() user {
+	return user{
+		Audit:   Audit{CreatedBy: "admin"},
+		Name:    "john",
+		Email:   "john@example.com",
+		Age:     30,
+		Role:    "admin",
+		Code:    "ABCD",
+		Tags:    []string{"go"},
+		Address: address{City: "Berlin", ZipCode: "10115"},
+	}
+}
+
+func TestNew(t *testing.T) {
+	validator := New()
+
+	assert.NotNil(t, validator)
+	assert.IsType(t, defaultValidator{}, validator)
+}
+
+func TestDefaultValidator_Validate(t *testing.T) {
+	validator := New()
+
+	u := validUser()
+	assert.NoError(t, validator.Validate(u))
+	assert.NoError(t, validator.Validate(&u))
+
+	score := 11.5
+
+	u = user{
+		Name:     "jo",
+		Email:    "John <john@example.com>",
+		Website:  "example.com",
+		Age:      17,
+		Score:    &score,
+		Role:     "root",
+		Code:     "ABC",
+		Tags:     []string{"golang", "", "a", "b"},
+		Address:  address{ZipCode: "123"},
+		Billing:  &address{},
+		Contacts: []address{{City: "Paris"}, {}},
+		Labels:   map[string]string{"x": "a", "y": "c"},
+	}
+
+	err := validator.Validate(&u)
+
+	assert.Equal(t, ValidationErrors{
+		{Field: "CreatedBy", Rule: "required", Message: "is required"},
+		{Field: "name", Rule: "min", Param: "3", Message: "must be at least 3 characters long"},
+		{Field: "email", Rule: "email", Message: "must be a valid email address"},
+		{Field: "website", Rule: "url", Message: "must be a valid URL"},
+		{Field: "age", Rule: "min", Param: "18", Message: "must be at least 18"},
+		{Field: "score", Rule: "max", Param: "10", Message: "must be at most 10"},
+		{Field: "role", Rule: "oneof", Param: "admin user", Message: "must be one of admin, user"},
+		{Field: "code", Rule: "len", Param: "4", Message: "must be exactly 4 characters long"},
+		{Field: "tags", Rule: "max", Param: "3", Message: "must contain at most 3 items"},
+		{Field: "address.city", Rule: "required", Message: "is required"},
+		{Field: "address.zip_code", Rule: "regex", Param: "^[0-9]{5}(-[0-9]{4})?$", Message: "must match ^[0-9]{5}(-[0-9]{4})?$"},
+		{Field: "billing.city", Rule: "required", Message: "is required"},
+		{Field: "contacts[1].city", Rule: "required", Message: "is required"},
+		{Field: "labels[y]", Rule: "oneof", Param: "a b", Message: "must be one of a, b"},
+	}, err)
+}
+
+func TestDefaultValidator_Validate_Dive(t *testing.T) {
+	validator := New()
+
+	u := validUser()
+	u.Tags = []string{"golang", ""}
+
+	err := validator.Validate(u)
+
+	assert.Equal(t, ValidationErrors{
+		{Field: "tags[0]", Rule: "max", Param: "5", Message: "must be at most 5 characters long"},
+		{Field: "tags[1]", Rule: "required", Message: "is required"},
+	}, err)
+}
+
+func TestDefaultValidator_Validate_Required(t *testing.T) {
+	var v struct {
+		Pointer *int           `validate:"required"`
+		Slice   []int          `validate:"required"`
+		Map     map[string]int `validate:"required"`
+		Number  int            `validate:"required"`
+		Bool    bool           `validate:"required"`
+	}
+
+	v.Slice = []int{}
+
+	err := New().Validate(v)
+
+	assert.Equal(t, ValidationErrors{
+		{Field: "Pointer", Rule: "required", Message: "is required"},
+		{Field: "Slice", Rule: "required", Message: "is required"},
+		{Field: "Map", Rule: "required", Message: "is required"},
+		{Field: "Number", Rule: "required", Message: "is required"},
+		{Field: "Bool", Rule: "required", Message: "is required"},
+	}, err)
+}
+
+func TestDefaultValidator_Validate_Panics(t *testing.T) {
+	validator := New()
+
+	for _, v := range []any{nil, 1, (*user)(nil), []user{}} {
+		assert.PanicsWithValue(t, "validation target must be a struct or a pointer to a struct", func() {
+			_ = validator.Validate(v)
+		})
+	}
+
+	testCases := []struct {
+		v        any
+		expected string
+	}{
+		{v: struct {
+			A string `validate:"unknown"`
+		}{}, expected: "unknown validation rule \"unknown\""},
+		{v: struct {
+			A string `validate:"min=abc"`
+		}{}, expected: "invalid parameter \"abc\" of validation rule min"},
+		{v: struct {
+			A string `validate:"oneof="`
+		}{}, expected: "validation rule oneof requires at least one value"},
+		{v: struct {
+			A string `validate:"regex=[a-z"`
+		}{}, expected: "invalid pattern of validation rule regex: error parsing regexp: missing closing ]: `[a-z`"},
+		{v: struct {
+			A bool `validate:"min=1"`
+		}{}, expected: "validation rule min cannot be used on bool"},
+		{v: struct {
+			A int `validate:"len=1"`
+		}{}, expected: "validation rule len cannot be used on int"},
+		{v: struct {
+			A int `validate:"email"`
+		}{}, expected: "validation rule email cannot be used on int"},
+		{v: struct {
+			A int `validate:"dive"`
+		}{}, expected: "validation rule dive cannot be used on int"},
+	}
+
+	for _, tc := range testCases {
+		assert.PanicsWithValue(t, tc.expected, func() {
+			_ = validator.Validate(tc.v)
+		})
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	assert.Nil(t, parseRules(""))
+
+	rules := parseRules("required,min=3,regex=^[a-z]{1,3}$")
+
+	assert.Len(t, rules, 3)
+	assert.Equal(t, rule{name: "required"}, rules[0])
+	assert.Equal(t, rule{name: "min", param: "3", num: 3}, rules[1])
+	assert.Equal(t, "regex", rules[2].name)
+	assert.Equal(t, "^[a-z]{1,3}$", rules[2].param)
+	assert.Same(t, rules[2].regex, compileRegex("^[a-z]{1,3}$"))
+}
@@ -0,0 +1,60 @@
+// Package validator provides an interface to validate request data.
+//
+// The default validator validates structs by their `validate` tags.
+package validator
+
+import (
+	"strings"
+)
+
+// Validator is the interface for validating structs.
+//
+// It can be implemented to use custom validators.
+type Validator interface {
+	// Validate validates the given struct or pointer to a struct.
+	//
+	// Should return ValidationErrors if the struct is invalid, so the error can be rendered field by field.
+	Validate(v any) error
+}
+
+type (
+	// FieldError describes why a field is invalid.
+	FieldError struct {
+		// Field is the path of the invalid field, e.g. items[0].name.
+		// JSON names of the fields are used if they have any.
+		Field string `json:"field" xml:"field"`
+
+		// Rule is the failed validation rule, e.g. required.
+		Rule string `json:"rule" xml:"rule"`
+
+		// Param is the parameter of the rule, e.g. 3 in min=3.
+		Param string `json:"param,omitempty" xml:"param,omitempty"`
+
+		// Message is a human-readable description of the error.
+		Message string `json:"message" xml:"message"`
+	}
+
+	// ValidationErrors is the list of field errors returned when validation fails.
+	ValidationErrors []FieldError
+)
+
+// Verifying interface compliance.
+var (
+	_ error = FieldError{}
+	_ error = ValidationErrors{}
+)
+
+// Error implements the error interface.
+func (e FieldError) Error() string {
+	return e.Field + " " + e.Message
+}
+
+// Error implements the error interface.
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+
+	return strings.Join(messages, "; ")
+}
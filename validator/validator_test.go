@@ -0,0 +1,21 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFieldError_Error(t *testing.T) {
+	err := FieldError{Field: "email", Rule: "required", Message: "is required"}
+	assert.EqualError(t, err, "email is required")
+}
+
+func TestValidationErrors_Error(t *testing.T) {
+	errs := ValidationErrors{
+		{Field: "email", Rule: "required", Message: "is required"},
+		{Field: "age", Rule: "min", Param: "18", Message: "must be at least 18"},
+	}
+
+	assert.EqualError(t, errs, "email is required; age must be at least 18")
+}